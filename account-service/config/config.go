@@ -2,51 +2,83 @@ package config
 
 import (
 	"log/slog"
-	"os"
 	"strings"
 
-	"github.com/joho/godotenv"
+	"securepay/pkg/envconfig"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Port         string
-	DatabaseURL  string
-	KafkaBrokers []string
-	KafkaTopic   string
+	Port          string
+	DatabaseURL   string
+	KafkaBrokers  []string
+	KafkaTopic    string
+	ResultsTopic  string
 	SpiffeSocket  string
 	RedisAddr     string
 	RedisPassword string
+	// HouseAccounts maps a currency code to the account credited with the
+	// fees of payments in that currency.
+	HouseAccounts map[string]string
+	// PrincipalIssuers are the SPIFFE IDs allowed to sign caller principals.
+	PrincipalIssuers []string
+	// HealthPort serves /livez and /readyz over plain HTTP for probes.
+	HealthPort string
+	// ClientSpiffeIDs are the SPIFFE IDs allowed to connect over mTLS; empty
+	// accepts any workload of a trusted domain.
+	ClientSpiffeIDs []string
+	LogLevel        slog.Level
+	// TraceSampleRatio is the fraction of root spans sampled, from 0 to 1,
+	// unless OTEL_TRACES_SAMPLER is set.
+	TraceSampleRatio float64
 }
 
 // Load loads the configuration from environment variables
-func Load() *Config {
+func Load() (*Config, error) {
 	// Load .env file if exists (optional)
-	if err := godotenv.Load(); err != nil {
-		slog.Warn("No .env file found, using system environment variables")
-	}
+	envconfig.LoadDotEnv()
 
-	return &Config{
-		Port:         getEnv("PORT", ":8082"), // Default 8082 for Account Service
-		DatabaseURL:  getEnv("DATABASE_URL", ""),
-		KafkaBrokers: getEnvList("KAFKA_BROKERS", []string{"localhost:9092"}),
-		KafkaTopic:   getEnv("KAFKA_TOPIC", "payment.initiated"),
-		SpiffeSocket:  getEnv("SPIFFE_ENDPOINT_SOCKET", "unix:///tmp/spire-agent/public/api.sock"),
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+	cfg := &Config{
+		Port:             ":8082", // Default 8082 for Account Service
+		KafkaBrokers:     []string{"localhost:9092"},
+		KafkaTopic:       "payment.initiated",
+		ResultsTopic:     "payment.results",
+		SpiffeSocket:     "unix:///tmp/spire-agent/public/api.sock",
+		RedisAddr:        "localhost:6379",
+		PrincipalIssuers: []string{"spiffe://securepay.dev/api-gateway"},
+		HealthPort:       ":9082",
+		ClientSpiffeIDs:  []string{"spiffe://securepay.dev/api-gateway"},
+		LogLevel:         slog.LevelInfo,
+		TraceSampleRatio: 1,
 	}
-}
 
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	var env envconfig.Loader
+	env.String("PORT", &cfg.Port)
+	env.String("DATABASE_URL", &cfg.DatabaseURL)
+	env.List("KAFKA_BROKERS", &cfg.KafkaBrokers)
+	env.String("KAFKA_TOPIC", &cfg.KafkaTopic)
+	env.String("PAYMENT_RESULTS_TOPIC", &cfg.ResultsTopic)
+	env.String("SPIFFE_ENDPOINT_SOCKET", &cfg.SpiffeSocket)
+	env.String("REDIS_ADDR", &cfg.RedisAddr)
+	env.String("REDIS_PASSWORD", &cfg.RedisPassword)
+	env.Map("HOUSE_ACCOUNTS", &cfg.HouseAccounts)
+	env.List("PRINCIPAL_ISSUERS", &cfg.PrincipalIssuers)
+	env.String("HEALTH_PORT", &cfg.HealthPort)
+	env.List("CLIENT_SPIFFE_IDS", &cfg.ClientSpiffeIDs)
+	env.Level("LOG_LEVEL", &cfg.LogLevel)
+	env.Float("TRACE_SAMPLE_RATIO", &cfg.TraceSampleRatio)
+	if err := env.Err(); err != nil {
+		return nil, err
 	}
-	return fallback
+	cfg.HouseAccounts = upperKeys(cfg.HouseAccounts)
+	return cfg, nil
 }
 
-func getEnvList(key string, fallback []string) []string {
-	if value, exists := os.LookupEnv(key); exists {
-		return strings.Split(value, ",")
+// upperKeys returns m with its currency code keys in upper case.
+func upperKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[strings.ToUpper(k)] = v
 	}
-	return fallback
+	return out
 }
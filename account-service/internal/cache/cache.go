@@ -20,6 +20,8 @@ type Cache interface {
 	GetBalance(ctx context.Context, accountID string) (*BalanceEntry, error)
 	SetBalance(ctx context.Context, accountID string, entry *BalanceEntry) error
 	DeleteBalance(ctx context.Context, accountID string) error
+	// Ping checks connectivity to the cache.
+	Ping(ctx context.Context) error
 }
 
 type redisCache struct {
@@ -85,3 +87,11 @@ func (r *redisCache) DeleteBalance(ctx context.Context, accountID string) error
 	}
 	return nil
 }
+
+// Ping checks connectivity to Redis.
+func (r *redisCache) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping error: %w", err)
+	}
+	return nil
+}
@@ -6,15 +6,15 @@ import (
 
 	"go.opentelemetry.io/otel"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
 	"securepay/account-service/internal/cache"
 	"securepay/account-service/internal/repository"
+	"securepay/pkg/money"
+	"securepay/pkg/principal"
 	pb "securepay/proto/gen/go/account/v1"
 )
 
-// AccountHandler implements pb.AccountServiceServer
+// AccountHandler implements pb.AccountServiceServer. Balances are read
+// through the v2 implementation in v2.go.
 type AccountHandler struct {
 	pb.UnimplementedAccountServiceServer
 	repo  repository.Repository
@@ -31,46 +31,17 @@ func (h *AccountHandler) CheckBalance(ctx context.Context, req *pb.CheckBalanceR
 	ctx, span := otel.Tracer("account-service").Start(ctx, "handler.CheckBalance")
 	defer span.End()
 
-	slog.InfoContext(ctx, "CheckBalance called", "account_id", req.AccountId)
-
-	if req.AccountId == "" {
-		return nil, status.Error(codes.InvalidArgument, "account_id is required")
-	}
-
-	// 1. Check Redis cache
-	entry, err := h.cache.GetBalance(ctx, req.AccountId)
-	if err != nil {
-		slog.WarnContext(ctx, "Cache get failed, falling back to DB", "error", err)
-	}
-	if entry != nil {
-		slog.InfoContext(ctx, "Cache hit", "account_id", req.AccountId)
-		return &pb.CheckBalanceResponse{
-			AccountId: req.AccountId,
-			Balance:   entry.Balance,
-			Currency:  entry.Currency,
-		}, nil
-	}
+	caller, _ := principal.FromContext(ctx)
+	slog.InfoContext(ctx, "CheckBalance called", "account_id", req.AccountId, "caller", caller.Subject)
 
-	// 2. Cache miss -- fetch from PostgreSQL
-	slog.InfoContext(ctx, "Cache miss", "account_id", req.AccountId)
-	acc, err := h.repo.GetAccount(ctx, req.AccountId)
+	balance, err := h.getBalance(ctx, req.AccountId)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get account", "error", err)
-		return nil, status.Errorf(codes.NotFound, "account not found: %v", err)
-	}
-
-	// 3. Write to Redis cache with TTL 60s
-	cacheEntry := &cache.BalanceEntry{
-		Balance:  acc.Balance,
-		Currency: acc.Currency,
-	}
-	if err := h.cache.SetBalance(ctx, req.AccountId, cacheEntry); err != nil {
-		slog.WarnContext(ctx, "Failed to set cache", "error", err)
+		return nil, err
 	}
 
 	return &pb.CheckBalanceResponse{
-		AccountId: acc.ID,
-		Balance:   acc.Balance,
-		Currency:  acc.Currency,
+		AccountId: balance.AccountId,
+		Balance:   money.FromMinorUnits(balance.Available.GetMinorUnits()),
+		Currency:  balance.Available.GetCurrency(),
 	}, nil
 }
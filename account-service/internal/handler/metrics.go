@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_lookups_total",
+	Help: "Redis lookups by cache and result (hit, miss or error).",
+}, []string{"cache", "result"})
+
+// recordBalanceLookup counts a lookup of a cached balance.
+func recordBalanceLookup(hit bool, err error) {
+	result := "miss"
+	switch {
+	case err != nil:
+		result = "error"
+	case hit:
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues("balance", result).Inc()
+}
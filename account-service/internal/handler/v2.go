@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/account-service/internal/cache"
+	"securepay/pkg/money"
+	"securepay/pkg/principal"
+	pbv2 "securepay/proto/gen/go/account/v2"
+)
+
+// AccountHandlerV2 implements pbv2.AccountServiceServer. The v1
+// CheckBalance of AccountHandler translates to getBalance.
+type AccountHandlerV2 struct {
+	pbv2.UnimplementedAccountServiceServer
+	h *AccountHandler
+}
+
+// NewAccountHandlerV2 creates an AccountHandlerV2 sharing the dependencies of h
+func NewAccountHandlerV2(h *AccountHandler) *AccountHandlerV2 {
+	return &AccountHandlerV2{h: h}
+}
+
+// GetBalance returns the balance of an account in minor units
+func (v *AccountHandlerV2) GetBalance(ctx context.Context, req *pbv2.GetBalanceRequest) (*pbv2.Balance, error) {
+	ctx, span := otel.Tracer("account-service").Start(ctx, "handler.v2.GetBalance")
+	defer span.End()
+
+	caller, _ := principal.FromContext(ctx)
+	slog.InfoContext(ctx, "GetBalance called", "account_id", req.AccountId, "caller", caller.Subject)
+
+	return v.h.getBalance(ctx, req.AccountId)
+}
+
+// getBalance reads the balance with the read-aside cache pattern
+func (h *AccountHandler) getBalance(ctx context.Context, accountID string) (*pbv2.Balance, error) {
+	if accountID == "" {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+
+	// 1. Check Redis cache
+	entry, err := h.cache.GetBalance(ctx, accountID)
+	recordBalanceLookup(entry != nil, err)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache get failed, falling back to DB", "error", err)
+	}
+	if entry != nil {
+		slog.InfoContext(ctx, "Cache hit", "account_id", accountID)
+		return toProtoBalanceV2(accountID, entry.Balance, entry.Currency), nil
+	}
+
+	// 2. Cache miss -- fetch from PostgreSQL
+	slog.InfoContext(ctx, "Cache miss", "account_id", accountID)
+	acc, err := h.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get account", "error", err)
+		return nil, status.Errorf(codes.NotFound, "account not found: %v", err)
+	}
+
+	// 3. Write to Redis cache with TTL 60s
+	cacheEntry := &cache.BalanceEntry{
+		Balance:  acc.Balance,
+		Currency: acc.Currency,
+	}
+	if err := h.cache.SetBalance(ctx, accountID, cacheEntry); err != nil {
+		slog.WarnContext(ctx, "Failed to set cache", "error", err)
+	}
+
+	return toProtoBalanceV2(acc.ID, acc.Balance, acc.Currency), nil
+}
+
+func toProtoBalanceV2(accountID string, balance float64, currency string) *pbv2.Balance {
+	return &pbv2.Balance{
+		AccountId: accountID,
+		Available: &pbv2.Money{MinorUnits: money.ToMinorUnits(balance), Currency: currency},
+	}
+}
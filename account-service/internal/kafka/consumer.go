@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"securepay/account-service/internal/cache"
 	"securepay/account-service/internal/repository"
 	"securepay/account-service/models"
+	"securepay/pkg/metrics"
 )
 
 type Consumer struct {
@@ -32,7 +34,10 @@ func NewConsumer(cfg *config.Config) *Consumer {
 	return &Consumer{reader: reader}
 }
 
-func (c *Consumer) Start(ctx context.Context, repo repository.Repository, balanceCache cache.Cache) {
+// Start consumes payment.initiated events, applies them to the balances and
+// publishes the outcome through results so payment-service can update the
+// payment status.
+func (c *Consumer) Start(ctx context.Context, repo repository.Repository, balanceCache cache.Cache, results *Producer) {
 	slog.InfoContext(ctx, "Starting Kafka Consumer", "topic", c.reader.Config().Topic)
 	go func() {
 		for {
@@ -70,39 +75,26 @@ func (c *Consumer) Start(ctx context.Context, repo repository.Repository, balanc
 				if err := json.Unmarshal(m.Value, &event); err != nil {
 					slog.ErrorContext(msgCtx, "Failed to unmarshal event", "error", err)
 					span.RecordError(err)
+					metrics.ObserveKafkaConsume(c.reader, m, err)
 					span.End()
 					c.reader.CommitMessages(msgCtx, m)
 					continue
 				}
 
-				// Process Payment (Deduct Balance)
-				err = repo.ProcessPayment(msgCtx, event.FromAccount, event.ToAccount, event.Amount)
-				if err != nil {
-					slog.ErrorContext(msgCtx, "Failed to process payment",
-						"error", err,
-						"payment_id", event.PaymentID,
-					)
-				} else {
-					slog.InfoContext(msgCtx, "Payment processed successfully", "payment_id", event.PaymentID)
-
-					// Invalidate Redis cache for both accounts
-					if delErr := balanceCache.DeleteBalance(msgCtx, event.FromAccount); delErr != nil {
-						slog.WarnContext(msgCtx, "Failed to invalidate cache for from_account",
-							"account_id", event.FromAccount,
-							"error", delErr,
-						)
-					} else {
-						slog.InfoContext(msgCtx, "Cache invalidated", "account_id", event.FromAccount)
-					}
+				result := process(msgCtx, repo, balanceCache, event)
 
-					if delErr := balanceCache.DeleteBalance(msgCtx, event.ToAccount); delErr != nil {
-						slog.WarnContext(msgCtx, "Failed to invalidate cache for to_account",
-							"account_id", event.ToAccount,
-							"error", delErr,
-						)
-					} else {
-						slog.InfoContext(msgCtx, "Cache invalidated", "account_id", event.ToAccount)
-					}
+				// Report the outcome to payment-service. A failed payment is
+				// still a processed message; only a lost result is an error.
+				// The offset is committed once the result is sent, so that
+				// a result is never lost with the message.
+				err = publishResult(msgCtx, results, result)
+				metrics.ObserveKafkaConsume(c.reader, m, err)
+				if err != nil {
+					// Shutting down: leave the message to be redelivered,
+					// which repeats the recorded result
+					span.RecordError(err)
+					span.End()
+					return
 				}
 
 				// Commit message after processing
@@ -116,6 +108,107 @@ func (c *Consumer) Start(ctx context.Context, repo repository.Repository, balanc
 	}()
 }
 
+// publishResult sends result, retrying with backoff until it is sent or
+// ctx is done.
+func publishResult(ctx context.Context, results *Producer, result models.PaymentResultEvent) error {
+	backoff := 100 * time.Millisecond
+	for {
+		err := results.ProducePaymentResultEvent(ctx, result)
+		if err == nil {
+			return nil
+		}
+		slog.ErrorContext(ctx, "Failed to produce payment result",
+			"error", err,
+			"payment_id", result.PaymentID,
+			"retry_in", backoff,
+		)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+// process applies event to the balances once and returns its outcome. A
+// redelivered event is not applied again; it yields the recorded outcome, so
+// payment-service still receives the result.
+func process(ctx context.Context, repo repository.Repository, balanceCache cache.Cache, event models.PaymentInitiatedEvent) models.PaymentResultEvent {
+	result := models.PaymentResultEvent{
+		PaymentID: event.PaymentID,
+		Status:    "COMPLETED",
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	outcome, err := repo.PaymentOutcome(ctx, event.PaymentID)
+	if err == nil && outcome == nil {
+		// Process Payment (Deduct Balance); split payments credit all legs atomically
+		if len(event.Legs) > 0 {
+			err = repo.ProcessSplitPayment(ctx, event.PaymentID, event.FromAccount, event.Legs, event.Fee, event.Currency)
+		} else {
+			err = repo.ProcessPayment(ctx, event.PaymentID, event.FromAccount, event.ToAccount, event.Amount, event.Fee, event.Currency)
+		}
+		if errors.Is(err, repository.ErrAlreadyProcessed) {
+			outcome, err = repo.PaymentOutcome(ctx, event.PaymentID)
+		}
+	}
+	if err == nil && outcome != nil {
+		slog.InfoContext(ctx, "Payment already processed, repeating its result",
+			"payment_id", event.PaymentID,
+			"status", outcome.Status,
+		)
+		result.Status = outcome.Status
+		result.Reason = outcome.Reason
+		return result
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to process payment",
+			"error", err,
+			"payment_id", event.PaymentID,
+		)
+		result.Status = "FAILED"
+		result.Reason = err.Error()
+		if recErr := repo.RecordFailedPayment(ctx, event.PaymentID, result.Reason); recErr != nil {
+			slog.WarnContext(ctx, "Failed to record failed payment", "error", recErr, "payment_id", event.PaymentID)
+		}
+		return result
+	}
+
+	slog.InfoContext(ctx, "Payment processed successfully", "payment_id", event.PaymentID)
+
+	// Invalidate Redis cache for every affected account
+	for _, accountID := range affectedAccounts(event, repo.HouseAccount(event.Currency)) {
+		if delErr := balanceCache.DeleteBalance(ctx, accountID); delErr != nil {
+			slog.WarnContext(ctx, "Failed to invalidate cache",
+				"account_id", accountID,
+				"error", delErr,
+			)
+		} else {
+			slog.InfoContext(ctx, "Cache invalidated", "account_id", accountID)
+		}
+	}
+	return result
+}
+
+// affectedAccounts returns the payer followed by every credited account,
+// including houseAccountID when a fee was charged.
+func affectedAccounts(event models.PaymentInitiatedEvent, houseAccountID string) []string {
+	accounts := []string{event.FromAccount}
+	if len(event.Legs) == 0 {
+		accounts = append(accounts, event.ToAccount)
+	}
+	for _, leg := range event.Legs {
+		accounts = append(accounts, leg.ToAccount)
+	}
+	if event.Fee > 0 && houseAccountID != "" {
+		accounts = append(accounts, houseAccountID)
+	}
+	return accounts
+}
+
 func (c *Consumer) Close() error {
 	return c.reader.Close()
 }
@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"securepay/account-service/models"
+	"securepay/pkg/metrics"
+)
+
+// Producer publishes payment results back to payment-service
+type Producer struct {
+	writer *kafka.Writer
+}
+
+// NewProducer creates a new producer
+func NewProducer(brokers []string, topic string) *Producer {
+	slog.Info("Initializing Kafka Producer", "brokers", brokers, "topic", topic)
+
+	return &Producer{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+}
+
+// Close closes the producer
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
+
+// ProducePaymentResultEvent sends the result of processing a payment to Kafka
+func (p *Producer) ProducePaymentResultEvent(ctx context.Context, event models.PaymentResultEvent) error {
+	ctx, span := otel.Tracer("account-service").Start(ctx, "kafka.ProducePaymentResultEvent")
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// Inject Trace Context so payment-service continues the same trace
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	headers := make([]kafka.Header, 0, len(carrier))
+	for k, v := range carrier {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	msg := kafka.Message{
+		Key:     []byte(event.PaymentID),
+		Value:   payload,
+		Headers: headers,
+	}
+
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, msg)
+	metrics.ObserveKafkaProduce(p.writer.Topic, start, err)
+	if err != nil {
+		return fmt.Errorf("failed to write message to kafka: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Produced payment result", "topic", p.writer.Topic, "payment_id", event.PaymentID, "status", event.Status)
+	return nil
+}
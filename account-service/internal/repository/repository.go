@@ -6,28 +6,43 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"securepay/account-service/models"
 
-	"go.opentelemetry.io/otel"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel"
 )
 
 // Repository defines the interface for database operations
 type Repository interface {
 	GetAccount(ctx context.Context, accountID string) (*models.Account, error)
 	UpsertAccount(ctx context.Context, account *models.Account) error
-	ProcessPayment(ctx context.Context, fromAccountID, toAccountID string, amount float64) error
+	ProcessPayment(ctx context.Context, paymentID, fromAccountID, toAccountID string, amount, fee float64, currency string) error
+	ProcessSplitPayment(ctx context.Context, paymentID, fromAccountID string, legs []models.SplitLeg, fee float64, currency string) error
+	// PaymentOutcome returns the recorded outcome of a processed payment, or
+	// a nil outcome when the payment was not processed yet.
+	PaymentOutcome(ctx context.Context, paymentID string) (*models.PaymentOutcome, error)
+	RecordFailedPayment(ctx context.Context, paymentID, reason string) error
+	// HouseAccount returns the account credited with fees in currency.
+	HouseAccount(currency string) string
 }
 
+// ErrAlreadyProcessed is returned by ProcessPayment and ProcessSplitPayment
+// for a payment whose outcome is already recorded.
+var ErrAlreadyProcessed = errors.New("payment already processed")
+
 // PostgresRepository implements Repository
 type PostgresRepository struct {
-	db *sql.DB
+	db            *sql.DB
+	houseAccounts map[string]string
 }
 
-// NewPostgresRepository creates a new PostgresRepository
-func NewPostgresRepository(db *sql.DB) *PostgresRepository {
-	return &PostgresRepository{db: db}
+// NewPostgresRepository creates a new PostgresRepository.
+// Fees are credited within the payment transaction to the house account
+// houseAccounts maps the payment currency to.
+func NewPostgresRepository(db *sql.DB, houseAccounts map[string]string) *PostgresRepository {
+	return &PostgresRepository{db: db, houseAccounts: houseAccounts}
 }
 
 // GetAccount fetches account details by ID
@@ -79,9 +94,11 @@ func (r *PostgresRepository) UpsertAccount(ctx context.Context, account *models.
 	return nil
 }
 
-
-// ProcessPayment handles the transactional balance update
-func (r *PostgresRepository) ProcessPayment(ctx context.Context, fromAccountID, toAccountID string, amount float64) error {
+// ProcessPayment handles the transactional balance update.
+// The payer is debited amount + fee; the fee is credited to the house account.
+// The payment is recorded as COMPLETED in the same transaction, so a
+// redelivered event returns ErrAlreadyProcessed instead of moving money twice.
+func (r *PostgresRepository) ProcessPayment(ctx context.Context, paymentID, fromAccountID, toAccountID string, amount, fee float64, currency string) (err error) {
 	ctx, span := otel.Tracer("account-service").Start(ctx, "postgres.ProcessPayment")
 	defer span.End()
 
@@ -101,6 +118,11 @@ func (r *PostgresRepository) ProcessPayment(ctx context.Context, fromAccountID,
 		}
 	}()
 
+	// 0. Record the payment; a recorded one was applied before
+	if err = recordCompleted(ctx, tx, paymentID); err != nil {
+		return err
+	}
+
 	// 1. Lock From Account and Check Balance
 	var fromBalance float64
 	err = tx.QueryRowContext(ctx, "SELECT balance FROM accounts.balances WHERE account_id = $1 FOR UPDATE", fromAccountID).Scan(&fromBalance)
@@ -111,12 +133,12 @@ func (r *PostgresRepository) ProcessPayment(ctx context.Context, fromAccountID,
 		return fmt.Errorf("failed to lock from_account: %w", err)
 	}
 
-	if fromBalance < amount {
+	if fromBalance < amount+fee {
 		return fmt.Errorf("insufficient funds")
 	}
 
-	// 2. Deduct from From Account
-	_, err = tx.ExecContext(ctx, "UPDATE accounts.balances SET balance = balance - $1, version = version + 1, updated_at = NOW() WHERE account_id = $2", amount, fromAccountID)
+	// 2. Deduct amount and fee from From Account
+	_, err = tx.ExecContext(ctx, "UPDATE accounts.balances SET balance = balance - $1, version = version + 1, updated_at = NOW() WHERE account_id = $2", amount+fee, fromAccountID)
 	if err != nil {
 		return fmt.Errorf("failed to deduct balance: %w", err)
 	}
@@ -129,7 +151,7 @@ func (r *PostgresRepository) ProcessPayment(ctx context.Context, fromAccountID,
 	if err != nil {
 		return fmt.Errorf("failed to credit balance: %w", err)
 	}
-	
+
 	rows, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to check rows affected: %w", err)
@@ -138,6 +160,189 @@ func (r *PostgresRepository) ProcessPayment(ctx context.Context, fromAccountID,
 		return fmt.Errorf("to_account not found")
 	}
 
-	slog.InfoContext(ctx, "Successfully processed payment", "from", fromAccountID, "to", toAccountID, "amount", amount)
+	// 4. Credit the fee to the house account
+	if err = r.creditFee(ctx, tx, fee, currency); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully processed payment", "from", fromAccountID, "to", toAccountID, "amount", amount, "fee", fee)
 	return nil
 }
+
+// ProcessSplitPayment debits the total of all legs plus the fee from the payer
+// and credits every recipient and the house account in a single transaction:
+// either all credits apply or none. Like ProcessPayment, it records the
+// payment and returns ErrAlreadyProcessed for a redelivered event.
+func (r *PostgresRepository) ProcessSplitPayment(ctx context.Context, paymentID, fromAccountID string, legs []models.SplitLeg, fee float64, currency string) (err error) {
+	ctx, span := otel.Tracer("account-service").Start(ctx, "postgres.ProcessSplitPayment")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// 0. Record the payment; a recorded one was applied before
+	if err = recordCompleted(ctx, tx, paymentID); err != nil {
+		return err
+	}
+
+	var total float64
+	for _, leg := range legs {
+		total += leg.Amount
+	}
+
+	// 1. Lock From Account and Check Balance
+	var fromBalance float64
+	err = tx.QueryRowContext(ctx, "SELECT balance FROM accounts.balances WHERE account_id = $1 FOR UPDATE", fromAccountID).Scan(&fromBalance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("from_account not found")
+		}
+		return fmt.Errorf("failed to lock from_account: %w", err)
+	}
+
+	if fromBalance < total+fee {
+		return fmt.Errorf("insufficient funds")
+	}
+
+	// 2. Deduct the total and fee from From Account
+	_, err = tx.ExecContext(ctx, "UPDATE accounts.balances SET balance = balance - $1, version = version + 1, updated_at = NOW() WHERE account_id = $2", total+fee, fromAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to deduct balance: %w", err)
+	}
+
+	// 3. Credit every leg; a missing recipient rolls back the whole split
+	for i, leg := range legs {
+		res, err := tx.ExecContext(ctx, "UPDATE accounts.balances SET balance = balance + $1, version = version + 1, updated_at = NOW() WHERE account_id = $2", leg.Amount, leg.ToAccount)
+		if err != nil {
+			return fmt.Errorf("failed to credit leg %d: %w", i+1, err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("leg %d: to_account %s not found", i+1, leg.ToAccount)
+		}
+	}
+
+	// 4. Credit the fee to the house account
+	if err := r.creditFee(ctx, tx, fee, currency); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully processed split payment", "from", fromAccountID, "legs", len(legs), "total", total, "fee", fee)
+	return nil
+}
+
+// HouseAccount returns the account credited with fees in currency, or ""
+// when none is configured.
+func (r *PostgresRepository) HouseAccount(currency string) string {
+	return r.houseAccounts[strings.ToUpper(currency)]
+}
+
+// creditFee credits fee to the house account of currency inside tx. It is a
+// no-op for zero fees and fails, rolling back the payment, when a fee is due
+// but no house account is configured for the currency or the configured
+// account holds a different currency.
+func (r *PostgresRepository) creditFee(ctx context.Context, tx *sql.Tx, fee float64, currency string) error {
+	if fee <= 0 {
+		return nil
+	}
+	houseAccountID := r.HouseAccount(currency)
+	if houseAccountID == "" {
+		return fmt.Errorf("fee of %.2f %s due but no house account configured for %s", fee, currency, currency)
+	}
+
+	var houseCurrency string
+	err := tx.QueryRowContext(ctx, "SELECT currency FROM accounts.balances WHERE account_id = $1 FOR UPDATE", houseAccountID).Scan(&houseCurrency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("house account %s not found", houseAccountID)
+		}
+		return fmt.Errorf("failed to lock house account: %w", err)
+	}
+	if !strings.EqualFold(houseCurrency, currency) {
+		return fmt.Errorf("house account %s holds %s, cannot credit a %s fee", houseAccountID, houseCurrency, currency)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE accounts.balances SET balance = balance + $1, version = version + 1, updated_at = NOW() WHERE account_id = $2", fee, houseAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to credit fee: %w", err)
+	}
+
+	return nil
+}
+
+// recordCompleted records paymentID as COMPLETED inside tx, returning
+// ErrAlreadyProcessed when an outcome is recorded already.
+func recordCompleted(ctx context.Context, tx *sql.Tx, paymentID string) error {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO accounts.processed_payments (payment_id, status, processed_at)
+		VALUES ($1, 'COMPLETED', NOW())
+		ON CONFLICT (payment_id) DO NOTHING
+	`, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrAlreadyProcessed
+	}
+	return nil
+}
+
+// RecordFailedPayment records that paymentID was rejected, so a redelivered
+// event reports the same failure instead of being applied later.
+func (r *PostgresRepository) RecordFailedPayment(ctx context.Context, paymentID, reason string) error {
+	ctx, span := otel.Tracer("account-service").Start(ctx, "postgres.RecordFailedPayment")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO accounts.processed_payments (payment_id, status, reason, processed_at)
+		VALUES ($1, 'FAILED', $2, NOW())
+		ON CONFLICT (payment_id) DO NOTHING
+	`, paymentID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record failed payment: %w", err)
+	}
+	return nil
+}
+
+// PaymentOutcome returns the recorded outcome of paymentID, or nil when the
+// payment was not processed yet.
+func (r *PostgresRepository) PaymentOutcome(ctx context.Context, paymentID string) (*models.PaymentOutcome, error) {
+	ctx, span := otel.Tracer("account-service").Start(ctx, "postgres.PaymentOutcome")
+	defer span.End()
+
+	var o models.PaymentOutcome
+	err := r.db.QueryRowContext(ctx, `
+		SELECT payment_id, status, reason
+		FROM accounts.processed_payments
+		WHERE payment_id = $1
+	`, paymentID).Scan(&o.PaymentID, &o.Status, &o.Reason)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get payment outcome: %w", err)
+	}
+	return &o, nil
+}
@@ -1,55 +0,0 @@
-package telemetry
-
-import (
-	"context"
-	"fmt"
-	"log/slog"
-	"os"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
-)
-
-// InitTracer initializes the OpenTelemetry tracer
-func InitTracer(ctx context.Context, serviceName string) (func(context.Context) error, error) {
-	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "localhost:4317" // Default
-	}
-	slog.Info("Initializing Tracer", "endpoint", endpoint)
-
-	exporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithEndpoint(endpoint),
-		otlptracegrpc.WithInsecure(), // Jaeger inside cluster usually no TLS
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-	}
-
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String(serviceName),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
-	}
-
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Development setting
-	)
-
-	// Set global tracer provider
-	otel.SetTracerProvider(tp)
-
-	// Set global propagator to W3C TraceContext (standard)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-
-	return tp.Shutdown, nil
-}
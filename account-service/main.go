@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
@@ -17,35 +18,60 @@ import (
 	"securepay/account-service/internal/cache"
 	"securepay/account-service/internal/handler"
 	"securepay/account-service/internal/kafka"
-	"securepay/account-service/internal/logger"
 	"securepay/account-service/internal/repository"
-	"securepay/account-service/internal/spiffe"
-	"securepay/account-service/internal/telemetry"
 	"securepay/account-service/models"
+	"securepay/pkg/health"
+	"securepay/pkg/logger"
+	"securepay/pkg/metrics"
+	"securepay/pkg/principal"
+	"securepay/pkg/spiffe"
+	"securepay/pkg/telemetry"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	pb "securepay/proto/gen/go/account/v1"
+	pbv2 "securepay/proto/gen/go/account/v2"
 )
 
+// version is reported in the telemetry resource; builds set it with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
+// healthCheckInterval is how often dependencies are checked.
+const healthCheckInterval = 10 * time.Second
+
 func main() {
 	// Use the OTel-aware JSON logger so that trace_id and span_id are
-	// automatically injected into every log record that carries a span.
-	slog.SetDefault(logger.New())
+	// automatically injected into every log record that carries a span. The
+	// level is set once the configuration is loaded.
+	var logLevel slog.LevelVar
+	slog.SetDefault(logger.New(&logLevel))
 
 	// Load Configuration
-	cfg := config.Load()
-
-	// Initialize Tracer
-	shutdownTracer, err := telemetry.InitTracer(context.Background(), "account-service")
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(cfg.LogLevel)
+
+	// Initialize Telemetry (traces, and metrics and logs when exported)
+	tel, err := telemetry.Init(context.Background(), telemetry.Config{
+		ServiceName:    "account-service",
+		ServiceVersion: version,
+		SampleRatio:    cfg.TraceSampleRatio,
+	})
 	if err != nil {
-		slog.Error("Failed to initialize tracer", "error", err)
+		slog.Error("Failed to initialize telemetry", "error", err)
 		os.Exit(1)
 	}
 	defer func() {
-		if err := shutdownTracer(context.Background()); err != nil {
-			slog.Error("Failed to shutdown tracer", "error", err)
+		if err := tel.Shutdown(context.Background()); err != nil {
+			slog.Error("Failed to shutdown telemetry", "error", err)
 		}
 	}()
+	if bridge := tel.LogHandler(); bridge != nil {
+		slog.SetDefault(logger.New(&logLevel, bridge))
+	}
 
 	// Connect to Database
 	if cfg.DatabaseURL == "" {
@@ -65,24 +91,29 @@ func main() {
 		os.Exit(1)
 	}
 	slog.Info("Connected to database")
+	metrics.RegisterDBStats(db, "accounts")
 
-	repo := repository.NewPostgresRepository(db)
+	repo := repository.NewPostgresRepository(db, cfg.HouseAccounts)
 
 	// Seed Data
-	seedAccounts(context.Background(), repo)
+	seedAccounts(context.Background(), repo, cfg.HouseAccounts)
 
 	// Initialize Redis Cache
 	balanceCache := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword)
 	slog.Info("Redis cache initialized", "addr", cfg.RedisAddr)
 
+	// Initialize Kafka Producer (payment results)
+	producer := kafka.NewProducer(cfg.KafkaBrokers, cfg.ResultsTopic)
+	defer producer.Close()
+
 	// Initialize Kafka Consumer
 	consumer := kafka.NewConsumer(cfg)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	consumer.Start(ctx, repo, balanceCache)
+	consumer.Start(ctx, repo, balanceCache, producer)
 
 	// Initialize SPIFFE Workload API Source
-	source, err := spiffe.InitSPIFFESource(ctx, cfg.SpiffeSocket)
+	source, err := spiffe.NewSource(ctx, cfg.SpiffeSocket)
 	if err != nil {
 		slog.Error("Failed to initialize SPIFFE source", "error", err)
 		os.Exit(1)
@@ -90,13 +121,52 @@ func main() {
 	defer source.Close()
 	slog.Info("SPIFFE Source initialized successfully")
 
-	// Create gRPC Server with mTLS
-	creds := spiffe.ServerCredentials(source)
-	s := grpc.NewServer(creds, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	// Dependency health, served over gRPC and on the HTTP side port along
+	// with the Prometheus metrics
+	checker := health.NewChecker(healthCheckInterval)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("redis", balanceCache.Ping)
+	checker.Add("kafka", func(ctx context.Context) error { return health.PingKafka(ctx, cfg.KafkaBrokers) })
+	checker.Add("spiffe", func(context.Context) error { return spiffe.CheckSVID(source) })
+	// Balances are read from Postgres and Redis; Kafka only feeds the payment
+	// consumer, which resumes from its committed offset, so it only degrades.
+	checker.Service(pb.AccountService_ServiceDesc.ServiceName, "postgres", "redis", "spiffe")
+	checker.Service(pbv2.AccountService_ServiceDesc.ServiceName, "postgres", "redis", "spiffe")
+	checker.Handle("GET "+metrics.Path, metrics.Handler())
+	go checker.Run(ctx)
+	go func() {
+		if err := checker.Serve(ctx, cfg.HealthPort); err != nil {
+			slog.Error("Health server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Caller principals forwarded by the gateway are required on every call
+	// except reflection and health checks.
+	verifier, err := principal.NewVerifier(source, cfg.PrincipalIssuers, "/grpc.reflection.", "/grpc.health.v1.")
+	if err != nil {
+		slog.Error("Failed to initialize principal verifier", "error", err)
+		os.Exit(1)
+	}
 
-	// Register AccountService
+	// Create gRPC Server with mTLS
+	creds, err := spiffe.ServerCredentials(source, cfg.ClientSpiffeIDs)
+	if err != nil {
+		slog.Error("Failed to initialize server credentials", "error", err)
+		os.Exit(1)
+	}
+	s := grpc.NewServer(creds,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// Metrics first, so that calls rejected by the verifier are counted.
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), verifier.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor(), verifier.StreamServerInterceptor()),
+	)
+
+	// Register AccountService (v1 and v2)
 	h := handler.NewAccountHandler(repo, balanceCache)
 	pb.RegisterAccountServiceServer(s, h)
+	pbv2.RegisterAccountServiceServer(s, handler.NewAccountHandlerV2(h))
+	checker.Register(s)
 
 	// Enable reflection
 	reflection.Register(s)
@@ -107,6 +177,7 @@ func main() {
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
 		slog.Info("Shutting down gRPC server...")
+		checker.Shutdown()
 		s.GracefulStop()
 		consumer.Close() // Close Kafka reader
 		cancel()         // Cancel context for consumer loop
@@ -126,7 +197,7 @@ func main() {
 	}
 }
 
-func seedAccounts(ctx context.Context, repo repository.Repository) {
+func seedAccounts(ctx context.Context, repo repository.Repository, houseAccounts map[string]string) {
 	accounts := []models.Account{
 		{
 			ID:       "11111111-1111-1111-1111-111111111111", // Ahmet
@@ -140,6 +211,15 @@ func seedAccounts(ctx context.Context, repo repository.Repository) {
 		},
 	}
 
+	// House accounts collecting payment fees, one per currency
+	for currency, accountID := range houseAccounts {
+		accounts = append(accounts, models.Account{
+			ID:       accountID,
+			Balance:  0,
+			Currency: currency,
+		})
+	}
+
 	for _, acc := range accounts {
 		if err := repo.UpsertAccount(ctx, &acc); err != nil {
 			slog.Error("Failed to seed account", "id", acc.ID, "error", err)
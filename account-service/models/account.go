@@ -14,10 +14,33 @@ type Account struct {
 
 // PaymentInitiatedEvent represents the Kafka event payload
 type PaymentInitiatedEvent struct {
-	PaymentID   string  `json:"payment_id"`
-	FromAccount string  `json:"from_account"`
-	ToAccount   string  `json:"to_account"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	Timestamp   string  `json:"timestamp"`
+	PaymentID   string     `json:"payment_id"`
+	FromAccount string     `json:"from_account"`
+	ToAccount   string     `json:"to_account"`
+	Amount      float64    `json:"amount"`
+	Fee         float64    `json:"fee"` // Charged to the payer on top of Amount
+	Currency    string     `json:"currency"`
+	Timestamp   string     `json:"timestamp"`
+	Legs        []SplitLeg `json:"legs,omitempty"` // Set for split payments, ToAccount is empty then
+}
+
+// SplitLeg is one recipient of a split payment
+type SplitLeg struct {
+	ToAccount string  `json:"to_account"`
+	Amount    float64 `json:"amount"`
+}
+
+// PaymentOutcome is the recorded result of a processed payment
+type PaymentOutcome struct {
+	PaymentID string
+	Status    string // COMPLETED or FAILED
+	Reason    string // Set when Status is FAILED
+}
+
+// PaymentResultEvent is published once a payment has been applied (or rejected)
+type PaymentResultEvent struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`           // COMPLETED or FAILED
+	Reason    string `json:"reason,omitempty"` // Set when Status is FAILED
+	Timestamp string `json:"timestamp"`
 }
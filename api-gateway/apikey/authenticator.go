@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/middleware"
+)
+
+// lastUsedResolution bounds how often last_used_at is written per key, so
+// that busy keys do not cause a database write per request.
+const lastUsedResolution = time.Minute
+
+// Authenticator implements middleware.APIKeyAuthenticator.
+type Authenticator struct {
+	store Store
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+}
+
+// NewAuthenticator creates an Authenticator backed by store.
+func NewAuthenticator(store Store) *Authenticator {
+	return &Authenticator{store: store, touched: make(map[string]time.Time)}
+}
+
+// Authenticate verifies a key and returns the claims it grants. The key acts
+// as its owner, restricted to the key's scopes and accounts.
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (*middleware.Claims, error) {
+	prefix, secret, err := parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := a.store.GetByPrefix(ctx, prefix)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !verifySecret(k.SecretHash, secret) {
+		return nil, ErrInvalidKey
+	}
+	now := time.Now()
+	if !k.Usable(now) {
+		return nil, fmt.Errorf("%w: key %s is revoked or expired", ErrInvalidKey, k.ID)
+	}
+
+	a.touch(ctx, k.ID, now)
+
+	return &middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: k.Owner},
+		Tenant:           k.Tenant,
+		Scope:            strings.Join(k.Scopes, " "),
+		AccountIDs:       k.AccountIDs,
+		Plan:             k.Plan,
+		APIKeyID:         k.ID,
+	}, nil
+}
+
+// touch records the use of a key in the background, at most once per
+// lastUsedResolution.
+func (a *Authenticator) touch(ctx context.Context, id string, now time.Time) {
+	a.mu.Lock()
+	if now.Sub(a.touched[id]) < lastUsedResolution {
+		a.mu.Unlock()
+		return
+	}
+	a.touched[id] = now
+	a.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := a.store.TouchLastUsed(ctx, id, now); err != nil {
+			slog.WarnContext(ctx, "Failed to record API key use", "error", err, "api_key_id", id)
+		}
+	}()
+}
@@ -0,0 +1,259 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/middleware"
+)
+
+const owner1 = "user-1"
+
+// memoryStore is an in-memory Store with the semantics of PostgresStore.
+type memoryStore struct {
+	mu   sync.Mutex
+	keys map[string]*APIKey
+	next int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{keys: make(map[string]*APIKey)}
+}
+
+func (s *memoryStore) Create(_ context.Context, k *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insert(k)
+	return nil
+}
+
+func (s *memoryStore) insert(k *APIKey) {
+	s.next++
+	k.ID = fmt.Sprintf("key-%d", s.next)
+	k.CreatedAt = time.Now()
+	stored := *k
+	s.keys[k.ID] = &stored
+}
+
+func (s *memoryStore) GetByPrefix(_ context.Context, prefix string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.Prefix == prefix {
+			found := *k
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryStore) Get(_ context.Context, owner, id string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok || k.Owner != owner || k.RevokedAt != nil {
+		return nil, ErrNotFound
+	}
+	found := *k
+	return &found, nil
+}
+
+func (s *memoryStore) List(context.Context, string) ([]APIKey, error) { return nil, nil }
+
+func (s *memoryStore) Rotate(_ context.Context, owner, id string, replacement *APIKey, oldExpiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok || k.Owner != owner || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	if k.ExpiresAt == nil || oldExpiresAt.Before(*k.ExpiresAt) {
+		k.ExpiresAt = &oldExpiresAt
+	}
+	replacement.RotatedFrom = id
+	s.insert(replacement)
+	return nil
+}
+
+func (s *memoryStore) Revoke(_ context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok || k.Owner != owner || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return nil
+}
+
+func (s *memoryStore) TouchLastUsed(context.Context, string, time.Time) error { return nil }
+
+// createKey stores a key of owner1 and returns it in clear with its ID.
+func createKey(t *testing.T, store *memoryStore, expiresAt *time.Time) (key, id string) {
+	t.Helper()
+	key, prefix, secretHash := generate()
+	k := &APIKey{
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Name:       "partner",
+		Owner:      owner1,
+		Scopes:     []string{"payments:read"},
+		AccountIDs: []string{"acc-1"},
+		ExpiresAt:  expiresAt,
+	}
+	if err := store.Create(context.Background(), k); err != nil {
+		t.Fatal(err)
+	}
+	return key, k.ID
+}
+
+// manage calls a management handler as owner1 and returns the response.
+func manage(t *testing.T, handler func(http.ResponseWriter, *http.Request, string), id, body string, want int) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/v1/api-keys/"+id, strings.NewReader(body))
+	claims := &middleware.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: owner1}}
+	r = r.WithContext(middleware.WithClaims(r.Context(), claims))
+	w := httptest.NewRecorder()
+	handler(w, r, id)
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body)
+	}
+	return w
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := newMemoryStore()
+	a := NewAuthenticator(store)
+	ctx := context.Background()
+
+	key, id := createKey(t, store, nil)
+	_, otherPrefix, _ := generate()
+	past := time.Now().Add(-time.Minute)
+	expired, _ := createKey(t, store, &past)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"valid key", key, false},
+		{"expired key", expired, true},
+		{"wrong secret", key[:strings.LastIndex(key, "_")+1] + "wrong", true},
+		{"unknown prefix", "spk_" + otherPrefix + key[strings.LastIndex(key, "_"):], true},
+		{"malformed", "spk_" + otherPrefix, true},
+		{"other scheme", strings.Replace(key, "spk_", "sk_", 1), true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Authenticate(ctx, tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, ErrInvalidKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if claims.Subject != owner1 || claims.APIKeyID != id || claims.Scope != "payments:read" {
+				t.Errorf("claims = %+v, want subject %s, key %s, scope payments:read", claims, owner1, id)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRevokedKey(t *testing.T) {
+	store := newMemoryStore()
+	a := NewAuthenticator(store)
+	key, id := createKey(t, store, nil)
+
+	if _, err := a.Authenticate(context.Background(), key); err != nil {
+		t.Fatalf("Authenticate() before revoke error = %v", err)
+	}
+	manage(t, NewServer(store).HandleRevoke, id, "", http.StatusNoContent)
+	if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Authenticate() after revoke error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestAuthenticateRotatedKey(t *testing.T) {
+	soon := time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		grace     string
+		// oldValid is whether the old key works right after the rotation.
+		oldValid bool
+		// wait, when set, is slept before checking that the old key stopped
+		// working.
+		wait time.Duration
+	}{
+		{"no grace period", nil, "", false, 0},
+		{"zero grace period", nil, `{"grace_period":"0s"}`, false, 0},
+		{"within grace period", nil, `{"grace_period":"24h"}`, true, 0},
+		{"grace period elapsed", nil, `{"grace_period":"100ms"}`, true, 150 * time.Millisecond},
+		{"grace period beyond expiry", &soon, `{"grace_period":"48h"}`, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			a := NewAuthenticator(store)
+			ctx := context.Background()
+			oldKey, id := createKey(t, store, tt.expiresAt)
+
+			w := manage(t, NewServer(store).HandleRotate, id, tt.grace, http.StatusCreated)
+			var resp keyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.RotatedFrom != id || resp.Key == "" {
+				t.Fatalf("rotate response = %+v, want a new key rotated from %s", resp, id)
+			}
+
+			claims, err := a.Authenticate(ctx, resp.Key)
+			if err != nil {
+				t.Fatalf("Authenticate(new key) error = %v", err)
+			}
+			if claims.APIKeyID != resp.ID || claims.Subject != owner1 {
+				t.Errorf("new key claims = %+v, want key %s of %s", claims, resp.ID, owner1)
+			}
+
+			_, err = a.Authenticate(ctx, oldKey)
+			if tt.oldValid && err != nil {
+				t.Fatalf("Authenticate(old key) within grace period error = %v", err)
+			}
+			if !tt.oldValid && !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Authenticate(old key) error = %v, want %v", err, ErrInvalidKey)
+			}
+
+			if tt.expiresAt != nil {
+				old, _ := store.Get(ctx, owner1, id)
+				if !old.ExpiresAt.Equal(*tt.expiresAt) {
+					t.Errorf("old key expires at %s, want its original expiry %s", old.ExpiresAt, tt.expiresAt)
+				}
+			}
+
+			if tt.wait > 0 {
+				time.Sleep(tt.wait)
+				if _, err := a.Authenticate(ctx, oldKey); !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("Authenticate(old key) after grace period error = %v, want %v", err, ErrInvalidKey)
+				}
+				if _, err := a.Authenticate(ctx, resp.Key); err != nil {
+					t.Fatalf("Authenticate(new key) after grace period error = %v", err)
+				}
+			}
+		})
+	}
+}
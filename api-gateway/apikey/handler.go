@@ -0,0 +1,280 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"securepay/api-gateway/endpoints"
+	"securepay/api-gateway/middleware"
+)
+
+// maxRotationGracePeriod bounds how long a rotated key keeps working.
+const maxRotationGracePeriod = 7 * 24 * time.Hour
+
+// Server serves the API key management endpoints. Keys are owned by the
+// token subject and can only be managed with a JWT, never with another key.
+type Server struct {
+	store Store
+}
+
+// NewServer creates a Server backed by store.
+func NewServer(store Store) *Server {
+	return &Server{store: store}
+}
+
+type createRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	AccountIDs []string   `json:"account_ids"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type rotateRequest struct {
+	// GracePeriod is how long the old key keeps working, e.g. "24h".
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+type keyResponse struct {
+	ID string `json:"id"`
+	// Key is only returned when a key is created or rotated.
+	Key         string     `json:"key,omitempty"`
+	Prefix      string     `json:"prefix"`
+	Name        string     `json:"name"`
+	Scopes      []string   `json:"scopes"`
+	AccountIDs  []string   `json:"account_ids"`
+	RotatedFrom string     `json:"rotated_from,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newKeyResponse(k *APIKey, key string) keyResponse {
+	return keyResponse{
+		ID:          k.ID,
+		Key:         key,
+		Prefix:      k.Prefix,
+		Name:        k.Name,
+		Scopes:      k.Scopes,
+		AccountIDs:  k.AccountIDs,
+		RotatedFrom: k.RotatedFrom,
+		CreatedAt:   k.CreatedAt,
+		ExpiresAt:   k.ExpiresAt,
+		LastUsedAt:  k.LastUsedAt,
+	}
+}
+
+// HandleCreate creates a key. A key can only be granted scopes the caller
+// holds and accounts the caller owns.
+func (s *Server) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.ClaimsFromContext(r.Context())
+	if claims == nil || claims.Subject == "" {
+		middleware.Forbid(w, r, "token has no subject")
+		return
+	}
+
+	var req createRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		http.Error(w, "name must be 1 to 100 characters", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		http.Error(w, "at least one scope is required", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(endpoints.APIKeyScopes, scope) {
+			http.Error(w, "scope "+scope+" cannot be granted to API keys", http.StatusBadRequest)
+			return
+		}
+		if !claims.HasScope(scope) {
+			middleware.Forbid(w, r, "scope not held by caller", "scope", scope)
+			return
+		}
+	}
+	if !middleware.RequireOwnership(w, r, req.AccountIDs...) {
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "expires_at must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	key, prefix, secretHash := generate()
+	k := &APIKey{
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Name:       req.Name,
+		Owner:      claims.Subject,
+		Tenant:     claims.Tenant,
+		Scopes:     slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		AccountIDs: slices.Compact(slices.Sorted(slices.Values(req.AccountIDs))),
+		Plan:       claims.Plan,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := s.store.Create(r.Context(), k); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create API key", "error", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Created API key", "audit", true, "sub", k.Owner, "api_key_id", k.ID, "scopes", k.Scopes)
+
+	writeJSON(w, http.StatusCreated, newKeyResponse(k, key))
+}
+
+// HandleList lists the caller's keys without their secrets.
+func (s *Server) HandleList(w http.ResponseWriter, r *http.Request) {
+	owner, ok := owner(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := s.store.List(r.Context(), owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list API keys", "error", err)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Keys []keyResponse `json:"keys"`
+	}{Keys: make([]keyResponse, 0, len(keys))}
+	for i := range keys {
+		resp.Keys = append(resp.Keys, newKeyResponse(&keys[i], ""))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleRotate replaces a key with a new one carrying the same grants. The
+// old key keeps working for the requested grace period.
+func (s *Server) HandleRotate(w http.ResponseWriter, r *http.Request, id string) {
+	owner, ok := owner(w, r)
+	if !ok {
+		return
+	}
+
+	var req rotateRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		var err error
+		grace, err = time.ParseDuration(req.GracePeriod)
+		if err != nil || grace < 0 || grace > maxRotationGracePeriod {
+			http.Error(w, "grace_period must be a duration between 0s and 168h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	old, err := s.store.Get(r.Context(), owner, id)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get API key", "error", err, "api_key_id", id)
+		http.Error(w, "Failed to rotate API key", http.StatusInternalServerError)
+		return
+	}
+
+	key, prefix, secretHash := generate()
+	k := &APIKey{
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Name:       old.Name,
+		Owner:      old.Owner,
+		Tenant:     old.Tenant,
+		Scopes:     old.Scopes,
+		AccountIDs: old.AccountIDs,
+		Plan:       old.Plan,
+		ExpiresAt:  old.ExpiresAt,
+	}
+	err = s.store.Rotate(r.Context(), owner, id, k, time.Now().Add(grace))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to rotate API key", "error", err, "api_key_id", id)
+		http.Error(w, "Failed to rotate API key", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Rotated API key", "audit", true, "sub", owner, "api_key_id", k.ID, "rotated_from", id, "grace_period", grace)
+
+	writeJSON(w, http.StatusCreated, newKeyResponse(k, key))
+}
+
+// HandleRevoke revokes a key immediately.
+func (s *Server) HandleRevoke(w http.ResponseWriter, r *http.Request, id string) {
+	owner, ok := owner(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.store.Revoke(r.Context(), owner, id)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke API key", "error", err, "api_key_id", id)
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Revoked API key", "audit", true, "sub", owner, "api_key_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// owner returns the token subject, writing a 403 when the token has none.
+func owner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	sub := middleware.Subject(r.Context())
+	if sub == "" {
+		middleware.Forbid(w, r, "token has no subject")
+		return "", false
+	}
+	return sub, true
+}
+
+// decodeJSON decodes a request body into v. Unknown fields and data after
+// the JSON value are rejected, and errors say where the body is wrong. An
+// empty body returns io.EOF.
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return fmt.Errorf("%v at offset %d", err, syntaxErr.Offset)
+		case errors.As(err, &typeErr):
+			return fmt.Errorf("field %q must be %s, at offset %d", typeErr.Field, typeErr.Type, typeErr.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("unexpected end of JSON")
+		}
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after JSON value at offset %d", dec.InputOffset())
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
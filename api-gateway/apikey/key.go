@@ -0,0 +1,47 @@
+// Package apikey implements API key authentication for partners that cannot
+// use OAuth.
+//
+// A key has the form "spk_<prefix>_<secret>". The prefix identifies the key
+// record and is stored in clear; only the SHA-256 hash of the secret is
+// stored. The secret carries 130 bits of entropy, so a fast hash is
+// sufficient and keeps per-request verification cheap.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const keyScheme = "spk"
+
+// ErrInvalidKey is returned for malformed, unknown, revoked or expired keys.
+var ErrInvalidKey = errors.New("invalid API key")
+
+// generate returns a new key together with its prefix and secret hash.
+func generate() (key, prefix, secretHash string) {
+	prefix = strings.ToLower(rand.Text()[:12])
+	secret := rand.Text()
+	return keyScheme + "_" + prefix + "_" + secret, prefix, hashSecret(secret)
+}
+
+// parse splits a key into its prefix and secret.
+func parse(key string) (prefix, secret string, err error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != keyScheme || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidKey
+	}
+	return parts[1], parts[2], nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func verifySecret(secretHash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(secretHash), []byte(hashSecret(secret))) == 1
+}
@@ -0,0 +1,241 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+// ErrNotFound is returned when a key does not exist, is revoked or belongs
+// to another owner.
+var ErrNotFound = errors.New("not found")
+
+// APIKey is a stored API key. The secret itself is never stored.
+type APIKey struct {
+	ID         string
+	Prefix     string
+	SecretHash string
+	Name       string
+	// Owner is the subject that created the key; requests made with the key
+	// act on its behalf.
+	Owner  string
+	Tenant string
+	// Scopes are the operations the key may perform.
+	Scopes     []string
+	AccountIDs []string
+	// Plan is the rate limit plan of the owner when the key was created.
+	Plan string
+	// RotatedFrom is the ID of the key this one replaced, if any.
+	RotatedFrom string
+	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+	LastUsedAt  *time.Time
+}
+
+// Usable reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Store persists API keys.
+type Store interface {
+	Create(ctx context.Context, k *APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	Get(ctx context.Context, owner, id string) (*APIKey, error)
+	List(ctx context.Context, owner string) ([]APIKey, error)
+	Rotate(ctx context.Context, owner, id string, replacement *APIKey, oldExpiresAt time.Time) error
+	Revoke(ctx context.Context, owner, id string) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// PostgresStore implements Store
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const keyColumns = `id, prefix, secret_hash, name, owner, tenant, scopes, account_ids, plan,
+	COALESCE(rotated_from::text, ''), created_at, expires_at, revoked_at, last_used_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// queryRower is satisfied by *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanKey(row scanner) (*APIKey, error) {
+	k := &APIKey{}
+	err := row.Scan(&k.ID, &k.Prefix, &k.SecretHash, &k.Name, &k.Owner, &k.Tenant, pq.Array(&k.Scopes), pq.Array(&k.AccountIDs), &k.Plan,
+		&k.RotatedFrom, &k.CreatedAt, &k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Create inserts a new key; ID and CreatedAt are set by the database.
+func (s *PostgresStore) Create(ctx context.Context, k *APIKey) error {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.CreateAPIKey")
+	defer span.End()
+
+	if err := insertKey(ctx, s.db, k); err != nil {
+		return fmt.Errorf("failed to insert API key: %w", err)
+	}
+	return nil
+}
+
+func insertKey(ctx context.Context, q queryRower, k *APIKey) error {
+	return q.QueryRowContext(ctx, `
+		INSERT INTO gateway.api_keys (id, prefix, secret_hash, name, owner, tenant, scopes, account_ids, plan, rotated_from, created_at, expires_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid, NOW(), $10)
+		RETURNING id, created_at
+	`, k.Prefix, k.SecretHash, k.Name, k.Owner, k.Tenant, pq.Array(k.Scopes), pq.Array(k.AccountIDs), k.Plan, k.RotatedFrom, k.ExpiresAt).Scan(&k.ID, &k.CreatedAt)
+}
+
+// GetByPrefix loads a key by its public prefix, including revoked and
+// expired keys.
+func (s *PostgresStore) GetByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.GetAPIKeyByPrefix")
+	defer span.End()
+
+	k, err := scanKey(s.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM gateway.api_keys WHERE prefix = $1`, prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return k, nil
+}
+
+// Get loads a non-revoked key of the owner.
+func (s *PostgresStore) Get(ctx context.Context, owner, id string) (*APIKey, error) {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.GetAPIKey")
+	defer span.End()
+
+	k, err := scanKey(s.db.QueryRowContext(ctx, `
+		SELECT `+keyColumns+` FROM gateway.api_keys
+		WHERE id::text = $1 AND owner = $2 AND revoked_at IS NULL
+	`, id, owner))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return k, nil
+}
+
+// List returns the non-revoked keys of the owner, newest first.
+func (s *PostgresStore) List(ctx context.Context, owner string) ([]APIKey, error) {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.ListAPIKeys")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+keyColumns+` FROM gateway.api_keys
+		WHERE owner = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Rotate inserts replacement and shortens the lifetime of the old key to
+// oldExpiresAt in one transaction, so both keys work during the grace period.
+func (s *PostgresStore) Rotate(ctx context.Context, owner, id string, replacement *APIKey, oldExpiresAt time.Time) error {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.RotateAPIKey")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE gateway.api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $3), $3)
+		WHERE id::text = $1 AND owner = $2 AND revoked_at IS NULL
+	`, id, owner, oldExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to expire rotated API key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to expire rotated API key: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	replacement.RotatedFrom = id
+	if err := insertKey(ctx, tx, replacement); err != nil {
+		return fmt.Errorf("failed to insert API key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Revoke revokes a key of the owner.
+func (s *PostgresStore) Revoke(ctx context.Context, owner, id string) error {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.RevokeAPIKey")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE gateway.api_keys SET revoked_at = NOW()
+		WHERE id::text = $1 AND owner = $2 AND revoked_at IS NULL
+	`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records when a key was last used.
+func (s *PostgresStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.TouchAPIKey")
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE gateway.api_keys SET last_used_at = $2 WHERE id = $1`, id, at); err != nil {
+		return fmt.Errorf("failed to update API key last use: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v4"
+
+	"securepay/api-gateway/config"
+	"securepay/api-gateway/middleware"
+	"securepay/api-gateway/oauth"
+)
+
+// NewJWTValidator builds the token validator from the auth configuration.
+// Tokens of the built-in issuer are trusted in addition to the JWKS when
+// issuer is non-nil. The returned KeySet must be run by the caller to pick
+// up rotated keys.
+func NewJWTValidator(ctx context.Context, cfg config.Auth, issuer *oauth.Issuer, revocations middleware.RevocationChecker) (*middleware.JWTValidator, *middleware.KeySet, error) {
+	var localKeys []jose.JSONWebKey
+	if issuer != nil {
+		localKeys = append(localKeys, issuer.PublicKey())
+	}
+
+	keys, err := middleware.NewKeySet(ctx, middleware.JWKSConfig{
+		URL:             cfg.JWKSURL,
+		File:            cfg.JWKSFile,
+		RefreshInterval: cfg.JWKSRefreshInterval,
+		LocalKeys:       localKeys,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	validator, err := middleware.NewJWTValidator(keys, middleware.JWTConfig{
+		Issuer:      cfg.Issuer,
+		Audience:    cfg.Audience,
+		ClockSkew:   cfg.ClockSkew,
+		Revocations: revocations,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return validator, keys, nil
+}
+
+// NewOAuthIssuer configures the built-in client credentials token endpoint.
+// Issued tokens carry the configured issuer and audience so that the
+// gateway's own validator accepts them. It returns nil when the endpoint is
+// disabled.
+func NewOAuthIssuer(cfg config.Auth) (*oauth.Issuer, error) {
+	if cfg.OAuth.SigningKeyFile == "" {
+		return nil, nil
+	}
+	return oauth.NewIssuer(oauth.IssuerConfig{
+		KeyFile:  cfg.OAuth.SigningKeyFile,
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		TTL:      cfg.OAuth.TokenTTL,
+	})
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/api-gateway/middleware"
+	accountv1 "securepay/proto/gen/go/account/v1"
+	accountv2 "securepay/proto/gen/go/account/v2"
+	paymentv1 "securepay/proto/gen/go/payment/v1"
+	paymentv2 "securepay/proto/gen/go/payment/v2"
+)
+
+// The REST handlers generated by grpc-gateway decode the request and call
+// the backend directly, so the checks that depend on the request or on the
+// response are made by wrapping the clients they call. Scopes are enforced
+// earlier by ScopeMiddleware. Methods that are not overridden below pass
+// through unchecked; a new RPC exposed over REST needs an override here.
+
+// authorizedPaymentClient restricts payments to the accounts of the caller.
+type authorizedPaymentClient struct {
+	paymentv1.PaymentServiceClient
+}
+
+func (c authorizedPaymentClient) InitiatePayment(ctx context.Context, req *paymentv1.InitiatePaymentRequest, opts ...grpc.CallOption) (*paymentv1.InitiatePaymentResponse, error) {
+	if err := middleware.CheckOwnership(ctx, req.FromAccount); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.InitiatePayment(ctx, req, opts...)
+}
+
+func (c authorizedPaymentClient) GetPayment(ctx context.Context, req *paymentv1.GetPaymentRequest, opts ...grpc.CallOption) (*paymentv1.GetPaymentResponse, error) {
+	resp, err := c.PaymentServiceClient.GetPayment(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := middleware.CheckViewAccess(ctx, paymentAccounts(resp)...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// InitiateBatchPayment rejects the whole batch if any line debits an
+// account the caller does not own.
+func (c authorizedPaymentClient) InitiateBatchPayment(ctx context.Context, req *paymentv1.InitiateBatchPaymentRequest, opts ...grpc.CallOption) (*paymentv1.InitiateBatchPaymentResponse, error) {
+	fromAccounts := make([]string, 0, len(req.Payments))
+	for _, p := range req.Payments {
+		fromAccounts = append(fromAccounts, p.FromAccount)
+	}
+	if err := middleware.CheckOwnership(ctx, fromAccounts...); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.InitiateBatchPayment(ctx, req, opts...)
+}
+
+// GetBatch only returns batches submitted by the caller, except to ops.
+func (c authorizedPaymentClient) GetBatch(ctx context.Context, req *paymentv1.GetBatchRequest, opts ...grpc.CallOption) (*paymentv1.GetBatchResponse, error) {
+	resp, err := c.PaymentServiceClient.GetBatch(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	claims := middleware.ClaimsFromContext(ctx)
+	if claims == nil || (claims.Subject != resp.InitiatedBy && !claims.HasRole(middleware.RoleOps)) {
+		return nil, middleware.Deny(ctx, "batch submitted by another subject", "batch_id", req.BatchId)
+	}
+	return resp, nil
+}
+
+func (c authorizedPaymentClient) InitiateSplitPayment(ctx context.Context, req *paymentv1.InitiateSplitPaymentRequest, opts ...grpc.CallOption) (*paymentv1.InitiatePaymentResponse, error) {
+	if err := middleware.CheckOwnership(ctx, req.FromAccount); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.InitiateSplitPayment(ctx, req, opts...)
+}
+
+// WatchPayment looks the payment up first, since status events do not say
+// which accounts it involves.
+func (c authorizedPaymentClient) WatchPayment(ctx context.Context, req *paymentv1.WatchPaymentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[paymentv1.PaymentStatusEvent], error) {
+	if _, err := c.GetPayment(ctx, &paymentv1.GetPaymentRequest{PaymentId: req.PaymentId}, opts...); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.WatchPayment(ctx, req, opts...)
+}
+
+// paymentAccounts lists every account a payment touches, for view checks.
+func paymentAccounts(p *paymentv1.GetPaymentResponse) []string {
+	accounts := []string{p.FromAccount}
+	if p.ToAccount != "" {
+		accounts = append(accounts, p.ToAccount)
+	}
+	for _, leg := range p.Legs {
+		accounts = append(accounts, leg.ToAccount)
+	}
+	return accounts
+}
+
+// authorizedWebhookClient scopes webhooks to the token subject: the
+// client_id sent to the payment service is always taken from the token,
+// never from the request.
+type authorizedWebhookClient struct {
+	paymentv1.WebhookServiceClient
+}
+
+func (c authorizedWebhookClient) RegisterWebhook(ctx context.Context, req *paymentv1.RegisterWebhookRequest, opts ...grpc.CallOption) (*paymentv1.RegisterWebhookResponse, error) {
+	clientID, err := webhookClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.ClientId = clientID
+
+	// Only events of the caller's own accounts may be subscribed to.
+	if err := middleware.CheckOwnership(ctx, req.AccountIds...); err != nil {
+		return nil, err
+	}
+	return c.WebhookServiceClient.RegisterWebhook(ctx, req, opts...)
+}
+
+func (c authorizedWebhookClient) ListWebhooks(ctx context.Context, req *paymentv1.ListWebhooksRequest, opts ...grpc.CallOption) (*paymentv1.ListWebhooksResponse, error) {
+	clientID, err := webhookClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.ClientId = clientID
+	return c.WebhookServiceClient.ListWebhooks(ctx, req, opts...)
+}
+
+func (c authorizedWebhookClient) DeleteWebhook(ctx context.Context, req *paymentv1.DeleteWebhookRequest, opts ...grpc.CallOption) (*paymentv1.DeleteWebhookResponse, error) {
+	clientID, err := webhookClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.ClientId = clientID
+	return c.WebhookServiceClient.DeleteWebhook(ctx, req, opts...)
+}
+
+func (c authorizedWebhookClient) ListWebhookDeliveries(ctx context.Context, req *paymentv1.ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*paymentv1.ListWebhookDeliveriesResponse, error) {
+	clientID, err := webhookClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+	req.ClientId = clientID
+	return c.WebhookServiceClient.ListWebhookDeliveries(ctx, req, opts...)
+}
+
+func (c authorizedWebhookClient) RedeliverWebhook(ctx context.Context, req *paymentv1.RedeliverWebhookRequest, opts ...grpc.CallOption) (*paymentv1.RedeliverWebhookResponse, error) {
+	clientID, err := webhookClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.ClientId = clientID
+	return c.WebhookServiceClient.RedeliverWebhook(ctx, req, opts...)
+}
+
+// webhookClientID returns the token subject, denying tokens without one.
+func webhookClientID(ctx context.Context) (string, error) {
+	clientID := middleware.Subject(ctx)
+	if clientID == "" {
+		return "", middleware.Deny(ctx, "token has no subject")
+	}
+	return clientID, nil
+}
+
+// authorizedAccountClient restricts balances to the caller's accounts.
+type authorizedAccountClient struct {
+	accountv1.AccountServiceClient
+}
+
+func (c authorizedAccountClient) CheckBalance(ctx context.Context, req *accountv1.CheckBalanceRequest, opts ...grpc.CallOption) (*accountv1.CheckBalanceResponse, error) {
+	if err := middleware.CheckViewAccess(ctx, req.AccountId); err != nil {
+		return nil, err
+	}
+	return c.AccountServiceClient.CheckBalance(ctx, req, opts...)
+}
+
+// authorizedPaymentV2Client applies the rules of authorizedPaymentClient to
+// the v2 API, and lets callers list the payments of their own accounts.
+type authorizedPaymentV2Client struct {
+	paymentv2.PaymentServiceClient
+}
+
+func (c authorizedPaymentV2Client) CreatePayment(ctx context.Context, req *paymentv2.CreatePaymentRequest, opts ...grpc.CallOption) (*paymentv2.Payment, error) {
+	if err := middleware.CheckOwnership(ctx, req.FromAccount); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.CreatePayment(ctx, req, opts...)
+}
+
+func (c authorizedPaymentV2Client) GetPayment(ctx context.Context, req *paymentv2.GetPaymentRequest, opts ...grpc.CallOption) (*paymentv2.Payment, error) {
+	resp, err := c.PaymentServiceClient.GetPayment(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := middleware.CheckViewAccess(ctx, paymentV2Accounts(resp)...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c authorizedPaymentV2Client) ListPayments(ctx context.Context, req *paymentv2.ListPaymentsRequest, opts ...grpc.CallOption) (*paymentv2.ListPaymentsResponse, error) {
+	if err := middleware.CheckViewAccess(ctx, req.AccountId); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.ListPayments(ctx, req, opts...)
+}
+
+func (c authorizedPaymentV2Client) CreateSplitPayment(ctx context.Context, req *paymentv2.CreateSplitPaymentRequest, opts ...grpc.CallOption) (*paymentv2.Payment, error) {
+	if err := middleware.CheckOwnership(ctx, req.FromAccount); err != nil {
+		return nil, err
+	}
+	return c.PaymentServiceClient.CreateSplitPayment(ctx, req, opts...)
+}
+
+// paymentV2Accounts lists every account a v2 payment touches.
+func paymentV2Accounts(p *paymentv2.Payment) []string {
+	accounts := []string{p.FromAccount}
+	if p.ToAccount != "" {
+		accounts = append(accounts, p.ToAccount)
+	}
+	for _, leg := range p.Legs {
+		accounts = append(accounts, leg.ToAccount)
+	}
+	return accounts
+}
+
+// authorizedAccountV2Client restricts balances to the caller's accounts.
+type authorizedAccountV2Client struct {
+	accountv2.AccountServiceClient
+}
+
+func (c authorizedAccountV2Client) GetBalance(ctx context.Context, req *accountv2.GetBalanceRequest, opts ...grpc.CallOption) (*accountv2.Balance, error) {
+	if err := middleware.CheckViewAccess(ctx, req.AccountId); err != nil {
+		return nil, err
+	}
+	return c.AccountServiceClient.GetBalance(ctx, req, opts...)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/api-gateway/upstream"
+)
+
+// writeBackendError reports a failed backend call from a hand-written
+// handler the way handleGatewayError does for the generated ones: the gRPC
+// status is mapped to an HTTP status and written as JSON, and an
+// unavailable backend gets a Retry-After.
+func writeBackendError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	code := httpStatusFromCode(s.Code())
+	if s.Code() == codes.Unavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(int(upstream.RetryAfter.Seconds())))
+	}
+	body, merr := gatewayMarshaler.Marshal(s.Proto())
+	if merr != nil {
+		http.Error(w, s.Message(), code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// handleReady reports the connectivity of every backend. It answers 503
+// while any backend is not ready.
+func handleReady(w http.ResponseWriter, r *http.Request, backends []*upstream.Backend) {
+	resp := struct {
+		Ready     bool              `json:"ready"`
+		Upstreams []upstream.Status `json:"upstreams"`
+	}{Ready: true}
+	for _, b := range backends {
+		s := b.Status()
+		resp.Ready = resp.Ready && s.Ready
+		resp.Upstreams = append(resp.Upstreams, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !resp.Ready {
+		w.Header().Set("Retry-After", strconv.Itoa(int(upstream.RetryAfter.Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
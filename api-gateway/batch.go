@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/api-gateway/endpoints"
+	paymentv1 "securepay/proto/gen/go/payment/v1"
+)
+
+// csvColumns lists the columns understood in a CSV batch upload.
+// from_account, to_account, amount and currency are required; payment_id and
+// idempotency_key are generated by the payment service when omitted.
+var csvColumns = []string{"payment_id", "from_account", "to_account", "amount", "currency", "idempotency_key"}
+
+// handleInitiateBatchPayment accepts a batch as JSON or protobuf, like the
+// generated handlers, or as CSV. The size and media type of the body are
+// checked by BodyMiddleware. client must be an authorizedPaymentClient.
+func handleInitiateBatchPayment(w http.ResponseWriter, r *http.Request, client paymentv1.PaymentServiceClient) {
+	var req paymentv1.InitiateBatchPaymentRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case endpoints.CSVMediaType:
+		payments, err := parseBatchCSV(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid CSV body: %v", err), http.StatusBadRequest)
+			return
+		}
+		req.Payments = payments
+		req.BatchId = r.URL.Query().Get("batch_id")
+	default:
+		decoder := gatewayMarshaler.NewDecoder(r.Body)
+		if mediaType == endpoints.ProtobufMediaType {
+			decoder = protoMarshaler.NewDecoder(r.Body)
+		}
+		if err := decoder.Decode(&req); err != nil {
+			writeBackendError(w, status.Errorf(codes.InvalidArgument, "%v", err))
+			return
+		}
+	}
+
+	// The Idempotency-Key header is accepted for both formats since CSV has
+	// no envelope to carry it.
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+
+	ctx := r.Context()
+
+	resp, err := client.InitiateBatchPayment(ctx, &req)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	marshaler := responseMarshaler(r)
+	body, err := marshaler.Marshal(resp)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", marshaler.ContentType(resp))
+	w.Write(body)
+}
+
+// parseBatchCSV reads a CSV upload with a header row into payment requests.
+// Only structural problems (unknown columns, unparsable amounts) are reported
+// here; business validation happens per line in the payment service.
+func parseBatchCSV(body io.Reader) ([]*paymentv1.InitiatePaymentRequest, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header row")
+		}
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isCSVColumn(name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		index[name] = i
+	}
+	for _, required := range []string{"from_account", "to_account", "amount", "currency"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := index[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var payments []*paymentv1.InitiatePaymentRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := strconv.ParseFloat(field(record, "amount"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, field(record, "amount"))
+		}
+
+		payments = append(payments, &paymentv1.InitiatePaymentRequest{
+			PaymentId:      field(record, "payment_id"),
+			FromAccount:    field(record, "from_account"),
+			ToAccount:      field(record, "to_account"),
+			Amount:         amount,
+			Currency:       field(record, "currency"),
+			IdempotencyKey: field(record, "idempotency_key"),
+		})
+	}
+
+	return payments, nil
+}
+
+func isCSVColumn(name string) bool {
+	for _, c := range csvColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// certReloader serves the listener certificate from disk and loads it again
+// when the files change, so that renewed certificates (e.g. written by
+// cert-manager) are served without a restart.
+type certReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+	// modTime is the latest modification time of the loaded files; only
+	// touched by the watching goroutine after construction.
+	modTime time.Time
+}
+
+// newCertReloader loads the certificate, failing if it is unusable.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// watch checks the files every interval until ctx is done. A certificate
+// that fails to load is logged and the current one is kept.
+func (c *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		modTime, err := c.latestModTime()
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to check TLS certificate files", "error", err)
+			continue
+		}
+		if !modTime.After(c.modTime) {
+			continue
+		}
+		if err := c.reload(); err != nil {
+			slog.ErrorContext(ctx, "Failed to reload TLS certificate, keeping the current one", "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "TLS certificate reloaded", "cert_file", c.certFile, "not_after", c.cert.Load().Leaf.NotAfter)
+	}
+}
+
+func (c *certReloader) reload() error {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	c.modTime = modTime
+	return nil
+}
+
+// latestModTime returns the modification time of whichever file changed
+// last, since the certificate and key are not replaced atomically together.
+func (c *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
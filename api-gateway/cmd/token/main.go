@@ -1,22 +1,263 @@
+// Command token manages development signing keys and issues test JWTs.
+//
+//	token keygen -alg ES256 -key signing.pem -jwks jwks.json
+//	token sign -key signing.pem -sub test-user
+//	token hash-secret -secret s3cr3t
+//
+// keygen writes a PKCS#8 private key and adds its public half to a JWKS file
+// (existing keys are kept so that rotation does not invalidate live tokens).
+// The kid defaults to the RFC 7638 thumbprint of the key, so sign derives the
+// same kid from the private key without further configuration. hash-secret
+// prints the stored form of an OAuth client secret for gateway.oauth_clients
+// (a random secret is generated when none is given).
 package main
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/go-jose/go-jose/v4"
 	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/oauth"
+)
+
+// Defaults match the development api-gateway configuration and seed data.
+const (
+	defaultIssuer   = "https://auth.securepay.dev"
+	defaultAudience = "securepay-api"
+	defaultTenant   = "securepay-dev"
+	defaultScope    = "payments:read payments:write accounts:read webhooks:manage apikeys:manage"
+	defaultAccounts = "11111111-1111-1111-1111-111111111111,22222222-2222-2222-2222-222222222222"
 )
 
+// claims mirrors the claims the gateway authorizes against.
+type claims struct {
+	jwt.RegisteredClaims
+	Tenant     string   `json:"tenant,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	AccountIDs []string `json:"account_ids,omitempty"`
+	Plan       string   `json:"plan,omitempty"`
+}
+
 func main() {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": "test-user",
-		"exp": time.Now().Add(time.Hour).Unix(),
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "hash-secret":
+		err = hashSecret(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "token:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: token keygen [flags] | token sign [flags] | token hash-secret [flags]")
+	fmt.Fprintln(os.Stderr, "run 'token <command> -h' for the flags of a command")
+}
+
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	alg := fs.String("alg", "ES256", "key algorithm: RS256, ES256 or EdDSA")
+	keyPath := fs.String("key", "signing.pem", "private key output file (PKCS#8 PEM)")
+	jwksPath := fs.String("jwks", "jwks.json", "JWKS file the public key is added to")
+	kid := fs.String("kid", "", "key ID (default: RFC 7638 thumbprint)")
+	fs.Parse(args)
+
+	var priv crypto.Signer
+	var err error
+	switch *alg {
+	case "RS256":
+		priv, err = rsa.GenerateKey(rand.Reader, 3072)
+	case "ES256":
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported algorithm %q", *alg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	pub := jose.JSONWebKey{Key: priv.Public(), KeyID: *kid, Algorithm: *alg, Use: "sig"}
+	if pub.KeyID == "" {
+		if pub.KeyID, err = thumbprint(pub); err != nil {
+			return err
+		}
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	keyFile, err := os.OpenFile(*keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer keyFile.Close()
+	if err := pem.Encode(keyFile, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	var set jose.JSONWebKeySet
+	if data, err := os.ReadFile(*jwksPath); err == nil {
+		if err := json.Unmarshal(data, &set); err != nil {
+			return fmt.Errorf("failed to parse existing JWKS: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	set.Keys = append(set.Keys, pub)
+
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JWKS: %w", err)
+	}
+	if err := os.WriteFile(*jwksPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JWKS: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %s and added kid %s to %s\n", *keyPath, pub.KeyID, *jwksPath)
+	return nil
+}
+
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := fs.String("key", "signing.pem", "private key file (PKCS#8 PEM)")
+	kid := fs.String("kid", "", "key ID (default: RFC 7638 thumbprint)")
+	sub := fs.String("sub", "test-user", "subject claim")
+	tenant := fs.String("tenant", defaultTenant, "tenant claim")
+	iss := fs.String("iss", defaultIssuer, "issuer claim")
+	aud := fs.String("aud", defaultAudience, "audience claim")
+	ttl := fs.Duration("ttl", time.Hour, "token lifetime")
+	scope := fs.String("scope", defaultScope, "space-delimited scopes")
+	roles := fs.String("roles", "", "comma-separated roles (e.g. ops)")
+	accounts := fs.String("accounts", defaultAccounts, "comma-separated owned account IDs")
+	plan := fs.String("plan", "", "rate limit plan (default: the default quota)")
+	fs.Parse(args)
+
+	data, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("key file is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse private key: %w", err)
+	}
+	priv, ok := parsed.(crypto.Signer)
+	if !ok {
+		return errors.New("unsupported private key type")
+	}
+
+	var method jwt.SigningMethod
+	switch priv.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return errors.New("unsupported private key type")
+	}
+
+	if *kid == "" {
+		if *kid, err = thumbprint(jose.JSONWebKey{Key: priv.Public()}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(method, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   *sub,
+			Issuer:    *iss,
+			Audience:  jwt.ClaimStrings{*aud},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(*ttl)),
+		},
+		Tenant:     *tenant,
+		Scope:      *scope,
+		Roles:      splitList(*roles),
+		AccountIDs: splitList(*accounts),
+		Plan:       *plan,
 	})
+	token.Header["kid"] = *kid
 
-	tokenString, err := token.SignedString([]byte("securepay-secret-key"))
+	tokenString, err := token.SignedString(priv)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to sign token: %w", err)
 	}
 
 	fmt.Println(tokenString)
+	return nil
+}
+
+func hashSecret(args []string) error {
+	fs := flag.NewFlagSet("hash-secret", flag.ExitOnError)
+	secret := fs.String("secret", "", "client secret (default: randomly generated)")
+	fs.Parse(args)
+
+	if *secret == "" {
+		*secret = rand.Text()
+		fmt.Fprintln(os.Stderr, "secret:", *secret)
+	}
+	hash, err := oauth.HashSecret(*secret)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+func thumbprint(key jose.JSONWebKey) (string, error) {
+	sum, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute key thumbprint: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
 }
@@ -0,0 +1,459 @@
+// Package config loads the API gateway configuration from an optional YAML
+// file (CONFIG_FILE) and environment variables, which take precedence over
+// the file. See config.example.yaml for the file format.
+//
+// The configuration is validated as a whole at startup. On SIGHUP the
+// gateway reloads it and applies the settings that are safe to change at
+// runtime: request timeouts and body limits, rate limit quotas, CORS, the
+// log level and the logging of account ids.
+// Changes to anything else are reported and require a restart.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"gopkg.in/yaml.v3"
+
+	"securepay/api-gateway/endpoints"
+	"securepay/api-gateway/logging"
+	"securepay/api-gateway/ratelimit"
+)
+
+// Config holds the gateway configuration.
+type Config struct {
+	Server          Server          `yaml:"server"`
+	TLS             TLS             `yaml:"tls"`
+	Upstreams       Upstreams       `yaml:"upstreams"`
+	Timeouts        Timeouts        `yaml:"timeouts"`
+	BodyLimits      BodyLimits      `yaml:"body_limits"`
+	CORS            CORS            `yaml:"cors"`
+	SecurityHeaders SecurityHeaders `yaml:"security_headers"`
+	API             API             `yaml:"api"`
+	RateLimit       RateLimit       `yaml:"rate_limit"`
+	Auth            Auth            `yaml:"auth"`
+	Tracing         Tracing         `yaml:"tracing"`
+	// SpiffeSocket is the SPIRE agent workload API socket.
+	SpiffeSocket string `yaml:"spiffe_socket"`
+	// DatabaseURL is the gateway database holding OAuth clients, revoked
+	// tokens and API keys. Both features are disabled without it.
+	DatabaseURL string     `yaml:"database_url"`
+	LogLevel    slog.Level `yaml:"log_level"`
+	Logging     Logging    `yaml:"logging"`
+}
+
+// Server configures the HTTP listener.
+type Server struct {
+	Addr            string        `yaml:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ReadHeaderTimeout bounds how long a client may take to send the
+	// request headers. There is no write timeout: event streams stay open,
+	// and request deadlines are set per route by Timeouts.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	// IdleTimeout closes keep-alive connections without a request.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+// TLS enables HTTPS on the listener when both files are set.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ReloadInterval is how often the files are checked for a renewed
+	// certificate, which is then served without a restart.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// Enabled reports whether the listener serves HTTPS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Upstreams configures the backend gRPC services.
+type Upstreams struct {
+	// DialTimeout bounds each attempt to create a backend connection.
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// LoadBalancing is the policy used to spread calls over the replicas of
+	// a backend: round_robin or least_request.
+	LoadBalancing string `yaml:"load_balancing"`
+	// ResolveInterval is how often backend host names are looked up again.
+	ResolveInterval time.Duration  `yaml:"resolve_interval"`
+	Payment         Upstream       `yaml:"payment"`
+	Account         Upstream       `yaml:"account"`
+	CircuitBreaker  CircuitBreaker `yaml:"circuit_breaker"`
+	Retry           Retry          `yaml:"retry"`
+}
+
+// CircuitBreaker configures the per-backend circuit breakers.
+type CircuitBreaker struct {
+	// FailureThreshold consecutive failures open the breaker; 0 disables it.
+	FailureThreshold int `yaml:"failure_threshold"`
+	// OpenTimeout is how long an open breaker rejects calls before probing.
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+}
+
+// Retry configures retries of backend calls that are safe to repeat.
+type Retry struct {
+	// MaxAttempts includes the first attempt; 1 disables retries.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	// HedgeDelay, when positive, sends a second copy of a read that has not
+	// completed after this delay.
+	HedgeDelay time.Duration `yaml:"hedge_delay"`
+}
+
+// Upstream is a backend gRPC service.
+type Upstream struct {
+	// Addr is the host:port of the backend. Every address of the host is
+	// used, so in Kubernetes it should name a headless service.
+	Addr string `yaml:"addr"`
+	// Endpoints, when set, replaces the DNS lookup of Addr with a fixed list
+	// of host:port addresses, e.g. for local runs.
+	Endpoints []string `yaml:"endpoints"`
+	// SpiffeID is the identity the backend must present.
+	SpiffeID string `yaml:"spiffe_id"`
+	// HealthService is the grpc.health.v1 service name checked on every
+	// replica; replicas that are not SERVING get no calls. Empty disables
+	// health checking.
+	HealthService string `yaml:"health_service"`
+}
+
+// Timeouts bound how long a request may take, per route pattern.
+type Timeouts struct {
+	Default time.Duration `yaml:"default"`
+	// Routes overrides Default by route pattern; 0 disables the deadline,
+	// e.g. for event streams.
+	Routes map[string]time.Duration `yaml:"routes"`
+}
+
+// For returns the timeout of a route pattern.
+func (t Timeouts) For(pattern string) time.Duration {
+	if d, ok := t.Routes[pattern]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// BodyLimits cap the size of request bodies in bytes, per route pattern.
+type BodyLimits struct {
+	Default int64 `yaml:"default"`
+	// Routes overrides Default by route pattern.
+	Routes map[string]int64 `yaml:"routes"`
+}
+
+// For returns the body limit of a route pattern.
+func (b BodyLimits) For(pattern string) int64 {
+	if n, ok := b.Routes[pattern]; ok {
+		return n
+	}
+	return b.Default
+}
+
+// CORS configures cross-origin access from browsers. CORS is disabled when
+// no origins are allowed.
+type CORS struct {
+	// AllowedOrigins are exact origins such as https://dashboard.securepay.dev,
+	// or "*" to allow any origin.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string `yaml:"exposed_headers"`
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// SecurityHeaders configures the security headers of every response.
+// X-Content-Type-Options: nosniff is always sent.
+type SecurityHeaders struct {
+	// HSTSMaxAge is the max-age of Strict-Transport-Security, sent on HTTPS
+	// requests only; 0 omits the header.
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	// ContentSecurityPolicy is sent as is; empty omits the header.
+	ContentSecurityPolicy string `yaml:"content_security_policy"`
+}
+
+// API configures the versions of the REST API.
+type API struct {
+	// V1Deprecated and V1Sunset are announced in the Deprecation and Sunset
+	// headers of the v1 routes that have a v2 successor. A zero time omits
+	// its header.
+	V1Deprecated time.Time `yaml:"v1_deprecated"`
+	V1Sunset     time.Time `yaml:"v1_sunset"`
+}
+
+// RateLimit configures rate limiting.
+type RateLimit struct {
+	// RedisAddr shares counters across replicas; in-memory only when empty.
+	RedisAddr     string `yaml:"redis_addr"`
+	RedisPassword string `yaml:"redis_password"`
+	// TrustedProxies are the networks whose X-Forwarded-For is trusted.
+	TrustedProxies []netip.Prefix `yaml:"trusted_proxies"`
+	// MemoryKeys is the capacity of the in-memory fallback.
+	MemoryKeys int              `yaml:"memory_keys"`
+	Quotas     ratelimit.Config `yaml:"quotas"`
+}
+
+// Auth configures token validation and the built-in OAuth token endpoint.
+type Auth struct {
+	// JWKSURL and JWKSFile are where verification keys are loaded from;
+	// exactly one is required.
+	JWKSURL             string        `yaml:"jwks_url"`
+	JWKSFile            string        `yaml:"jwks_file"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval"`
+	// Issuer and Audience are the required iss and aud claims.
+	Issuer    string        `yaml:"issuer"`
+	Audience  string        `yaml:"audience"`
+	ClockSkew time.Duration `yaml:"clock_skew"`
+	OAuth     OAuth         `yaml:"oauth"`
+}
+
+// OAuth configures the client credentials token endpoint, which is
+// disabled when SigningKeyFile is empty.
+type OAuth struct {
+	SigningKeyFile string        `yaml:"signing_key_file"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+}
+
+// Logging configures what the logs may contain.
+type Logging struct {
+	// AccountIDs is how account ids are written to logs: plain, hash or
+	// redact.
+	AccountIDs logging.Mode `yaml:"account_ids"`
+}
+
+// Tracing configures the OpenTelemetry tracer. Exporters are configured
+// through the standard OTEL_* environment variables (see pkg/telemetry).
+type Tracing struct {
+	// SampleRatio is the fraction of root spans sampled, from 0 (none) to 1
+	// (all); requests with a traceparent follow the caller's decision.
+	// OTEL_TRACES_SAMPLER replaces this sampler.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Default returns the configuration used for everything the file and the
+// environment leave unset.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Addr:              ":8080",
+			ShutdownTimeout:   10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		TLS: TLS{ReloadInterval: time.Minute},
+		Upstreams: Upstreams{
+			DialTimeout:     5 * time.Second,
+			LoadBalancing:   "round_robin",
+			ResolveInterval: 30 * time.Second,
+			Payment: Upstream{
+				Addr:          "payment-service:8081",
+				SpiffeID:      "spiffe://securepay.dev/payment-service",
+				HealthService: "payment.v1.PaymentService",
+			},
+			Account: Upstream{
+				Addr:          "account-service:8082",
+				SpiffeID:      "spiffe://securepay.dev/account-service",
+				HealthService: "account.v1.AccountService",
+			},
+			CircuitBreaker: CircuitBreaker{FailureThreshold: 5, OpenTimeout: 10 * time.Second},
+			Retry:          Retry{MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond, MaxBackoff: time.Second},
+		},
+		Timeouts: Timeouts{
+			Default: 5 * time.Second,
+			Routes: map[string]time.Duration{
+				// The payment service persists and publishes every line of a
+				// batch before responding.
+				endpoints.InitiateBatchPaymentPathPattern: 60 * time.Second,
+				endpoints.WatchPaymentPathPattern:         0,
+			},
+		},
+		BodyLimits: BodyLimits{
+			Default: 1 << 20, // 1 MiB
+			Routes: map[string]int64{
+				endpoints.InitiateBatchPaymentPathPattern: 8 << 20, // 8 MiB
+			},
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Idempotency-Key", "X-API-Key"},
+			ExposedHeaders: []string{"Retry-After", "X-Trace-ID", "Deprecation", "Sunset", "Link"},
+			MaxAge:         10 * time.Minute,
+		},
+		SecurityHeaders: SecurityHeaders{
+			HSTSMaxAge: 365 * 24 * time.Hour,
+			// The gateway serves JSON only; nothing may be loaded or framed.
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		},
+		API: API{
+			V1Deprecated: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+			V1Sunset:     time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC),
+		},
+		RateLimit: RateLimit{
+			MemoryKeys: 100000,
+			Quotas:     *ratelimit.DefaultConfig(),
+		},
+		Auth: Auth{
+			JWKSRefreshInterval: 5 * time.Minute,
+			ClockSkew:           30 * time.Second,
+			OAuth:               OAuth{TokenTTL: 15 * time.Minute},
+		},
+		Tracing:      Tracing{SampleRatio: 1},
+		SpiffeSocket: "unix:///tmp/spire-agent/public/api.sock",
+		LogLevel:     slog.LevelInfo,
+		Logging:      Logging{AccountIDs: logging.Redact},
+	}
+}
+
+// Load reads the file named by CONFIG_FILE (if any), applies environment
+// overrides and validates the result.
+func Load() (*Config, error) {
+	cfg := Default()
+	if file := os.Getenv("CONFIG_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := cfg.parseYAML(data); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseYAML overlays the YAML document on cfg. Unknown keys are rejected so
+// that typos do not silently fall back to defaults.
+func (c *Config) parseYAML(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every problem found.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil, "server.addr %q: must be host:port", c.Server.Addr)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file and tls.key_file must be set together")
+	check(c.TLS.ReloadInterval > 0, "tls.reload_interval must be positive")
+
+	check(c.Upstreams.DialTimeout > 0, "upstreams.dial_timeout must be positive")
+	check(c.Upstreams.LoadBalancing == "round_robin" || c.Upstreams.LoadBalancing == "least_request",
+		"upstreams.load_balancing %q must be round_robin or least_request", c.Upstreams.LoadBalancing)
+	check(c.Upstreams.ResolveInterval > 0, "upstreams.resolve_interval must be positive")
+	for name, u := range map[string]Upstream{"payment": c.Upstreams.Payment, "account": c.Upstreams.Account} {
+		check(u.Addr != "", "upstreams.%s.addr is required", name)
+		if u.Addr != "" {
+			_, _, err := net.SplitHostPort(u.Addr)
+			check(err == nil, "upstreams.%s.addr %q must be host:port", name, u.Addr)
+		}
+		for _, e := range u.Endpoints {
+			_, _, err := net.SplitHostPort(e)
+			check(err == nil, "upstreams.%s.endpoints: %q must be host:port", name, e)
+		}
+		_, err := spiffeid.FromString(u.SpiffeID)
+		check(err == nil, "upstreams.%s.spiffe_id %q: %v", name, u.SpiffeID, err)
+	}
+	breaker, retry := c.Upstreams.CircuitBreaker, c.Upstreams.Retry
+	check(breaker.FailureThreshold >= 0, "upstreams.circuit_breaker.failure_threshold must not be negative")
+	check(breaker.FailureThreshold == 0 || breaker.OpenTimeout > 0, "upstreams.circuit_breaker.open_timeout must be positive")
+	check(retry.MaxAttempts >= 1, "upstreams.retry.max_attempts must be at least 1")
+	check(retry.InitialBackoff > 0 && retry.MaxBackoff >= retry.InitialBackoff, "upstreams.retry: initial_backoff must be positive and not above max_backoff")
+	check(retry.HedgeDelay >= 0, "upstreams.retry.hedge_delay must not be negative")
+
+	check(c.Timeouts.Default > 0, "timeouts.default must be positive")
+	for pattern, d := range c.Timeouts.Routes {
+		check(knownRoute(pattern), "timeouts.routes: unknown route %q", pattern)
+		check(d >= 0, "timeouts.routes %q: must not be negative", pattern)
+	}
+
+	check(c.BodyLimits.Default > 0, "body_limits.default must be positive")
+	for pattern, n := range c.BodyLimits.Routes {
+		// The OAuth endpoints cap their form bodies themselves.
+		_, ok := endpoints.RouteScopes[pattern]
+		check(ok, "body_limits.routes: unknown API route %q", pattern)
+		check(n > 0, "body_limits.routes %q: must be positive", pattern)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins %q: must be \"*\" or scheme://host[:port]", origin)
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+	check(c.SecurityHeaders.HSTSMaxAge >= 0, "security_headers.hsts_max_age must not be negative")
+
+	check(c.API.V1Deprecated.IsZero() || c.API.V1Sunset.IsZero() || c.API.V1Sunset.After(c.API.V1Deprecated),
+		"api.v1_sunset must be after api.v1_deprecated")
+
+	check(c.RateLimit.MemoryKeys > 0, "rate_limit.memory_keys must be positive")
+	if err := c.RateLimit.Quotas.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit.quotas: %w", err))
+	}
+	for pattern := range c.RateLimit.Quotas.Routes {
+		check(knownRoute(pattern), "rate_limit.quotas.routes: unknown route %q", pattern)
+	}
+
+	check((c.Auth.JWKSURL == "") != (c.Auth.JWKSFile == ""), "exactly one of auth.jwks_url and auth.jwks_file must be set")
+	check(c.Auth.Issuer != "" && c.Auth.Audience != "", "auth.issuer and auth.audience are required")
+	check(c.Auth.JWKSRefreshInterval > 0, "auth.jwks_refresh_interval must be positive")
+	check(c.Auth.ClockSkew >= 0, "auth.clock_skew must not be negative")
+	if c.Auth.OAuth.SigningKeyFile != "" {
+		check(c.Auth.OAuth.TokenTTL > 0, "auth.oauth.token_ttl must be positive")
+		check(c.DatabaseURL != "", "auth.oauth.signing_key_file requires database_url")
+	}
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.SpiffeSocket != "", "spiffe_socket is required")
+	check(slices.Contains(logging.Modes, c.Logging.AccountIDs), "logging.account_ids %q must be one of %v", c.Logging.AccountIDs, logging.Modes)
+	return errors.Join(errs...)
+}
+
+// knownRoute reports whether pattern is a route registered by the gateway.
+func knownRoute(pattern string) bool {
+	if _, ok := endpoints.RouteScopes[pattern]; ok {
+		return true
+	}
+	switch pattern {
+	case endpoints.OAuthTokenPathPattern, endpoints.OAuthRevokePathPattern, endpoints.OAuthIntrospectPathPattern:
+		return true
+	}
+	return false
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && !strings.Contains(origin, "@")
+}
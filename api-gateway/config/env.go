@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"securepay/api-gateway/logging"
+	"securepay/api-gateway/ratelimit"
+	"securepay/pkg/envconfig"
+)
+
+// applyEnv overrides the configuration with the environment variables the
+// gateway has always understood, so that existing deployments keep working
+// without a config file.
+func (c *Config) applyEnv() error {
+	var env envconfig.Loader
+	if v, ok := os.LookupEnv("PORT"); ok {
+		// PORT has historically been a bare port number.
+		if !strings.Contains(v, ":") {
+			v = ":" + v
+		}
+		c.Server.Addr = v
+	}
+	env.Duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.String("TLS_CERT_FILE", &c.TLS.CertFile)
+	env.String("TLS_KEY_FILE", &c.TLS.KeyFile)
+
+	env.Duration("UPSTREAM_DIAL_TIMEOUT", &c.Upstreams.DialTimeout)
+	env.String("UPSTREAM_LOAD_BALANCING", &c.Upstreams.LoadBalancing)
+	env.Duration("UPSTREAM_RESOLVE_INTERVAL", &c.Upstreams.ResolveInterval)
+	env.String("PAYMENT_SERVICE_ADDR", &c.Upstreams.Payment.Addr)
+	env.List("PAYMENT_SERVICE_ENDPOINTS", &c.Upstreams.Payment.Endpoints)
+	env.String("PAYMENT_SERVICE_SPIFFE_ID", &c.Upstreams.Payment.SpiffeID)
+	env.String("ACCOUNT_SERVICE_ADDR", &c.Upstreams.Account.Addr)
+	env.List("ACCOUNT_SERVICE_ENDPOINTS", &c.Upstreams.Account.Endpoints)
+	env.String("ACCOUNT_SERVICE_SPIFFE_ID", &c.Upstreams.Account.SpiffeID)
+
+	env.Duration("REQUEST_TIMEOUT", &c.Timeouts.Default)
+	env.Int64("REQUEST_BODY_LIMIT", &c.BodyLimits.Default)
+
+	env.List("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+
+	env.Time("API_V1_DEPRECATED", &c.API.V1Deprecated)
+	env.Time("API_V1_SUNSET", &c.API.V1Sunset)
+
+	env.String("REDIS_ADDR", &c.RateLimit.RedisAddr)
+	env.String("REDIS_PASSWORD", &c.RateLimit.RedisPassword)
+	envconfig.Func(&env, "RATE_LIMIT_TRUSTED_PROXIES", &c.RateLimit.TrustedProxies, func(v string) ([]netip.Prefix, error) {
+		return parsePrefixes(envconfig.SplitList(v))
+	})
+	env.Int("RATE_LIMIT_MEMORY_KEYS", &c.RateLimit.MemoryKeys)
+
+	env.String("JWKS_URL", &c.Auth.JWKSURL)
+	env.String("JWKS_FILE", &c.Auth.JWKSFile)
+	env.Duration("JWKS_REFRESH_INTERVAL", &c.Auth.JWKSRefreshInterval)
+	env.String("JWT_ISSUER", &c.Auth.Issuer)
+	env.String("JWT_AUDIENCE", &c.Auth.Audience)
+	env.Duration("JWT_CLOCK_SKEW", &c.Auth.ClockSkew)
+	env.String("OAUTH_SIGNING_KEY_FILE", &c.Auth.OAuth.SigningKeyFile)
+	env.Duration("OAUTH_TOKEN_TTL", &c.Auth.OAuth.TokenTTL)
+
+	env.Float("TRACE_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+	env.String("SPIFFE_ENDPOINT_SOCKET", &c.SpiffeSocket)
+	env.String("DATABASE_URL", &c.DatabaseURL)
+	env.Level("LOG_LEVEL", &c.LogLevel)
+	if v, ok := os.LookupEnv("LOG_ACCOUNT_IDS"); ok {
+		c.Logging.AccountIDs = logging.Mode(v)
+	}
+
+	errs := []error{env.Err()}
+	inline, file := os.Getenv("RATE_LIMIT_CONFIG"), os.Getenv("RATE_LIMIT_CONFIG_FILE")
+	if inline != "" || file != "" {
+		quotas, err := ratelimit.LoadConfig(inline, file)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			c.RateLimit.Quotas = *quotas
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
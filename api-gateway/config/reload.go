@@ -0,0 +1,41 @@
+package config
+
+import "reflect"
+
+// Reload returns the configuration to run with after next was loaded while
+// running with c. It takes the reloadable settings (timeouts, body limits,
+// rate limit quotas, CORS, log level and logging) from next and keeps everything else from c;
+// ignored lists the changed settings that only take effect after a restart.
+func (c *Config) Reload(next *Config) (applied *Config, ignored []string) {
+	applied = new(Config)
+	*applied = *c
+	applied.Timeouts = next.Timeouts
+	applied.BodyLimits = next.BodyLimits
+	applied.RateLimit.Quotas = next.RateLimit.Quotas
+	applied.CORS = next.CORS
+	applied.LogLevel = next.LogLevel
+	applied.Logging = next.Logging
+
+	// applied now differs from next only in settings that need a restart.
+	sections := []struct {
+		name string
+		get  func(*Config) any
+	}{
+		{"server", func(c *Config) any { return c.Server }},
+		{"tls", func(c *Config) any { return c.TLS }},
+		{"upstreams", func(c *Config) any { return c.Upstreams }},
+		{"rate_limit", func(c *Config) any { return c.RateLimit }},
+		{"security_headers", func(c *Config) any { return c.SecurityHeaders }},
+		{"api", func(c *Config) any { return c.API }},
+		{"auth", func(c *Config) any { return c.Auth }},
+		{"tracing", func(c *Config) any { return c.Tracing }},
+		{"spiffe_socket", func(c *Config) any { return c.SpiffeSocket }},
+		{"database_url", func(c *Config) any { return c.DatabaseURL }},
+	}
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.get(applied), s.get(next)) {
+			ignored = append(ignored, s.name)
+		}
+	}
+	return applied, ignored
+}
@@ -1,14 +1,45 @@
 package endpoints
 
-// APIPrefix is the base path for protected API endpoints.
+import "strings"
+
+// APIPrefix is the base path of the protected v1 API endpoints.
 const APIPrefix = "/api/v1/"
 
+// APIV2Prefix is the base path of the protected v2 API endpoints.
+const APIV2Prefix = "/api/v2/"
+
+// IsAPIPath reports whether path belongs to a version of the protected API.
+func IsAPIPath(path string) bool {
+	return strings.HasPrefix(path, APIPrefix) || strings.HasPrefix(path, APIV2Prefix)
+}
+
 // HealthCheckPath is the path for the health check endpoint.
 const HealthCheckPath = "/health"
 
+// ReadyPath is the path for the readiness endpoint reporting backend connectivity.
+const ReadyPath = "/ready"
+
 // MetricsPath is the path for Prometheus metrics.
 const MetricsPath = "/metrics"
 
+// OpenAPIPath is the path of the OpenAPI v3 description of the v1 API.
+const OpenAPIPath = "/openapi.json"
+
+// OpenAPIV2Path is the path of the OpenAPI v3 description of the v2 API.
+const OpenAPIV2Path = "/openapi-v2.json"
+
+// OAuth 2.0 endpoints. They authenticate clients themselves and are not
+// behind the JWT middleware.
+
+// OAuthTokenPathPattern is the route pattern for the client credentials token endpoint.
+const OAuthTokenPathPattern = "POST /oauth/token"
+
+// OAuthRevokePathPattern is the route pattern for token revocation (RFC 7009).
+const OAuthRevokePathPattern = "POST /oauth/revoke"
+
+// OAuthIntrospectPathPattern is the route pattern for token introspection (RFC 7662).
+const OAuthIntrospectPathPattern = "POST /oauth/introspect"
+
 // Protected Endpoints Patterns (including HTTP Method for Go 1.22+ mux)
 
 // InitiatePaymentPathPattern is the route pattern for initiating a payment.
@@ -17,5 +48,160 @@ const InitiatePaymentPathPattern = "POST " + APIPrefix + "payments"
 // GetPaymentPathPattern is the route pattern for retrieving payment details.
 const GetPaymentPathPattern = "GET " + APIPrefix + "payments/{id}"
 
+// WatchPaymentPathPattern is the route pattern for streaming payment status changes as Server-Sent Events.
+const WatchPaymentPathPattern = "GET " + APIPrefix + "payments/{id}/events"
+
+// InitiateSplitPaymentPathPattern is the route pattern for initiating a split payment to several payees.
+const InitiateSplitPaymentPathPattern = "POST " + APIPrefix + "payments/split"
+
+// InitiateBatchPaymentPathPattern is the route pattern for submitting a batch of payments (JSON or CSV).
+const InitiateBatchPaymentPathPattern = "POST " + APIPrefix + "payment-batches"
+
+// GetBatchPathPattern is the route pattern for retrieving a batch summary.
+const GetBatchPathPattern = "GET " + APIPrefix + "payment-batches/{id}"
+
+// RegisterWebhookPathPattern is the route pattern for registering a webhook endpoint.
+const RegisterWebhookPathPattern = "POST " + APIPrefix + "webhooks"
+
+// ListWebhooksPathPattern is the route pattern for listing the caller's webhook endpoints.
+const ListWebhooksPathPattern = "GET " + APIPrefix + "webhooks"
+
+// DeleteWebhookPathPattern is the route pattern for deleting a webhook endpoint.
+const DeleteWebhookPathPattern = "DELETE " + APIPrefix + "webhooks/{id}"
+
+// ListWebhookDeliveriesPathPattern is the route pattern for a webhook endpoint's delivery log.
+const ListWebhookDeliveriesPathPattern = "GET " + APIPrefix + "webhooks/{id}/deliveries"
+
+// RedeliverWebhookPathPattern is the route pattern for manually redelivering a webhook delivery.
+const RedeliverWebhookPathPattern = "POST " + APIPrefix + "webhook-deliveries/{id}/redeliver"
+
 // CheckBalancePathPattern is the route pattern for checking account balance.
 const CheckBalancePathPattern = "GET " + APIPrefix + "accounts/{id}/balance"
+
+// v2 API. Amounts are in minor units and ids are generated by the server.
+
+// CreatePaymentV2PathPattern is the route pattern for creating a payment.
+const CreatePaymentV2PathPattern = "POST " + APIV2Prefix + "payments"
+
+// GetPaymentV2PathPattern is the route pattern for retrieving payment details.
+const GetPaymentV2PathPattern = "GET " + APIV2Prefix + "payments/{id}"
+
+// CreateSplitPaymentV2PathPattern is the route pattern for creating a split payment.
+const CreateSplitPaymentV2PathPattern = "POST " + APIV2Prefix + "split-payments"
+
+// ListPaymentsV2PathPattern is the route pattern for paging through the payments of an account.
+const ListPaymentsV2PathPattern = "GET " + APIV2Prefix + "accounts/{id}/payments"
+
+// GetBalanceV2PathPattern is the route pattern for checking account balance.
+const GetBalanceV2PathPattern = "GET " + APIV2Prefix + "accounts/{id}/balance"
+
+// V1Successors maps the deprecated v1 routes to the v2 routes replacing
+// them. Their responses announce the v1 sunset.
+var V1Successors = map[string]string{
+	InitiatePaymentPathPattern:      CreatePaymentV2PathPattern,
+	GetPaymentPathPattern:           GetPaymentV2PathPattern,
+	InitiateSplitPaymentPathPattern: CreateSplitPaymentV2PathPattern,
+	CheckBalancePathPattern:         GetBalanceV2PathPattern,
+}
+
+// Media types of request and response bodies.
+const (
+	JSONMediaType = "application/json"
+	// ProtobufMediaType selects binary protobuf bodies, for internal
+	// clients built from the same protos.
+	ProtobufMediaType = "application/x-protobuf"
+	CSVMediaType      = "text/csv"
+)
+
+// DefaultMediaTypes are the request body media types accepted by routes
+// without an entry in RequestMediaTypes.
+var DefaultMediaTypes = []string{JSONMediaType, ProtobufMediaType}
+
+// RequestMediaTypes declares the request body media types of the routes
+// that accept others than DefaultMediaTypes.
+var RequestMediaTypes = map[string][]string{
+	InitiateBatchPaymentPathPattern: {JSONMediaType, ProtobufMediaType, CSVMediaType},
+	CreateAPIKeyPathPattern:         {JSONMediaType},
+	RotateAPIKeyPathPattern:         {JSONMediaType},
+}
+
+// CreateAPIKeyPathPattern is the route pattern for creating an API key.
+const CreateAPIKeyPathPattern = "POST " + APIPrefix + "api-keys"
+
+// ListAPIKeysPathPattern is the route pattern for listing the caller's API keys.
+const ListAPIKeysPathPattern = "GET " + APIPrefix + "api-keys"
+
+// RotateAPIKeyPathPattern is the route pattern for rotating an API key.
+const RotateAPIKeyPathPattern = "POST " + APIPrefix + "api-keys/{id}/rotate"
+
+// RevokeAPIKeyPathPattern is the route pattern for revoking an API key.
+const RevokeAPIKeyPathPattern = "DELETE " + APIPrefix + "api-keys/{id}"
+
+// OAuth 2.0 scopes understood by the gateway.
+const (
+	ScopePaymentsRead   = "payments:read"
+	ScopePaymentsWrite  = "payments:write"
+	ScopeAccountsRead   = "accounts:read"
+	ScopeWebhooksManage = "webhooks:manage"
+	// ScopeTokensIntrospect lets a client introspect tokens issued to other clients.
+	ScopeTokensIntrospect = "tokens:introspect"
+	// ScopeAPIKeysManage lets a subject create, rotate and revoke its API keys.
+	ScopeAPIKeysManage = "apikeys:manage"
+)
+
+// APIKeyScopes are the scopes that may be granted to API keys.
+var APIKeyScopes = []string{
+	ScopePaymentsRead,
+	ScopePaymentsWrite,
+	ScopeAccountsRead,
+	ScopeWebhooksManage,
+}
+
+// RouteScopes declares the scope each protected route requires. Every
+// pattern registered behind the auth middleware must have an entry; routes
+// without one are denied.
+var RouteScopes = map[string]string{
+	InitiatePaymentPathPattern:       ScopePaymentsWrite,
+	GetPaymentPathPattern:            ScopePaymentsRead,
+	WatchPaymentPathPattern:          ScopePaymentsRead,
+	InitiateSplitPaymentPathPattern:  ScopePaymentsWrite,
+	InitiateBatchPaymentPathPattern:  ScopePaymentsWrite,
+	GetBatchPathPattern:              ScopePaymentsRead,
+	RegisterWebhookPathPattern:       ScopeWebhooksManage,
+	ListWebhooksPathPattern:          ScopeWebhooksManage,
+	DeleteWebhookPathPattern:         ScopeWebhooksManage,
+	ListWebhookDeliveriesPathPattern: ScopeWebhooksManage,
+	RedeliverWebhookPathPattern:      ScopeWebhooksManage,
+	CheckBalancePathPattern:          ScopeAccountsRead,
+	CreatePaymentV2PathPattern:       ScopePaymentsWrite,
+	GetPaymentV2PathPattern:          ScopePaymentsRead,
+	CreateSplitPaymentV2PathPattern:  ScopePaymentsWrite,
+	ListPaymentsV2PathPattern:        ScopePaymentsRead,
+	GetBalanceV2PathPattern:          ScopeAccountsRead,
+	CreateAPIKeyPathPattern:          ScopeAPIKeysManage,
+	ListAPIKeysPathPattern:           ScopeAPIKeysManage,
+	RotateAPIKeyPathPattern:          ScopeAPIKeysManage,
+	RevokeAPIKeyPathPattern:          ScopeAPIKeysManage,
+}
+
+// APIKeyRoutes lists the routes that accept an X-API-Key header instead of a
+// bearer token. API key management itself requires a JWT.
+var APIKeyRoutes = map[string]bool{
+	InitiatePaymentPathPattern:       true,
+	GetPaymentPathPattern:            true,
+	WatchPaymentPathPattern:          true,
+	InitiateSplitPaymentPathPattern:  true,
+	InitiateBatchPaymentPathPattern:  true,
+	GetBatchPathPattern:              true,
+	RegisterWebhookPathPattern:       true,
+	ListWebhooksPathPattern:          true,
+	DeleteWebhookPathPattern:         true,
+	ListWebhookDeliveriesPathPattern: true,
+	RedeliverWebhookPathPattern:      true,
+	CheckBalancePathPattern:          true,
+	CreatePaymentV2PathPattern:       true,
+	GetPaymentV2PathPattern:          true,
+	CreateSplitPaymentV2PathPattern:  true,
+	ListPaymentsV2PathPattern:        true,
+	GetBalanceV2PathPattern:          true,
+}
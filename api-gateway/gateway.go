@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+
+	"securepay/api-gateway/endpoints"
+	"securepay/api-gateway/upstream"
+	accountv1 "securepay/proto/gen/go/account/v1"
+	accountv2 "securepay/proto/gen/go/account/v2"
+	paymentv1 "securepay/proto/gen/go/payment/v1"
+	paymentv2 "securepay/proto/gen/go/payment/v2"
+)
+
+// gatewayMarshaler encodes REST bodies with protojson. Fields keep their
+// proto names (from_account), as the API has always used, and enums are
+// encoded by name. Unknown request fields are rejected; protojson errors
+// name the field and its line and column.
+var gatewayMarshaler = &runtime.JSONPb{
+	MarshalOptions: protojson.MarshalOptions{UseProtoNames: true},
+}
+
+// protoMarshaler encodes application/x-protobuf bodies. The response is
+// protobuf when the request body is, unless the client accepts JSON.
+var protoMarshaler = &protobufMarshaler{}
+
+// protobufMarshaler labels binary protobuf with its media type rather than
+// application/octet-stream.
+type protobufMarshaler struct {
+	runtime.ProtoMarshaller
+}
+
+func (*protobufMarshaler) ContentType(any) string {
+	return endpoints.ProtobufMediaType
+}
+
+// responseMarshaler picks the marshaler of a hand-written handler's response
+// the way grpc-gateway does for the generated ones: by Accept, then by the
+// request Content-Type.
+func responseMarshaler(r *http.Request) runtime.Marshaler {
+	for _, accept := range r.Header.Values("Accept") {
+		switch accept {
+		case endpoints.ProtobufMediaType:
+			return protoMarshaler
+		case endpoints.JSONMediaType:
+			return gatewayMarshaler
+		}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == endpoints.ProtobufMediaType {
+		return protoMarshaler
+	}
+	return gatewayMarshaler
+}
+
+// newGatewayMux creates the REST handlers generated by grpc-gateway from
+// the google.api.http annotations of the protos, for both API versions.
+// The clients are wrapped with the checks of authorize.go. Request headers
+// are not forwarded to the backends, which authenticate the gateway and
+// receive the caller as a signed principal instead.
+func newGatewayMux(clients Clients) (*runtime.ServeMux, error) {
+	noHeaders := func(string) (string, bool) { return "", false }
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, gatewayMarshaler),
+		// Registered by name too so that Accept can ask for JSON.
+		runtime.WithMarshalerOption(endpoints.JSONMediaType, gatewayMarshaler),
+		runtime.WithMarshalerOption(endpoints.ProtobufMediaType, protoMarshaler),
+		runtime.WithErrorHandler(handleGatewayError),
+		runtime.WithIncomingHeaderMatcher(noHeaders),
+		runtime.WithOutgoingHeaderMatcher(noHeaders),
+	)
+
+	ctx := context.Background()
+	if err := paymentv1.RegisterPaymentServiceHandlerClient(ctx, mux, authorizedPaymentClient{clients.Payment}); err != nil {
+		return nil, fmt.Errorf("failed to register payment service handlers: %w", err)
+	}
+	if err := paymentv1.RegisterWebhookServiceHandlerClient(ctx, mux, authorizedWebhookClient{clients.Webhook}); err != nil {
+		return nil, fmt.Errorf("failed to register webhook service handlers: %w", err)
+	}
+	if err := accountv1.RegisterAccountServiceHandlerClient(ctx, mux, authorizedAccountClient{clients.Account}); err != nil {
+		return nil, fmt.Errorf("failed to register account service handlers: %w", err)
+	}
+	if err := paymentv2.RegisterPaymentServiceHandlerClient(ctx, mux, authorizedPaymentV2Client{clients.PaymentV2}); err != nil {
+		return nil, fmt.Errorf("failed to register payment service v2 handlers: %w", err)
+	}
+	if err := accountv2.RegisterAccountServiceHandlerClient(ctx, mux, authorizedAccountV2Client{clients.AccountV2}); err != nil {
+		return nil, fmt.Errorf("failed to register account service v2 handlers: %w", err)
+	}
+	return mux, nil
+}
+
+// handleGatewayError maps the gRPC status to the HTTP status and writes it
+// as JSON. An unavailable backend is answered with 503 and Retry-After so
+// that clients back off and retry.
+func handleGatewayError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	code := status.Code(err)
+	if code == codes.Unavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(int(upstream.RetryAfter.Seconds())))
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, &runtime.HTTPStatusError{HTTPStatus: httpStatusFromCode(code), Err: err})
+}
+
+// httpStatusFromCode is runtime.HTTPStatusFromCode, except that
+// FailedPrecondition, which the backends return for an idempotency key
+// reused with a different request, is a 409 Conflict.
+func httpStatusFromCode(code codes.Code) int {
+	if code == codes.FailedPrecondition {
+		return http.StatusConflict
+	}
+	return runtime.HTTPStatusFromCode(code)
+}
+
+// withStatus answers successful calls with code instead of 200. 204
+// responses are sent without the (empty) message body.
+func withStatus(code int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&statusWriter{ResponseWriter: w, code: code}, r)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	noBody      bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK {
+		code = w.code
+	}
+	if w.noBody = code == http.StatusNoContent; w.noBody {
+		w.Header().Del("Content-Type")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.noBody {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// openAPIHandler serves an OpenAPI v3 description of the REST API, given
+// in YAML, as JSON.
+func openAPIHandler(doc []byte) (http.Handler, error) {
+	var spec any
+	if err := yaml.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}), nil
+}
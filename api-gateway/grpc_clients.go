@@ -3,69 +3,117 @@ package main
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 
-	// Import generated code packages
+	"securepay/api-gateway/config"
+	"securepay/api-gateway/middleware"
+	"securepay/api-gateway/upstream"
+	"securepay/pkg/principal"
+	"securepay/pkg/spiffe"
 	accountv1 "securepay/proto/gen/go/account/v1"
+	accountv2 "securepay/proto/gen/go/account/v2"
 	paymentv1 "securepay/proto/gen/go/payment/v1"
+	paymentv2 "securepay/proto/gen/go/payment/v2"
 )
 
-// PaymentServiceAddr is the address of the Payment Service.
-const PaymentServiceAddr = "payment-service:8081"
-
-// AccountServiceAddr is the address of the Account Service.
-const AccountServiceAddr = "account-service:8082"
-
-// NewPaymentServiceClient creates a new gRPC client for the Payment Service.
-// It uses SPIFFE-based mTLS for secure communication.
-func NewPaymentServiceClient(ctx context.Context, source *workloadapi.X509Source) (paymentv1.PaymentServiceClient, *grpc.ClientConn, error) {
-	// Get mTLS credentials securely using SPIFFE.
-	creds, err := PaymentServiceCredentials(source)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get payment service credentials: %w", err)
-	}
-
-	// Dial the Payment Service using gRPC with mTLS.
-	// Returns immediately (async connection).
-	conn, err := grpc.DialContext(ctx, PaymentServiceAddr, creds,
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-	)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to dial payment service: %w", err)
-	}
-
-	slog.Info("Initialized Payment Service Client (async connect)", "address", PaymentServiceAddr)
-
-	// Create and return the generated gRPC client.
-	client := paymentv1.NewPaymentServiceClient(conn)
-	return client, conn, nil
+// Clients are the generated clients of every backend service and API
+// version the gateway serves.
+type Clients struct {
+	Payment   paymentv1.PaymentServiceClient
+	Webhook   paymentv1.WebhookServiceClient
+	Account   accountv1.AccountServiceClient
+	PaymentV2 paymentv2.PaymentServiceClient
+	AccountV2 accountv2.AccountServiceClient
 }
 
-// NewAccountServiceClient creates a new gRPC client for the Account Service.
-// It uses SPIFFE-based mTLS for secure communication.
-func NewAccountServiceClient(ctx context.Context, source *workloadapi.X509Source) (accountv1.AccountServiceClient, *grpc.ClientConn, error) {
-	// Get mTLS credentials securely using SPIFFE.
-	creds, err := AccountServiceCredentials(source)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get account service credentials: %w", err)
+// NewClients creates the clients from the backend connections. The webhook
+// service is served on the payment service connection.
+func NewClients(payment, account grpc.ClientConnInterface) Clients {
+	return Clients{
+		Payment:   paymentv1.NewPaymentServiceClient(payment),
+		Webhook:   paymentv1.NewWebhookServiceClient(payment),
+		Account:   accountv1.NewAccountServiceClient(account),
+		PaymentV2: paymentv2.NewPaymentServiceClient(payment),
+		AccountV2: accountv2.NewAccountServiceClient(account),
 	}
+}
 
-	// Dial the Account Service using gRPC with mTLS.
-	// Returns immediately (async connection).
-	conn, err := grpc.DialContext(ctx, AccountServiceAddr, creds,
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-	)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to dial account service: %w", err)
+// retryPolicy lists the backend calls that are safe to repeat. Reads are
+// always safe; writes only when the backend deduplicates them by
+// idempotency key.
+func retryPolicy(cfg config.Retry) upstream.RetryPolicy {
+	return upstream.RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		HedgeDelay:     cfg.HedgeDelay,
+		Idempotent: map[string]bool{
+			paymentv1.PaymentService_GetPayment_FullMethodName:            true,
+			paymentv1.PaymentService_GetBatch_FullMethodName:              true,
+			paymentv1.WebhookService_ListWebhooks_FullMethodName:          true,
+			paymentv1.WebhookService_ListWebhookDeliveries_FullMethodName: true,
+			accountv1.AccountService_CheckBalance_FullMethodName:          true,
+			paymentv2.PaymentService_GetPayment_FullMethodName:            true,
+			paymentv2.PaymentService_ListPayments_FullMethodName:          true,
+			accountv2.AccountService_GetBalance_FullMethodName:            true,
+		},
+		Keyed: map[string]bool{
+			paymentv1.PaymentService_InitiatePayment_FullMethodName:      true,
+			paymentv1.PaymentService_InitiateSplitPayment_FullMethodName: true,
+			paymentv2.PaymentService_CreatePayment_FullMethodName:        true,
+			paymentv2.PaymentService_CreateSplitPayment_FullMethodName:   true,
+		},
 	}
+}
 
-	slog.Info("Initialized Account Service Client (async connect)", "address", AccountServiceAddr)
+// NewBackend creates a lazily connected backend for a gRPC service.
+// It uses SPIFFE-based mTLS for secure communication. Generated clients
+// (paymentv1.NewPaymentServiceClient, ...) are created from the returned
+// Backend; their calls fail with codes.Unavailable until the connection exists.
+// Calls are spread over all replicas of the service (see upstream.NewResolver),
+// go through a circuit breaker, and safe calls are retried.
+func NewBackend(name string, source *workloadapi.X509Source, cfg config.Upstreams, target config.Upstream) *upstream.Backend {
+	signer := principal.NewSigner(source)
+	breaker := upstream.NewBreaker(name, upstream.BreakerConfig{
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      cfg.CircuitBreaker.OpenTimeout,
+	})
+	retries := retryPolicy(cfg.Retry)
+	return upstream.NewBackend(name, func(ctx context.Context) (*grpc.ClientConn, error) {
+		// Get mTLS credentials securely using SPIFFE.
+		creds, err := spiffe.ClientCredentials(source, target.SpiffeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s credentials: %w", name, err)
+		}
 
-	// Create and return the generated gRPC client.
-	client := accountv1.NewAccountServiceClient(conn)
-	return client, conn, nil
+		// Dial the service using gRPC with mTLS.
+		// Returns immediately (async connect).
+		dialCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+		defer cancel()
+		serviceConfig, err := upstream.ServiceConfig(cfg.LoadBalancing, target.HealthService)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s service config: %w", name, err)
+		}
+		conn, err := grpc.DialContext(dialCtx, upstream.Target(target.Addr), creds,
+			grpc.WithResolvers(upstream.NewResolver(target.Endpoints, cfg.ResolveInterval)),
+			grpc.WithDefaultServiceConfig(serviceConfig),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+			grpc.WithChainUnaryInterceptor(
+				retries.UnaryClientInterceptor(name),
+				breaker.UnaryClientInterceptor(),
+				signer.UnaryClientInterceptor(middleware.Principal),
+			),
+			grpc.WithChainStreamInterceptor(
+				breaker.StreamClientInterceptor(),
+				signer.StreamClientInterceptor(middleware.Principal),
+			),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial %s: %w", name, err)
+		}
+		return conn, nil
+	}, breaker)
 }
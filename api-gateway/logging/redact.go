@@ -0,0 +1,144 @@
+// Package logging keeps account ids, which identify customers, out of the
+// gateway logs.
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+)
+
+// Mode is how account ids are written to logs.
+type Mode string
+
+const (
+	// Plain writes account ids as they are.
+	Plain Mode = "plain"
+	// Hash replaces account ids with a short SHA-256 digest, so that the
+	// lines of one account can still be correlated.
+	Hash Mode = "hash"
+	// Redact replaces account ids with a fixed placeholder.
+	Redact Mode = "redact"
+)
+
+// Modes lists the valid modes.
+var Modes = []Mode{Plain, Hash, Redact}
+
+const redacted = "[REDACTED]"
+
+// accountPath matches the account id segment of request paths, such as
+// /api/v1/accounts/{id}/balance.
+var accountPath = regexp.MustCompile(`(/accounts/)([^/?#]+)`)
+
+// Redactor rewrites account ids in the records of the handlers it wraps.
+// They are found in the attributes named account_id and account_ids, and
+// in request paths logged as path. The mode can be changed at runtime.
+type Redactor struct {
+	mode atomic.Value // Mode
+}
+
+// NewRedactor returns a Redactor in the given mode.
+func NewRedactor(mode Mode) *Redactor {
+	r := &Redactor{}
+	r.SetMode(mode)
+	return r
+}
+
+// SetMode changes how account ids are written from now on.
+func (r *Redactor) SetMode(mode Mode) {
+	r.mode.Store(mode)
+}
+
+// Mode returns the current mode.
+func (r *Redactor) Mode() Mode {
+	return r.mode.Load().(Mode)
+}
+
+// Handler returns a handler that redacts records before passing them to
+// next. Attributes added with WithAttrs are redacted in the mode current at
+// that time.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	return &redactHandler{redactor: r, next: next}
+}
+
+type redactHandler struct {
+	redactor *Redactor
+	next     slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	mode := h.redactor.Mode()
+	if mode == Plain {
+		return h.next.Handle(ctx, r)
+	}
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a, mode))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	mode := h.redactor.Mode()
+	if mode != Plain {
+		redactedAttrs := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redactedAttrs[i] = redactAttr(a, mode)
+		}
+		attrs = redactedAttrs
+	}
+	return &redactHandler{redactor: h.redactor, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{redactor: h.redactor, next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr, mode Mode) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(ga, mode)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+
+	switch a.Key {
+	case "account_id":
+		return slog.String(a.Key, redactID(a.Value.String(), mode))
+	case "account_ids":
+		ids, ok := a.Value.Any().([]string)
+		if !ok {
+			return slog.String(a.Key, redacted)
+		}
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = redactID(id, mode)
+		}
+		return slog.Any(a.Key, out)
+	case "path":
+		return slog.String(a.Key, accountPath.ReplaceAllStringFunc(a.Value.String(), func(m string) string {
+			parts := accountPath.FindStringSubmatch(m)
+			return parts[1] + redactID(parts[2], mode)
+		}))
+	}
+	return a
+}
+
+func redactID(id string, mode Mode) string {
+	if mode == Hash {
+		sum := sha256.Sum256([]byte(id))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	}
+	return redacted
+}
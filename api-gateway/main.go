@@ -2,42 +2,88 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"errors"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	_ "github.com/lib/pq"
+
+	"securepay/api-gateway/apikey"
+	"securepay/api-gateway/config"
+	"securepay/api-gateway/logging"
+	"securepay/api-gateway/middleware"
+	"securepay/api-gateway/oauth"
+	"securepay/api-gateway/upstream"
+	"securepay/pkg/logger"
+	"securepay/pkg/spiffe"
+	"securepay/pkg/telemetry"
 )
 
+// version is reported in the telemetry resource; builds set it with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	// Logger Init (JSON format for production)
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	slog.SetDefault(logger)
+	// OTel-aware JSON logger, which adds trace_id and span_id to records
+	// logged with a span in the context; the level is reloadable. Account
+	// ids are redacted until the configuration says otherwise.
+	var logLevel slog.LevelVar
+	redactor := logging.NewRedactor(logging.Redact)
+	slog.SetDefault(slog.New(redactor.Handler(logger.NewHandler(os.Stdout, &logLevel))))
 
 	// Context with Graceful Shutdown Signals (SIGINT, SIGTERM)
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// 0. Initialize Tracer
-	shutdownTracer, err := InitTracer(ctx, "api-gateway")
+	// SIGHUP reloads the configuration. Registered before anything else so
+	// that an early SIGHUP does not terminate the process.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	// Configuration (CONFIG_FILE and environment)
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(cfg.LogLevel)
+	redactor.SetMode(cfg.Logging.AccountIDs)
+	var current atomic.Pointer[config.Config]
+	current.Store(cfg)
+
+	// 0. Initialize Telemetry (traces, and metrics and logs when exported)
+	tel, err := telemetry.Init(ctx, telemetry.Config{
+		ServiceName:    "api-gateway",
+		ServiceVersion: version,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
-		slog.Error("Failed to initialize tracer", "error", err)
+		slog.Error("Failed to initialize telemetry", "error", err)
 		os.Exit(1)
 	}
 	defer func() {
-		if err := shutdownTracer(context.Background()); err != nil {
-			slog.Error("Failed to shutdown tracer", "error", err)
+		if err := tel.Shutdown(context.Background()); err != nil {
+			slog.Error("Failed to shutdown telemetry", "error", err)
 		}
 	}()
+	if bridge := tel.LogHandler(); bridge != nil {
+		slog.SetDefault(slog.New(redactor.Handler(logger.NewHandler(os.Stdout, &logLevel, bridge))))
+	}
 
-	slog.Info("Starting API Gateway...", "port", 8080)
+	slog.Info("Starting API Gateway...", "addr", cfg.Server.Addr)
 
 	// 1. Initialize SPIFFE X.509 Source
-	// This connects to the SPIRE Agent via Unix socket defined in spiffe.go.
+	// This connects to the SPIRE Agent via the spiffe_socket Unix socket.
 	// Ensure SPIRE Agent is running and socket is accessible.
-	source, err := InitSPIFFESource(ctx)
+	source, err := spiffe.NewSource(ctx, cfg.SpiffeSocket)
 	if err != nil {
 		slog.Error("Failed to initialize SPIFFE source", "error", err)
 		os.Exit(1)
@@ -50,52 +96,160 @@ func main() {
 	slog.Info("SPIFFE Source initialized successfully")
 
 	// 2. Initialize gRPC Clients
-	// Use a short timeout for initial connection establishment
-	dialCtx, dialCancel := context.WithTimeout(ctx, 5*time.Second)
-	defer dialCancel()
+	// Backends connect in the background and are retried until they succeed;
+	// meanwhile their routes answer 503 and /ready reports them as down.
+	paymentBackend := NewBackend("payment-service", source, cfg.Upstreams, cfg.Upstreams.Payment)
+	defer paymentBackend.Close()
+	go paymentBackend.Run(ctx)
+	accountBackend := NewBackend("account-service", source, cfg.Upstreams, cfg.Upstreams.Account)
+	defer accountBackend.Close()
+	go accountBackend.Run(ctx)
+
+	clients := NewClients(paymentBackend, accountBackend)
+	backends := []*upstream.Backend{paymentBackend, accountBackend}
 
-	// Payment Service Client
-	paymentClient, paymentConn, err := NewPaymentServiceClient(dialCtx, source)
+	// 3. Gateway database (optional). It holds OAuth clients, revoked tokens
+	// and API keys; without it only externally issued JWTs are accepted.
+	var db *sql.DB
+	if cfg.DatabaseURL != "" {
+		db, err = sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("Failed to open database connection", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		if err := db.PingContext(ctx); err != nil {
+			slog.Error("Failed to ping database", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Gateway database connected")
+	}
+
+	// Built-in OAuth 2.0 token endpoint (optional)
+	issuer, err := NewOAuthIssuer(cfg.Auth)
 	if err != nil {
-		slog.Warn("Failed to connect to Payment Service (continuing without it)", "error", err)
-	} else {
-		defer paymentConn.Close()
-		slog.Info("Payment Service Client initialized")
+		slog.Error("Failed to initialize OAuth token issuer", "error", err)
+		os.Exit(1)
+	}
+	var oauthStore *oauth.PostgresStore
+	var revocations middleware.RevocationChecker
+	if issuer != nil {
+		oauthStore = oauth.NewPostgresStore(db)
+		revocations = oauthStore
+		slog.Info("OAuth token endpoint enabled", "token_ttl", issuer.TTL())
+	}
+
+	// API keys (enabled together with the gateway database)
+	var apiKeyServer *apikey.Server
+	var apiKeyAuth middleware.APIKeyAuthenticator
+	if db != nil {
+		apiKeyStore := apikey.NewPostgresStore(db)
+		apiKeyServer = apikey.NewServer(apiKeyStore)
+		apiKeyAuth = apikey.NewAuthenticator(apiKeyStore)
+		slog.Info("API key authentication enabled")
 	}
 
-	// Account Service Client
-	accountClient, accountConn, err := NewAccountServiceClient(dialCtx, source)
+	// 4. Load JWT verification keys (JWKS) and keep them fresh for key rotation
+	validator, keySet, err := NewJWTValidator(ctx, cfg.Auth, issuer, revocations)
 	if err != nil {
-		slog.Warn("Failed to connect to Account Service (continuing without it)", "error", err)
-	} else {
-		defer accountConn.Close()
-		slog.Info("Account Service Client initialized")
+		slog.Error("Failed to initialize JWT validation", "error", err)
+		os.Exit(1)
+	}
+	go keySet.Run(ctx)
+
+	var oauthServer *oauth.Server
+	if issuer != nil {
+		oauthServer = oauth.NewServer(oauthStore, issuer, validator)
+	}
+
+	// Rate limiting, shared across replicas through Redis
+	limiter, closeLimiter, err := NewRateLimiter(ctx, cfg.RateLimit)
+	if err != nil {
+		slog.Error("Failed to initialize rate limiter", "error", err)
+		os.Exit(1)
 	}
+	defer closeLimiter()
 
-	// 3. Setup Router (Inject dependencies)
-	router := NewRouter(paymentClient, accountClient)
+	// 5. Setup Router (Inject dependencies)
+	router, err := NewRouter(clients, RouterOptions{
+		Validator:  validator,
+		Limiter:    limiter,
+		OAuth:      oauthServer,
+		APIKeys:    apiKeyServer,
+		APIKeyAuth: apiKeyAuth,
+		Timeouts:   func(pattern string) time.Duration { return current.Load().Timeouts.For(pattern) },
+		BodyLimits: func(pattern string) int64 { return current.Load().BodyLimits.For(pattern) },
+		API:        cfg.API,
+		Backends:   backends,
+	})
+	if err != nil {
+		slog.Error("Failed to create router", "error", err)
+		os.Exit(1)
+	}
 
-	// 4. Start HTTP Server
+	go watchConfig(ctx, reload, &current, limiter, &logLevel, redactor)
+
+	// CORS and security headers apply to every response, including the
+	// preflight requests that match no route.
+	cors := func() middleware.CORSPolicy {
+		c := current.Load().CORS
+		return middleware.CORSPolicy{
+			AllowedOrigins: c.AllowedOrigins,
+			AllowedMethods: c.AllowedMethods,
+			AllowedHeaders: c.AllowedHeaders,
+			ExposedHeaders: c.ExposedHeaders,
+			MaxAge:         c.MaxAge,
+		}
+	}
+	securityHeaders := middleware.SecurityHeadersMiddleware(middleware.SecurityHeaders{
+		HSTSMaxAge:            cfg.SecurityHeaders.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.SecurityHeaders.HSTSIncludeSubdomains,
+		ContentSecurityPolicy: cfg.SecurityHeaders.ContentSecurityPolicy,
+	})
+	handler := securityHeaders(middleware.CORSMiddleware(cors)(router))
+
+	// 6. Start HTTP Server
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:              cfg.Server.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	if cfg.TLS.Enabled() {
+		certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			slog.Error("Failed to load TLS certificate", "error", err)
+			os.Exit(1)
+		}
+		go certs.watch(ctx, cfg.TLS.ReloadInterval)
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
 	}
 
 	// Run server in a specific goroutine
 	go func() {
-		slog.Info("HTTP Server listening on :8080")
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Info("HTTP Server listening", "addr", srv.Addr, "tls", cfg.TLS.Enabled())
+		var err error
+		if cfg.TLS.Enabled() {
+			// The certificate comes from TLSConfig.GetCertificate.
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("HTTP Server failed", "error", err)
 			os.Exit(1)
 		}
 	}()
 
-	// 5. Wait for Shutdown Signal
+	// 7. Wait for Shutdown Signal
 	<-ctx.Done()
 	slog.Info("Shutdown signal received, initiating graceful shutdown...")
 
 	// Create a timeout context for shutdown operations
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer shutdownCancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScopeMiddleware(t *testing.T) {
+	scopes := map[string]string{
+		"POST /api/v1/payments":     "payments:write",
+		"GET /api/v1/payments/{id}": "payments:read",
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux := http.NewServeMux()
+	for pattern := range scopes {
+		mux.Handle(pattern, ok)
+	}
+	// Registered without a scope declaration
+	mux.Handle("DELETE /api/v1/payments/{id}", ok)
+
+	// The mux sets r.Pattern before the scope check runs, as in the router
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		r.Pattern = pattern
+		ScopeMiddleware(scopes)(mux).ServeHTTP(w, r)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		claims *Claims
+		want   int
+	}{
+		{"granted scope", http.MethodPost, "/api/v1/payments", &Claims{Scope: "payments:write"}, http.StatusOK},
+		{"one of several scopes", http.MethodGet, "/api/v1/payments/p1", &Claims{Scope: "accounts:read payments:read"}, http.StatusOK},
+		{"missing scope", http.MethodPost, "/api/v1/payments", &Claims{Scope: "payments:read"}, http.StatusForbidden},
+		{"scope prefix only", http.MethodPost, "/api/v1/payments", &Claims{Scope: "payments:write:all"}, http.StatusForbidden},
+		{"no scopes", http.MethodGet, "/api/v1/payments/p1", &Claims{}, http.StatusForbidden},
+		{"no claims", http.MethodGet, "/api/v1/payments/p1", nil, http.StatusForbidden},
+		{"undeclared route", http.MethodDelete, "/api/v1/payments/p1", &Claims{Scope: "payments:write payments:read"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.claims != nil {
+				r = r.WithContext(WithClaims(r.Context(), tt.claims))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnershipChecks(t *testing.T) {
+	owner := &Claims{AccountIDs: []string{"acc-1", "acc-2"}}
+	ops := &Claims{Roles: []string{RoleOps}}
+
+	tests := []struct {
+		name     string
+		claims   *Claims
+		accounts []string
+		wantOwn  bool
+		wantView bool
+	}{
+		{"owns all", owner, []string{"acc-1", "acc-2"}, true, true},
+		{"owns one", owner, []string{"acc-1", "acc-3"}, false, true},
+		{"owns none", owner, []string{"acc-3"}, false, false},
+		{"empty account id", owner, []string{""}, false, false},
+		{"ops views but does not own", ops, []string{"acc-3"}, false, true},
+		{"no claims", nil, []string{"acc-1"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = WithClaims(ctx, tt.claims)
+			}
+
+			err := CheckOwnership(ctx, tt.accounts...)
+			if (err == nil) != tt.wantOwn {
+				t.Errorf("CheckOwnership() error = %v, want allowed %v", err, tt.wantOwn)
+			}
+			if err != nil && status.Code(err) != codes.PermissionDenied {
+				t.Errorf("CheckOwnership() code = %s, want %s", status.Code(err), codes.PermissionDenied)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil).WithContext(ctx)
+			if got := RequireOwnership(w, r, tt.accounts...); got != tt.wantOwn {
+				t.Errorf("RequireOwnership() = %v, want %v", got, tt.wantOwn)
+			}
+			if !tt.wantOwn && w.Code != http.StatusForbidden {
+				t.Errorf("RequireOwnership() status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+
+			if err := CheckViewAccess(ctx, tt.accounts...); (err == nil) != tt.wantView {
+				t.Errorf("CheckViewAccess() error = %v, want allowed %v", err, tt.wantView)
+			}
+		})
+	}
+}
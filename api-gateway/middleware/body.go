@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BodyMiddleware checks request bodies before the handler decodes them. A
+// body larger than the current limit of the route, looked up by pattern so
+// that it can change at runtime, is rejected with 413. A non-empty body
+// must have one of the media types of the route in mediaTypes, or of
+// defaults for routes without an entry, or it is rejected with 415.
+//
+// The body is read up front, so that chunked uploads are held to the limit
+// as well and handlers never decode a truncated body.
+func BodyMiddleware(limit func(pattern string) int64, mediaTypes map[string][]string, defaults []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			max := limit(r.Pattern)
+			if r.ContentLength > max {
+				tooLarge(w, max)
+				return
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, max))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					tooLarge(w, max)
+					return
+				}
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+
+			if len(body) > 0 {
+				accepted, ok := mediaTypes[r.Pattern]
+				if !ok {
+					accepted = defaults
+				}
+				mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || !slices.Contains(accepted, mediaType) {
+					// RFC 9110 suggests Accept to list what would have been accepted.
+					w.Header().Set("Accept", strings.Join(accepted, ", "))
+					http.Error(w, "Unsupported Content-Type, use one of: "+strings.Join(accepted, ", "), http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tooLarge(w http.ResponseWriter, max int64) {
+	http.Error(w, "Request body larger than "+strconv.FormatInt(max, 10)+" bytes", http.StatusRequestEntityTooLarge)
+}
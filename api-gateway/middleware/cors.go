@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy configures cross-origin access from browsers. CORS is disabled
+// when no origins are allowed.
+type CORSPolicy struct {
+	// AllowedOrigins are exact origins, or "*" to allow any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// CORSMiddleware answers preflight requests and adds the CORS headers to
+// the responses of allowed origins. It must wrap the whole mux: preflights
+// are OPTIONS requests, which match no route and carry no credentials. The
+// policy is looked up per request so that it can change at runtime.
+//
+// Requests from origins that are not allowed are served without CORS
+// headers, so browsers keep the response from the page; their preflights
+// are answered with 403.
+func CORSMiddleware(policy func() CORSPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := policy()
+			origin := r.Header.Get("Origin")
+			if len(p.AllowedOrigins) == 0 || origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			wildcard := slices.Contains(p.AllowedOrigins, "*")
+			if !wildcard && !slices.Contains(p.AllowedOrigins, origin) {
+				if preflight {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Credentials travel in headers, never in cookies, so any
+			// allowed origin can be answered with "*" under the wildcard.
+			if wildcard {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if !preflight {
+				if len(p.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !slices.Contains(p.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+				http.Error(w, "Method not allowed by CORS policy", http.StatusForbidden)
+				return
+			}
+			for _, name := range requestedHeaders(r) {
+				if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+					http.Error(w, "Header "+name+" not allowed by CORS policy", http.StatusForbidden)
+					return
+				}
+			}
+			h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+			if p.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// requestedHeaders lists the headers named by Access-Control-Request-Headers.
+func requestedHeaders(r *http.Request) []string {
+	var names []string
+	for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeprecationMiddleware marks the responses of deprecated routes, looked up
+// by pattern in successors, with the Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers and a successor-version Link to the route replacing
+// them. A zero time omits its header; with both zero nothing is added.
+func DeprecationMiddleware(deprecated, sunset time.Time, successors map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if deprecated.IsZero() && sunset.IsZero() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if successor, ok := successors[r.Pattern]; ok {
+				h := w.Header()
+				if !deprecated.IsZero() {
+					h.Set("Deprecation", "@"+strconv.FormatInt(deprecated.Unix(), 10))
+				}
+				if !sunset.IsZero() {
+					h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+				}
+				h.Add("Link", "<"+successorPath(successor, r)+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// successorPath returns the path of a route pattern with its wildcards set
+// to the values of the same name in r: "GET /api/v2/payments/{id}" becomes
+// /api/v2/payments/42 for a request to /api/v1/payments/42.
+func successorPath(pattern string, r *http.Request) string {
+	_, path, _ := strings.Cut(pattern, " ")
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if name, ok := strings.CutPrefix(s, "{"); ok {
+			segments[i] = url.PathEscape(r.PathValue(strings.TrimSuffix(name, "}")))
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// minForcedRefreshInterval limits how often an unknown kid can trigger a
+// JWKS fetch, so that forged tokens cannot be used to hammer the issuer.
+const minForcedRefreshInterval = 30 * time.Second
+
+// maxJWKSBytes caps the size of a JWKS document.
+const maxJWKSBytes = 1 << 20
+
+// ErrUnknownKey is returned when no verification key matches a token's kid.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWKSConfig configures where verification keys are loaded from. Exactly one
+// of URL and File must be set.
+type JWKSConfig struct {
+	URL  string
+	File string
+	// RefreshInterval is how often the key set is reloaded in the background.
+	RefreshInterval time.Duration
+	// HTTPClient is used for URL sources; nil uses a client with a 10s timeout.
+	HTTPClient *http.Client
+	// LocalKeys are always trusted in addition to the loaded JWKS, e.g. the
+	// key of the gateway's own token endpoint.
+	LocalKeys []jose.JSONWebKey
+}
+
+// KeySet caches the public keys of a JWKS document indexed by kid. Keys are
+// refreshed periodically and on demand when a token references an unknown
+// kid, which makes key rotation at the issuer transparent.
+type KeySet struct {
+	cfg JWKSConfig
+
+	mu          sync.RWMutex
+	keys        map[string]jose.JSONWebKey
+	lastRefresh time.Time
+
+	// refreshMu serialises fetches so concurrent misses trigger a single request.
+	refreshMu sync.Mutex
+}
+
+// NewKeySet loads the key set once and fails if it cannot be loaded.
+func NewKeySet(ctx context.Context, cfg JWKSConfig) (*KeySet, error) {
+	if (cfg.URL == "") == (cfg.File == "") {
+		return nil, errors.New("exactly one of JWKS URL and JWKS file must be set")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 5 * time.Minute
+	}
+
+	ks := &KeySet{cfg: cfg}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Run reloads the key set every RefreshInterval until ctx is cancelled.
+// Failed reloads keep the previously loaded keys.
+func (ks *KeySet) Run(ctx context.Context) {
+	ticker := time.NewTicker(ks.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.refresh(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to refresh JWKS, keeping previous keys", "error", err)
+			}
+		}
+	}
+}
+
+// Key returns the verification key with the given kid, reloading the key
+// set if the kid is unknown and the last reload is old enough.
+func (ks *KeySet) Key(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	// Another request may have refreshed while we waited for the lock.
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	ks.mu.RLock()
+	recent := time.Since(ks.lastRefresh) < minForcedRefreshInterval
+	ks.mu.RUnlock()
+	if recent {
+		return jose.JSONWebKey{}, ErrUnknownKey
+	}
+
+	if err := ks.load(ctx); err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+	return jose.JSONWebKey{}, ErrUnknownKey
+}
+
+func (ks *KeySet) lookup(kid string) (jose.JSONWebKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+	return ks.load(ctx)
+}
+
+// load fetches and parses the JWKS document; callers must hold refreshMu.
+func (ks *KeySet) load(ctx context.Context) error {
+	data, err := ks.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.KeyID == "" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		if !key.IsPublic() {
+			// Never keep private material around, even if it was published by mistake.
+			key = key.Public()
+		}
+		if !key.Valid() {
+			continue
+		}
+		keys[key.KeyID] = key
+	}
+	for _, key := range ks.cfg.LocalKeys {
+		keys[key.KeyID] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no usable signing keys")
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.lastRefresh = time.Now()
+	ks.mu.Unlock()
+
+	slog.InfoContext(ctx, "Loaded JWKS", "keys", len(keys))
+	return nil
+}
+
+func (ks *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if ks.cfg.File != "" {
+		data, err := os.ReadFile(ks.cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.cfg.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ks.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+	return data, nil
+}
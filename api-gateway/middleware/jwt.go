@@ -1,62 +1,159 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"securepay/api-gateway/endpoints"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("securepay-secret-key")
+// allowedSigningMethods lists the accepted JWS algorithms. Symmetric (HMAC)
+// algorithms are deliberately excluded: the gateway only holds public keys.
+var allowedSigningMethods = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
 
-// AuthMiddleware validates JWT tokens for requests to /api/v1/ endpoints.
-// It skips validation for paths not starting with /api/v1/ and for /health endpoints.
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip validation for non-API endpoints
-		if !strings.HasPrefix(r.URL.Path, endpoints.APIPrefix) {
-			next.ServeHTTP(w, r)
-			return
-		}
+// ErrTokenRevoked is returned for tokens whose jti has been revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
 
-		// Explicitly skip /health if it happens to be under /api/v1/ prefix
-		// or if any other public endpoint is needed in future.
-		// Task says /health is exempt. Assuming /health is root, it's covered by prefix check above.
-		// But adding explicit check for robustness if path structure changes.
-		if strings.HasSuffix(r.URL.Path, endpoints.HealthCheckPath) {
-			next.ServeHTTP(w, r)
-			return
-		}
+// RevocationChecker reports whether a token ID has been revoked.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// JWTConfig holds the claims every token must satisfy.
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// ClockSkew is the leeway applied to exp, nbf and iat.
+	ClockSkew time.Duration
+	// Revocations, when set, is consulted for every token carrying a jti.
+	Revocations RevocationChecker
+}
+
+// JWTValidator verifies bearer tokens against a KeySet.
+type JWTValidator struct {
+	keys        *KeySet
+	parser      *jwt.Parser
+	revocations RevocationChecker
+}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
+// NewJWTValidator creates a validator enforcing iss, aud and exp (nbf and iat
+// are checked when present).
+func NewJWTValidator(keys *KeySet, cfg JWTConfig) (*JWTValidator, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("JWT issuer and audience must be configured")
+	}
+
+	return &JWTValidator{
+		keys:        keys,
+		revocations: cfg.Revocations,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(allowedSigningMethods),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithLeeway(cfg.ClockSkew),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+	}, nil
+}
+
+// Validate parses and verifies a token and returns its claims.
+func (v *JWTValidator) Validate(ctx context.Context, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token has no kid header")
+		}
+		key, err := v.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, fmt.Errorf("kid %q: %w", kid, err)
 		}
+		// A key pinned to an algorithm must not be used with another one.
+		if key.Algorithm != "" && key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("kid %q does not allow algorithm %s", kid, token.Method.Alg())
+		}
+		return key.Key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
+	// Fail closed: a token is not accepted while its revocation state is unknown.
+	if v.revocations != nil && claims.ID != "" {
+		revoked, err := v.revocations.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
 		}
+	}
+	return claims, nil
+}
 
-		tokenString := bearerToken[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// AuthMiddleware validates JWT tokens for requests to /api/v1/ and /api/v2/ endpoints.
+// Requests carrying an X-API-Key header are authenticated with apiKeys
+// instead, on the routes it allows; apiKeys may be nil to accept JWTs only.
+// It skips validation for other paths and for /health endpoints.
+func AuthMiddleware(validator *JWTValidator, apiKeys *APIKeyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Skip validation for non-API endpoints
+			if !endpoints.IsAPIPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
 			}
-			return jwtSecret, nil
-		})
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			// Explicitly skip /health if it happens to be under /api/v1/ prefix
+			// or if any other public endpoint is needed in future.
+			// Task says /health is exempt. Assuming /health is root, it's covered by prefix check above.
+			// But adding explicit check for robustness if path structure changes.
+			if strings.HasSuffix(r.URL.Path, endpoints.HealthCheckPath) {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		// Token is valid, proceed
-		next.ServeHTTP(w, r)
-	})
+			if key := r.Header.Get(APIKeyHeader); key != "" {
+				claims := authenticateAPIKey(w, r, apiKeys, key)
+				if claims == nil {
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+				return
+			}
+
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			bearerToken := strings.Split(authHeader, " ")
+			if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
+				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := validator.Validate(r.Context(), bearerToken[1])
+			if err != nil {
+				Logger(r.Context()).WarnContext(r.Context(), "Rejected token", "error", err, "path", r.URL.Path)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// Token is valid, expose the claims to handlers and proceed
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
 }
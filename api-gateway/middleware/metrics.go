@@ -1,14 +1,25 @@
 package middleware
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// latencyBuckets are tuned to the latency SLOs: reads within 100ms and
+// payments within 300ms, with the batch upload and its 60s timeout at the
+// far end.
+var latencyBuckets = []float64{.005, .01, .025, .05, .075, .1, .15, .2, .3, .5, .75, 1, 2.5, 5, 10, 30, 60}
+
+// sizeBuckets span 64 bytes to 16 MiB, past the largest body limit.
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+
 var (
 	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "http_requests_total",
@@ -18,36 +29,136 @@ var (
 	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "http_request_duration_seconds",
 		Help:    "Duration of HTTP requests.",
-		Buckets: prometheus.DefBuckets,
+		Buckets: latencyBuckets,
+	}, []string{"method", "path"})
+
+	httpRequestsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	}, []string{"method", "path"})
+
+	httpRequestSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_size_bytes",
+		Help:    "Size of HTTP request bodies read by the gateway.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "path"})
+
+	httpResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "Size of HTTP response bodies.",
+		Buckets: sizeBuckets,
 	}, []string{"method", "path"})
 )
 
-// MetricsMiddleware records metrics for each HTTP request.
+// MetricsMiddleware records metrics for each HTTP request. Requests are
+// labelled with the path of the route pattern that matched (e.g.
+// /api/v1/payments/{id}) rather than the requested path, which would create
+// a series per resource id. Latencies and counts carry the trace id of the
+// span started by TracingMiddleware as an exemplar, exposed to scrapers
+// that negotiate OpenMetrics.
 func MetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		path := routePath(r.Pattern)
+
+		inFlight := httpRequestsInFlight.WithLabelValues(r.Method, path)
+		inFlight.Inc()
+		defer inFlight.Dec()
 
-		// Use a custom ResponseWriter to capture the status code
-		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		// Count what is actually read and written: chunked requests have no
+		// Content-Length, and streams have no length at all.
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		rw := &responseWriter{ResponseWriter: w}
 
 		next.ServeHTTP(rw, r)
 
-		duration := time.Since(start).Seconds()
+		status := rw.status
+		if status == 0 {
+			// Nothing was written, which net/http answers with 200.
+			status = http.StatusOK
+		}
+		exemplar := traceExemplar(r)
+		observe(httpRequestDuration.WithLabelValues(r.Method, path), time.Since(start).Seconds(), exemplar)
+		observe(httpRequestSize.WithLabelValues(r.Method, path), float64(body.n), exemplar)
+		observe(httpResponseSize.WithLabelValues(r.Method, path), float64(rw.written), exemplar)
+		counter := httpRequestsTotal.WithLabelValues(r.Method, path, strconv.Itoa(status))
+		if exemplar != nil {
+			counter.(prometheus.ExemplarAdder).AddWithExemplar(1, exemplar)
+		} else {
+			counter.Inc()
+		}
+	})
+}
 
-		// Get path pattern from request or use actual path
-		path := r.URL.Path
+// routePath strips the method from a route pattern. Requests that matched
+// no pattern share a single series.
+func routePath(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
 
-		httpRequestsTotal.WithLabelValues(r.Method, path, strconv.Itoa(rw.status)).Inc()
-		httpRequestDuration.WithLabelValues(r.Method, path).Observe(duration)
-	})
+// traceExemplar returns the exemplar labels of a sampled request, nil
+// otherwise: unsampled traces cannot be looked up.
+func traceExemplar(r *http.Request) prometheus.Labels {
+	sc := trace.SpanContextFromContext(r.Context())
+	if !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
+
+func observe(o prometheus.Observer, v float64, exemplar prometheus.Labels) {
+	if exemplar != nil {
+		o.(prometheus.ExemplarObserver).ObserveWithExemplar(v, exemplar)
+		return
+	}
+	o.Observe(v)
 }
 
 type responseWriter struct {
 	http.ResponseWriter
-	status int
+	status  int
+	written int64
 }
 
+// WriteHeader records the first status only; net/http ignores later ones.
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.status = code
+	if rw.status == 0 {
+		rw.status = code
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Write records the implicit 200 of handlers that never call WriteHeader.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.written += int64(n)
+	return n, err
+}
+
+// Unwrap exposes the underlying writer so http.ResponseController can reach
+// optional interfaces such as http.Flusher (needed for SSE).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
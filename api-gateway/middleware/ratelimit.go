@@ -1,81 +1,141 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
+
+	"securepay/api-gateway/ratelimit"
 )
 
-// client struct to track request count and window start time for each IP.
-type client struct {
-	count     int
-	startTime time.Time
+// RateLimiter enforces per-caller quotas. Callers are identified by the
+// authenticated subject, so it must run after AuthMiddleware; anonymous
+// requests are keyed by client IP.
+type RateLimiter struct {
+	limiter        ratelimit.Limiter
+	config         atomic.Pointer[ratelimit.Config]
+	trustedProxies []netip.Prefix
 }
 
-var (
-	// clients map stores the rate limit state for each IP.
-	// In a production environment with multiple replicas, this should be in Redis.
-	// For this task, strictly in-memory map is used as requested.
-	clients = make(map[string]*client)
-	mu      sync.Mutex
-)
+// NewRateLimiter creates a RateLimiter. X-Forwarded-For is only honoured
+// when the connection comes from one of the trusted proxy networks.
+func NewRateLimiter(limiter ratelimit.Limiter, config *ratelimit.Config, trustedProxies []netip.Prefix) *RateLimiter {
+	rl := &RateLimiter{limiter: limiter, trustedProxies: trustedProxies}
+	rl.config.Store(config)
+	return rl
+}
+
+// SetConfig replaces the quotas at runtime. Counters are kept; a changed
+// window starts counting afresh.
+func (rl *RateLimiter) SetConfig(config *ratelimit.Config) {
+	rl.config.Store(config)
+}
 
-// RateLimitMiddleware enforces a limit of 100 requests per minute per IP.
-func RateLimitMiddleware(next http.Handler) http.Handler {
+// Middleware counts the request against the caller's plan quota and, when
+// the route has one and the plan allows the request, the route quota, so a
+// request refused by the plan does not use up the route quota. It sets the
+// RateLimit-* headers of the most restrictive quota and answers 429 with
+// Retry-After when exceeded. Limiter errors fail open.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := getClientIP(r)
+		caller, plan := rl.caller(r)
+		config := rl.config.Load()
 
-		mu.Lock()
-		c, exists := clients[ip]
-		if !exists {
-			clients[ip] = &client{count: 1, startTime: time.Now()}
-			mu.Unlock()
-			next.ServeHTTP(w, r)
-			return
+		type check struct {
+			key   string
+			quota ratelimit.Quota
+		}
+		checks := []check{{"caller:" + caller, config.PlanQuota(plan)}}
+		if q, ok := config.RouteQuota(r.Pattern); ok {
+			checks = append(checks, check{"route:" + r.Pattern + ":" + caller, q})
 		}
 
-		// Check if the 1-minute window has passed
-		if time.Since(c.startTime) > time.Minute {
-			// Reset counter and start new window
-			c.count = 1
-			c.startTime = time.Now()
-		} else {
-			// Increment counter
-			c.count++
+		var tightest *ratelimit.Decision
+		var tightestQuota ratelimit.Quota
+		for _, c := range checks {
+			d, err := rl.limiter.Allow(r.Context(), c.key, c.quota)
+			if err != nil {
+				Logger(r.Context()).WarnContext(r.Context(), "Rate limiter failed, allowing request", "error", err)
+				continue
+			}
+			if tightest == nil || !d.Allowed || d.Remaining < tightest.Remaining {
+				tightest, tightestQuota = &d, c.quota
+			}
+			if !d.Allowed {
+				break
+			}
 		}
 
-		// Check limit
-		if c.count > 100 {
-			mu.Unlock()
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
-			return
+		if tightest != nil {
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+			h.Set("RateLimit-Reset", seconds(tightest.Reset))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", tightestQuota.Limit, seconds(tightestQuota.Window)))
+			if !tightest.Allowed {
+				Logger(r.Context()).WarnContext(r.Context(), "Rate limit exceeded", "caller", caller, "plan", plan, "path", r.URL.Path)
+				h.Set("Retry-After", seconds(tightest.RetryAfter))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
 		}
 
-		mu.Unlock()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// getClientIP extracts the client's real IP address from the request.
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (standard for proxies)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+// caller returns the rate limit key and plan of the request.
+func (rl *RateLimiter) caller(r *http.Request) (key, plan string) {
+	if claims := ClaimsFromContext(r.Context()); claims != nil && claims.Subject != "" {
+		return "sub:" + claims.Subject, claims.Plan
+	}
+	return "ip:" + rl.ClientIP(r), ""
+}
+
+// ClientIP returns the address of the client. X-Forwarded-For is walked
+// from the right, skipping trusted proxies, so that entries prepended by the
+// client itself are ignored.
+func (rl *RateLimiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !rl.trusted(remote.Unmap()) {
+		return host
 	}
 
-	// Check X-Real-Ip header
-	realIP := r.Header.Get("X-Real-Ip")
-	if realIP != "" {
-		return realIP
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := remote.Unmap()
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !rl.trusted(client) {
+			break
+		}
 	}
+	return client.String()
+}
 
-	// Fallback to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+func (rl *RateLimiter) trusted(addr netip.Addr) bool {
+	for _, prefix := range rl.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
 	}
-	return ip
+	return false
+}
+
+// seconds formats d as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
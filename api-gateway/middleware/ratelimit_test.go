@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/ratelimit"
+)
+
+const batchesRoute = "POST /api/v1/payment-batches"
+
+// newRateLimited returns a handler applying rl behind a mux, so that
+// r.Pattern is set as in the router.
+func newRateLimited(rl *RateLimiter) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux := http.NewServeMux()
+	mux.Handle(batchesRoute, rl.Middleware(ok))
+	mux.Handle("GET /api/v1/payments", rl.Middleware(ok))
+	return mux
+}
+
+func rateLimitRequest(method, path, subject, plan string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if subject != "" {
+		claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: subject}, Plan: plan}
+		r = r.WithContext(WithClaims(r.Context(), claims))
+	}
+	return r
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	config := &ratelimit.Config{
+		Default: ratelimit.Quota{Limit: 3, Window: time.Hour},
+		Plans:   map[string]ratelimit.Quota{"enterprise": {Limit: 5, Window: time.Hour}},
+		Routes:  map[string]ratelimit.Quota{batchesRoute: {Limit: 2, Window: time.Hour}},
+	}
+
+	type request struct {
+		method, path, subject, plan string
+	}
+	tests := []struct {
+		name string
+		// before are sent first and must all pass.
+		before []request
+		req    request
+		want   int
+		// wantLimit is the RateLimit-Limit of the tightest quota.
+		wantLimit int
+	}{
+		{
+			name:      "default quota",
+			before:    []request{{"GET", "/api/v1/payments", "alice", ""}, {"GET", "/api/v1/payments", "alice", ""}},
+			req:       request{"GET", "/api/v1/payments", "alice", ""},
+			want:      http.StatusOK,
+			wantLimit: 3,
+		},
+		{
+			name:      "default quota exceeded",
+			before:    []request{{"GET", "/api/v1/payments", "alice", ""}, {"GET", "/api/v1/payments", "alice", ""}, {"GET", "/api/v1/payments", "alice", ""}},
+			req:       request{"GET", "/api/v1/payments", "alice", ""},
+			want:      http.StatusTooManyRequests,
+			wantLimit: 3,
+		},
+		{
+			name:      "plan quota",
+			before:    []request{{"GET", "/api/v1/payments", "alice", "enterprise"}, {"GET", "/api/v1/payments", "alice", "enterprise"}, {"GET", "/api/v1/payments", "alice", "enterprise"}},
+			req:       request{"GET", "/api/v1/payments", "alice", "enterprise"},
+			want:      http.StatusOK,
+			wantLimit: 5,
+		},
+		{
+			name:      "other caller",
+			before:    []request{{"GET", "/api/v1/payments", "alice", ""}, {"GET", "/api/v1/payments", "alice", ""}, {"GET", "/api/v1/payments", "alice", ""}},
+			req:       request{"GET", "/api/v1/payments", "bob", ""},
+			want:      http.StatusOK,
+			wantLimit: 3,
+		},
+		{
+			name:      "anonymous callers by address",
+			before:    []request{{"GET", "/api/v1/payments", "", ""}, {"GET", "/api/v1/payments", "", ""}, {"GET", "/api/v1/payments", "", ""}},
+			req:       request{"GET", "/api/v1/payments", "", ""},
+			want:      http.StatusTooManyRequests,
+			wantLimit: 3,
+		},
+		{
+			name:      "route quota exceeded",
+			before:    []request{{"POST", "/api/v1/payment-batches", "alice", "enterprise"}, {"POST", "/api/v1/payment-batches", "alice", "enterprise"}},
+			req:       request{"POST", "/api/v1/payment-batches", "alice", "enterprise"},
+			want:      http.StatusTooManyRequests,
+			wantLimit: 2,
+		},
+		{
+			name:      "route quota leaves other routes",
+			before:    []request{{"POST", "/api/v1/payment-batches", "alice", "enterprise"}, {"POST", "/api/v1/payment-batches", "alice", "enterprise"}},
+			req:       request{"GET", "/api/v1/payments", "alice", "enterprise"},
+			want:      http.StatusOK,
+			wantLimit: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRateLimited(NewRateLimiter(ratelimit.NewMemoryLimiter(100), config, nil))
+			for i, req := range tt.before {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, rateLimitRequest(req.method, req.path, req.subject, req.plan))
+				if w.Code != http.StatusOK {
+					t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, http.StatusOK)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, rateLimitRequest(tt.req.method, tt.req.path, tt.req.subject, tt.req.plan))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("RateLimit-Limit"); got != strconv.Itoa(tt.wantLimit) {
+				t.Errorf("RateLimit-Limit = %q, want %d", got, tt.wantLimit)
+			}
+			if got := w.Header().Get("RateLimit-Policy"); got != strconv.Itoa(tt.wantLimit)+";w=3600" {
+				t.Errorf("RateLimit-Policy = %q, want %d;w=3600", got, tt.wantLimit)
+			}
+
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.want != http.StatusTooManyRequests {
+				if retryAfter != "" {
+					t.Errorf("Retry-After = %q on an allowed request", retryAfter)
+				}
+				return
+			}
+			if n, err := strconv.Atoi(retryAfter); err != nil || n <= 0 {
+				t.Errorf("Retry-After = %q, want a positive number of seconds", retryAfter)
+			}
+			if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+				t.Errorf("RateLimit-Remaining = %q, want 0", got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterPlanDenialKeepsRouteQuota(t *testing.T) {
+	route := map[string]ratelimit.Quota{batchesRoute: {Limit: 2, Window: time.Hour}}
+	rl := NewRateLimiter(ratelimit.NewMemoryLimiter(100), &ratelimit.Config{
+		Default: ratelimit.Quota{Limit: 1, Window: time.Hour},
+		Routes:  route,
+	}, nil)
+	handler := newRateLimited(rl)
+
+	send := func(want, wantLimit int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, rateLimitRequest(http.MethodPost, "/api/v1/payment-batches", "alice", ""))
+		if w.Code != want {
+			t.Fatalf("status = %d, want %d", w.Code, want)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != strconv.Itoa(wantLimit) {
+			t.Errorf("RateLimit-Limit = %q, want %d", got, wantLimit)
+		}
+	}
+
+	send(http.StatusOK, 1)
+	// Refused by the plan, so the route quota must not be used up.
+	send(http.StatusTooManyRequests, 1)
+
+	// Once the plan allows more, the route quota has one request left.
+	rl.SetConfig(&ratelimit.Config{Default: ratelimit.Quota{Limit: 10, Window: time.Hour}, Routes: route})
+	send(http.StatusOK, 2)
+	send(http.StatusTooManyRequests, 2)
+}
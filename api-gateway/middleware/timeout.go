@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeoutMiddleware sets a deadline on the request context, which bounds
+// the backend calls made by the handler. The timeout is looked up per
+// request by route pattern so that it can change at runtime; a zero timeout
+// leaves the request without a deadline, as needed by event streams.
+func TimeoutMiddleware(timeout func(pattern string) time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d := timeout(r.Pattern); d > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), d)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
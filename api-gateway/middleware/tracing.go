@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"log/slog"
 	"net/http"
 
 	"go.opentelemetry.io/otel"
@@ -9,7 +8,8 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// TracingMiddleware adds OpenTelemetry tracing to the request.
+// TracingMiddleware adds OpenTelemetry tracing to the request. The request
+// itself is logged by LoggingMiddleware.
 func TracingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -25,17 +25,9 @@ func TracingMiddleware(next http.Handler) http.Handler {
 		defer span.End()
 
 		// 3. Add trace_id to response header
-		traceID := span.SpanContext().TraceID().String()
-		w.Header().Set("X-Trace-ID", traceID)
+		w.Header().Set("X-Trace-ID", span.SpanContext().TraceID().String())
 
-		// 4. Log with trace_id
-		slog.Info("Incoming Request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"trace_id", traceID,
-		)
-
-		// 5. Pass context to next handler
+		// 4. Pass context to next handler
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,123 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/middleware"
+)
+
+// IssuerConfig configures the tokens issued by the gateway. Issuer and
+// Audience must match what the gateway's own validator expects.
+type IssuerConfig struct {
+	KeyFile  string
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+}
+
+// Issuer signs access tokens with a private key whose public half is
+// trusted by the gateway's JWT validator.
+type Issuer struct {
+	cfg    IssuerConfig
+	key    crypto.Signer
+	method jwt.SigningMethod
+	jwk    jose.JSONWebKey
+}
+
+// NewIssuer loads the PKCS#8 signing key. The kid is the RFC 7638
+// thumbprint of the key, as produced by "token keygen".
+func NewIssuer(cfg IssuerConfig) (*Issuer, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("token issuer and audience must be configured")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Minute
+	}
+
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	iss := &Issuer{cfg: cfg}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		iss.key, iss.method = key, jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		iss.key, iss.method = key, jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		iss.key, iss.method = key, jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", parsed)
+	}
+
+	iss.jwk = jose.JSONWebKey{Key: iss.key.Public(), Algorithm: iss.method.Alg(), Use: "sig"}
+	thumb, err := iss.jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key thumbprint: %w", err)
+	}
+	iss.jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumb)
+	return iss, nil
+}
+
+// PublicKey returns the verification key of issued tokens.
+func (iss *Issuer) PublicKey() jose.JSONWebKey {
+	return iss.jwk
+}
+
+// TTL returns the lifetime of issued tokens.
+func (iss *Issuer) TTL() time.Duration {
+	return iss.cfg.TTL
+}
+
+// Issue signs an access token for the client carrying the granted scopes.
+func (iss *Issuer) Issue(client *Client, scopes []string) (string, *middleware.Claims, error) {
+	now := time.Now()
+	claims := &middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rand.Text(),
+			Subject:   client.ID,
+			Issuer:    iss.cfg.Issuer,
+			Audience:  jwt.ClaimStrings{iss.cfg.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(iss.cfg.TTL)),
+		},
+		Tenant:     client.Tenant,
+		Scope:      strings.Join(scopes, " "),
+		Roles:      client.Roles,
+		AccountIDs: client.AccountIDs,
+		Plan:       client.Plan,
+	}
+
+	token := jwt.NewWithClaims(iss.method, claims)
+	token.Header["kid"] = iss.jwk.KeyID
+	signed, err := token.SignedString(iss.key)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, claims, nil
+}
@@ -0,0 +1,178 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+
+	"securepay/api-gateway/middleware"
+)
+
+const (
+	testIssuer   = "https://auth.securepay.test"
+	testAudience = "securepay-api"
+)
+
+// revokedSet is a RevocationChecker over a fixed set of token IDs.
+type revokedSet map[string]bool
+
+func (s revokedSet) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return s[jti], nil
+}
+
+// newTestIssuer writes a fresh ES256 key and returns an Issuer using it.
+func newTestIssuer(t *testing.T) (*Issuer, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	iss, err := NewIssuer(IssuerConfig{KeyFile: keyFile, Issuer: testIssuer, Audience: testAudience, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return iss, key
+}
+
+// newTestValidator trusts the issuer's key, as the gateway does, next to a
+// JWKS holding an RSA key pinned to RS256 under kid "rsa-1".
+func newTestValidator(t *testing.T, iss *Issuer, rsaKey *rsa.PrivateKey, revoked revokedSet) *middleware.JWTValidator {
+	t.Helper()
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: rsaKey.Public(), KeyID: "rsa-1", Algorithm: "RS256", Use: "sig"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := middleware.NewKeySet(context.Background(), middleware.JWKSConfig{
+		File:      jwksFile,
+		LocalKeys: []jose.JSONWebKey{iss.PublicKey()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := middleware.NewJWTValidator(keys, middleware.JWTConfig{Issuer: testIssuer, Audience: testAudience, Revocations: revoked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestIssuedTokensAgainstValidator(t *testing.T) {
+	iss, key := newTestIssuer(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{ID: "merchant-1", Tenant: "acme", Plan: "pro"}
+	issued, _, err := iss.Issue(client, []string{"payments:write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revokedToken, revokedClaims, err := iss.Issue(client, []string{"payments:write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newTestValidator(t, iss, rsaKey, revokedSet{revokedClaims.ID: true})
+
+	kid := iss.PublicKey().KeyID
+	claims := func(mutate func(*jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		now := time.Now()
+		c := jwt.RegisteredClaims{
+			Subject:   client.ID,
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+		if mutate != nil {
+			mutate(&c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, c jwt.RegisteredClaims, key any) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		// want is the error the token is rejected with; nil accepts it.
+		want error
+	}{
+		{"issued token", issued, nil},
+		{"revoked token", revokedToken, middleware.ErrTokenRevoked},
+		{"HS256 with the public key as secret", sign(jwt.SigningMethodHS256, kid, claims(nil), []byte(kid)), jwt.ErrTokenSignatureInvalid},
+		{"alg none", sign(jwt.SigningMethodNone, kid, claims(nil), jwt.UnsafeAllowNoneSignatureType), jwt.ErrTokenSignatureInvalid},
+		{"no kid", sign(jwt.SigningMethodES256, "", claims(nil), key), jwt.ErrTokenUnverifiable},
+		{"unknown kid", sign(jwt.SigningMethodES256, "unknown", claims(nil), key), middleware.ErrUnknownKey},
+		{"issuer kid, other key", sign(jwt.SigningMethodES256, kid, claims(nil), otherKey), jwt.ErrTokenSignatureInvalid},
+		{"RS256 key used with RS512", sign(jwt.SigningMethodRS512, "rsa-1", claims(nil), rsaKey), jwt.ErrTokenSignatureInvalid},
+		{"RS256 kid with ES256", sign(jwt.SigningMethodES256, "rsa-1", claims(nil), key), jwt.ErrTokenUnverifiable},
+		{"expired", sign(jwt.SigningMethodES256, kid, claims(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}), key), jwt.ErrTokenExpired},
+		{"no expiry", sign(jwt.SigningMethodES256, kid, claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil }), key), jwt.ErrTokenRequiredClaimMissing},
+		{"other audience", sign(jwt.SigningMethodES256, kid, claims(func(c *jwt.RegisteredClaims) {
+			c.Audience = jwt.ClaimStrings{"other-api"}
+		}), key), jwt.ErrTokenInvalidAudience},
+		{"other issuer", sign(jwt.SigningMethodES256, kid, claims(func(c *jwt.RegisteredClaims) { c.Issuer = "https://evil.test" }), key), jwt.ErrTokenInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Validate(context.Background(), tt.token)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want none", err)
+				}
+				if got.Subject != client.ID || got.Tenant != client.Tenant || !got.HasScope("payments:write") {
+					t.Errorf("Validate() claims = %+v", got)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
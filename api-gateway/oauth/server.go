@@ -0,0 +1,263 @@
+// Package oauth implements the gateway's built-in OAuth 2.0 authorization
+// server for machine clients: the client credentials grant (RFC 6749 §4.4),
+// token revocation (RFC 7009) and token introspection (RFC 7662).
+//
+// Client records with PBKDF2-hashed secrets and allowed scopes are stored in
+// Postgres. Issued tokens are short-lived JWTs signed with a key the
+// gateway's JWT validator trusts, so they are accepted by AuthMiddleware like
+// tokens of an external identity provider.
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"securepay/api-gateway/endpoints"
+	"securepay/api-gateway/middleware"
+)
+
+// maxFormBytes caps the size of a token endpoint request body.
+const maxFormBytes = 64 << 10
+
+// Server serves the token, revocation and introspection endpoints.
+type Server struct {
+	store     Store
+	issuer    *Issuer
+	validator *middleware.JWTValidator
+}
+
+// NewServer creates a Server. The validator must trust the issuer's key and
+// check revocations against the same store.
+func NewServer(store Store, issuer *Issuer, validator *middleware.JWTValidator) *Server {
+	return &Server{store: store, issuer: issuer, validator: validator}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type introspectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+	Tenant    string   `json:"tenant,omitempty"`
+}
+
+// oauthError is an RFC 6749 §5.2 error response.
+type oauthError struct {
+	status      int
+	code        string
+	description string
+}
+
+func (e *oauthError) Error() string {
+	return e.code + ": " + e.description
+}
+
+var errInvalidClient = &oauthError{http.StatusUnauthorized, "invalid_client", "client authentication failed"}
+
+// HandleToken issues an access token for the client_credentials grant.
+func (s *Server) HandleToken(w http.ResponseWriter, r *http.Request) {
+	client, err := s.authenticate(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if grant := r.PostForm.Get("grant_type"); grant != "client_credentials" {
+		writeError(w, &oauthError{http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported"})
+		return
+	}
+
+	scopes := client.Scopes
+	if requested := strings.Fields(r.PostForm.Get("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(client.Scopes, scope) {
+				middleware.Logger(r.Context()).WarnContext(r.Context(), "Rejected token request for unauthorized scope", "client_id", client.ID, "scope", scope)
+				writeError(w, &oauthError{http.StatusBadRequest, "invalid_scope", "scope " + scope + " is not allowed for this client"})
+				return
+			}
+		}
+		scopes = requested
+	}
+
+	token, claims, err := s.issuer.Issue(client, scopes)
+	if err != nil {
+		middleware.Logger(r.Context()).ErrorContext(r.Context(), "Failed to issue token", "error", err, "client_id", client.ID)
+		writeError(w, &oauthError{http.StatusInternalServerError, "server_error", "failed to issue token"})
+		return
+	}
+	middleware.Logger(r.Context()).InfoContext(r.Context(), "Issued access token", "client_id", client.ID, "jti", claims.ID, "scope", claims.Scope)
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.issuer.TTL() / time.Second),
+		Scope:       claims.Scope,
+	})
+}
+
+// HandleRevoke revokes a token issued to the calling client. As required by
+// RFC 7009, invalid, expired and already revoked tokens are acknowledged
+// with 200 as well.
+func (s *Server) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	client, err := s.authenticate(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, &oauthError{http.StatusBadRequest, "invalid_request", "token is required"})
+		return
+	}
+
+	claims, err := s.validator.Validate(r.Context(), token)
+	if err != nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if claims.Subject != client.ID {
+		middleware.Logger(r.Context()).WarnContext(r.Context(), "Rejected revocation of another client's token", "client_id", client.ID, "jti", claims.ID)
+		writeError(w, &oauthError{http.StatusBadRequest, "unauthorized_client", "token was not issued to this client"})
+		return
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		writeError(w, &oauthError{http.StatusBadRequest, "unsupported_token_type", "token cannot be revoked"})
+		return
+	}
+
+	if err := s.store.RevokeToken(r.Context(), claims.ID, client.ID, claims.ExpiresAt.Time); err != nil {
+		middleware.Logger(r.Context()).ErrorContext(r.Context(), "Failed to revoke token", "error", err, "client_id", client.ID, "jti", claims.ID)
+		writeError(w, &oauthError{http.StatusServiceUnavailable, "temporarily_unavailable", "failed to revoke token"})
+		return
+	}
+	middleware.Logger(r.Context()).InfoContext(r.Context(), "Revoked access token", "client_id", client.ID, "jti", claims.ID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleIntrospect reports whether a token is active. Clients may introspect
+// their own tokens; resource servers need the tokens:introspect scope to
+// introspect tokens of other clients.
+func (s *Server) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	client, err := s.authenticate(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, &oauthError{http.StatusBadRequest, "invalid_request", "token is required"})
+		return
+	}
+
+	claims, err := s.validator.Validate(r.Context(), token)
+	if err != nil || (claims.Subject != client.ID && !slices.Contains(client.Scopes, endpoints.ScopeTokensIntrospect)) {
+		writeJSON(w, http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+
+	resp := introspectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.Subject,
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		Tenant:    claims.Tenant,
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.Nbf = claims.NotBefore.Unix()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// authenticate parses the form and authenticates the client with either
+// HTTP Basic (client_secret_basic) or form parameters (client_secret_post).
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*Client, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormBytes)
+	if err := r.ParseForm(); err != nil {
+		return nil, &oauthError{http.StatusBadRequest, "invalid_request", "invalid form body"}
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		if r.PostForm.Has("client_secret") {
+			return nil, &oauthError{http.StatusBadRequest, "invalid_request", "multiple client authentication methods"}
+		}
+		// RFC 6749 §2.3.1: credentials are form-encoded before Basic encoding.
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		secret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil {
+			return nil, errInvalidClient
+		}
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		return nil, errInvalidClient
+	}
+
+	client, err := s.store.GetClient(r.Context(), clientID)
+	if errors.Is(err, ErrClientNotFound) {
+		VerifySecret(dummySecretHash(), secret)
+		middleware.Logger(r.Context()).WarnContext(r.Context(), "Client authentication failed", "client_id", clientID, "reason", "unknown client")
+		return nil, errInvalidClient
+	}
+	if err != nil {
+		middleware.Logger(r.Context()).ErrorContext(r.Context(), "Failed to load client", "error", err, "client_id", clientID)
+		return nil, &oauthError{http.StatusServiceUnavailable, "temporarily_unavailable", "failed to authenticate client"}
+	}
+	if !VerifySecret(client.SecretHash, secret) || !client.Active {
+		middleware.Logger(r.Context()).WarnContext(r.Context(), "Client authentication failed", "client_id", clientID, "reason", "invalid secret or inactive client")
+		return nil, errInvalidClient
+	}
+	return client, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var oe *oauthError
+	if !errors.As(err, &oe) {
+		oe = &oauthError{http.StatusInternalServerError, "server_error", "internal error"}
+	}
+	if oe.status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="securepay"`)
+	}
+	writeJSON(w, oe.status, map[string]string{"error": oe.code, "error_description": oe.description})
+}
+
+// writeJSON writes an uncacheable JSON response (RFC 6749 §5.1).
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
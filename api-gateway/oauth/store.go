@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+// ErrClientNotFound is returned when a client does not exist.
+var ErrClientNotFound = errors.New("client not found")
+
+// Client is a registered machine client allowed to use the client
+// credentials grant.
+type Client struct {
+	ID         string
+	SecretHash string
+	Tenant     string
+	// Scopes are the scopes the client may request.
+	Scopes     []string
+	Roles      []string
+	AccountIDs []string
+	// Plan selects the rate limit quota of the client.
+	Plan   string
+	Active bool
+}
+
+// Store persists clients and revoked tokens.
+type Store interface {
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+	RevokeToken(ctx context.Context, jti, clientID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// PostgresStore implements Store
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// GetClient loads a client by ID.
+func (s *PostgresStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.GetOAuthClient")
+	defer span.End()
+
+	c := &Client{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT client_id, secret_hash, tenant, scopes, roles, account_ids, plan, active
+		FROM gateway.oauth_clients
+		WHERE client_id = $1
+	`, clientID).Scan(&c.ID, &c.SecretHash, &c.Tenant, pq.Array(&c.Scopes), pq.Array(&c.Roles), pq.Array(&c.AccountIDs), &c.Plan, &c.Active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return c, nil
+}
+
+// RevokeToken records a revoked token ID until the token would have expired
+// anyway. Entries of tokens that have since expired are pruned on the way.
+func (s *PostgresStore) RevokeToken(ctx context.Context, jti, clientID string, expiresAt time.Time) error {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.RevokeToken")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO gateway.revoked_tokens (jti, client_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, clientID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM gateway.revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether a token ID has been revoked.
+func (s *PostgresStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, span := otel.Tracer("api-gateway").Start(ctx, "postgres.IsTokenRevoked")
+	defer span.End()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM gateway.revoked_tokens WHERE jti = $1)
+	`, jti).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"securepay/api-gateway/config"
+	"securepay/api-gateway/middleware"
+	"securepay/api-gateway/ratelimit"
+)
+
+// NewRateLimiter builds the rate limiter. Counters are shared through Redis
+// when configured, with an in-memory fallback; the returned function closes
+// the Redis client.
+func NewRateLimiter(ctx context.Context, cfg config.RateLimit) (*middleware.RateLimiter, func() error, error) {
+	quotas := cfg.Quotas
+	memory := ratelimit.NewMemoryLimiter(cfg.MemoryKeys)
+
+	if cfg.RedisAddr == "" {
+		slog.Warn("Redis not configured, rate limits are enforced per replica")
+		return middleware.NewRateLimiter(memory, &quotas, cfg.TrustedProxies), func() error { return nil }, nil
+	}
+
+	// Short timeouts: a slow Redis must not add noticeable latency before
+	// the in-memory fallback takes over.
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.RedisAddr,
+		Password:     cfg.RedisPassword,
+		DialTimeout:  time.Second,
+		ReadTimeout:  200 * time.Millisecond,
+		WriteTimeout: 200 * time.Millisecond,
+	})
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := client.Ping(pingCtx).Err(); err != nil {
+		slog.Warn("Redis unavailable, rate limiting falls back to in-memory until it recovers", "error", err, "addr", cfg.RedisAddr)
+	}
+
+	limiter := ratelimit.NewFallbackLimiter(ratelimit.NewRedisLimiter(client), memory)
+	return middleware.NewRateLimiter(limiter, &quotas, cfg.TrustedProxies), client.Close, nil
+}
@@ -0,0 +1,137 @@
+// Package ratelimit implements sliding-window rate limiting shared across
+// gateway replicas through Redis, with an in-process fallback.
+//
+// Quotas are configured as JSON (or the equivalent YAML in the gateway
+// config file). Every request counts against the quota of
+// the caller's plan (or the default quota); routes with their own quota are
+// additionally limited per caller:
+//
+//	{
+//	  "default": {"limit": 100, "window": "1m"},
+//	  "plans": {
+//	    "free":       {"limit": 60, "window": "1m"},
+//	    "enterprise": {"limit": 1000, "window": "1m"}
+//	  },
+//	  "routes": {
+//	    "POST /api/v1/payment-batches": {"limit": 10, "window": "1m"},
+//	    "POST /oauth/token":            {"limit": 20, "window": "1m"}
+//	  }
+//	}
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Quota allows Limit requests per sliding Window.
+type Quota struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+}
+
+// UnmarshalJSON accepts the window as a Go duration string.
+func (q *Quota) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Limit  int    `json:"limit"`
+		Window string `json:"window"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	window, err := time.ParseDuration(raw.Window)
+	if err != nil {
+		return fmt.Errorf("invalid window %q: %w", raw.Window, err)
+	}
+	q.Limit, q.Window = raw.Limit, window
+	return nil
+}
+
+func (q Quota) validate() error {
+	if q.Limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	if q.Window < time.Second {
+		return errors.New("window must be at least 1s")
+	}
+	return nil
+}
+
+// Config holds the configured quotas.
+type Config struct {
+	Default Quota            `json:"default" yaml:"default"`
+	Plans   map[string]Quota `json:"plans" yaml:"plans"`
+	// Routes holds per-route quotas keyed by route pattern.
+	Routes map[string]Quota `json:"routes" yaml:"routes"`
+}
+
+// DefaultConfig allows 100 requests per minute per caller.
+func DefaultConfig() *Config {
+	return &Config{Default: Quota{Limit: 100, Window: time.Minute}}
+}
+
+// ParseConfig parses and validates a JSON quota configuration. An empty
+// input yields DefaultConfig; a missing "default" keeps its default quota.
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := DefaultConfig()
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that every quota is usable.
+func (c *Config) Validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("default quota: %w", err)
+	}
+	for plan, q := range c.Plans {
+		if err := q.validate(); err != nil {
+			return fmt.Errorf("plan %q: %w", plan, err)
+		}
+	}
+	for route, q := range c.Routes {
+		if err := q.validate(); err != nil {
+			return fmt.Errorf("route %q: %w", route, err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads the configuration from file when set, otherwise from the
+// inline JSON.
+func LoadConfig(inline, file string) (*Config, error) {
+	data := []byte(inline)
+	if file != "" {
+		var err error
+		if data, err = os.ReadFile(file); err != nil {
+			return nil, fmt.Errorf("failed to read rate limit config: %w", err)
+		}
+	}
+	return ParseConfig(data)
+}
+
+// PlanQuota returns the quota of a plan, or the default quota for unknown
+// and empty plans.
+func (c *Config) PlanQuota(plan string) Quota {
+	if q, ok := c.Plans[plan]; ok {
+		return q
+	}
+	return c.Default
+}
+
+// RouteQuota returns the extra quota of a route pattern, if any.
+func (c *Config) RouteQuota(pattern string) (Quota, bool) {
+	q, ok := c.Routes[pattern]
+	return q, ok
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Decision is the outcome of counting one request against a quota.
+type Decision struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of further requests currently allowed.
+	Remaining int
+	// Reset is the time until the current window ends.
+	Reset time.Duration
+	// RetryAfter is how long a denied caller should wait.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests per key with the sliding window counter
+// algorithm: the count of the previous fixed window is weighted by how much
+// of it still overlaps the sliding window and added to the current count.
+type Limiter interface {
+	Allow(ctx context.Context, key string, q Quota) (Decision, error)
+}
+
+// decide applies the sliding window to the previous and current window
+// counts, elapsed being the time since the current window started. It
+// returns the decision and whether the request must be counted.
+func decide(prev, cur int64, q Quota, elapsed time.Duration) Decision {
+	left := q.Window - elapsed
+	weight := float64(left) / float64(q.Window)
+	estimated := float64(prev)*weight + float64(cur)
+	limit := float64(q.Limit)
+
+	if estimated+1 > limit {
+		retry := left
+		if cur+1 <= int64(q.Limit) && prev > 0 {
+			// Wait until enough of the previous window has slid out.
+			retry = left - time.Duration((limit-1-float64(cur))*float64(q.Window)/float64(prev))
+		}
+		return Decision{Limit: q.Limit, Reset: left, RetryAfter: max(retry, time.Millisecond)}
+	}
+
+	remaining := int(math.Floor(limit - (estimated + 1)))
+	return Decision{Allowed: true, Limit: q.Limit, Remaining: max(remaining, 0), Reset: left}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter is an in-process Limiter. It bounds memory by evicting the
+// least recently used keys once capacity is reached; an evicted caller
+// simply starts with a fresh window.
+type MemoryLimiter struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryEntry struct {
+	key   string
+	index int64 // fixed window the counts below belong to
+	prev  int64
+	cur   int64
+}
+
+// NewMemoryLimiter creates a MemoryLimiter tracking at most capacity keys.
+func NewMemoryLimiter(capacity int) *MemoryLimiter {
+	return &MemoryLimiter{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Allow implements Limiter.
+func (m *MemoryLimiter) Allow(_ context.Context, key string, q Quota) (Decision, error) {
+	now := time.Now()
+	index := now.UnixNano() / int64(q.Window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(q.Window))
+	key = key + "/" + q.Window.String()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var e *memoryEntry
+	if el, ok := m.entries[key]; ok {
+		m.lru.MoveToFront(el)
+		e = el.Value.(*memoryEntry)
+	} else {
+		if m.lru.Len() >= m.capacity {
+			oldest := m.lru.Back()
+			m.lru.Remove(oldest)
+			delete(m.entries, oldest.Value.(*memoryEntry).key)
+		}
+		e = &memoryEntry{key: key, index: index}
+		m.entries[key] = m.lru.PushFront(e)
+	}
+
+	switch {
+	case e.index == index-1:
+		e.prev, e.cur = e.cur, 0
+	case e.index < index-1:
+		e.prev, e.cur = 0, 0
+	}
+	e.index = index
+
+	d := decide(e.prev, e.cur, q, elapsed)
+	if d.Allowed {
+		e.cur++
+	}
+	return d, nil
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements decide() atomically in Redis. Time is taken
+// from the Redis server so that all gateway replicas agree on the window.
+// Both window keys share the hash tag of KEYS[1] and thus a cluster slot.
+//
+// KEYS[1] = key prefix, ARGV[1] = limit, ARGV[2] = window in ms.
+// Returns {allowed, remaining, reset_ms, retry_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local index = math.floor(now / window)
+local left = window - (now - index * window)
+local cur_key = KEYS[1] .. ':' .. index
+local prev = tonumber(redis.call('GET', KEYS[1] .. ':' .. (index - 1)) or '0')
+local cur = tonumber(redis.call('GET', cur_key) or '0')
+local estimated = prev * left / window + cur
+
+if estimated + 1 > limit then
+  local retry = left
+  if cur + 1 <= limit and prev > 0 then
+    retry = math.ceil(left - (limit - 1 - cur) * window / prev)
+  end
+  return {0, 0, left, math.max(retry, 1)}
+end
+
+redis.call('INCR', cur_key)
+redis.call('PEXPIRE', cur_key, window * 2)
+return {1, math.max(math.floor(limit - (estimated + 1)), 0), left, 0}
+`)
+
+// RedisLimiter is a Limiter shared by all gateway replicas.
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter creates a RedisLimiter.
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// Allow implements Limiter.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, q Quota) (Decision, error) {
+	redisKey := fmt.Sprintf("ratelimit:{%s}:%d", key, q.Window.Milliseconds())
+	res, err := slidingWindowScript.Run(ctx, l.client, []string{redisKey}, q.Limit, q.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(res) != 4 {
+		return Decision{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	return Decision{
+		Allowed:    res[0] == 1,
+		Limit:      q.Limit,
+		Remaining:  int(res[1]),
+		Reset:      time.Duration(res[2]) * time.Millisecond,
+		RetryAfter: time.Duration(res[3]) * time.Millisecond,
+	}, nil
+}
+
+const (
+	// fallbackLogInterval limits how often Redis failures are logged.
+	fallbackLogInterval = 30 * time.Second
+	// fallbackProbeInterval is how often a degraded limiter retries the
+	// primary, so that requests do not wait for a dead backend each time.
+	fallbackProbeInterval = time.Second
+)
+
+// FallbackLimiter uses primary and falls back to secondary while primary
+// fails. During a Redis outage each replica then enforces the quotas on its
+// own, which is more lenient but keeps the gateway available.
+type FallbackLimiter struct {
+	primary   Limiter
+	secondary Limiter
+	degraded  atomic.Bool
+	probeAt   atomic.Int64 // UnixNano before which the primary is skipped
+
+	mu         sync.Mutex
+	lastLog    time.Time
+	suppressed int
+}
+
+// NewFallbackLimiter creates a FallbackLimiter.
+func NewFallbackLimiter(primary, secondary Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, secondary: secondary}
+}
+
+// Allow implements Limiter.
+func (f *FallbackLimiter) Allow(ctx context.Context, key string, q Quota) (Decision, error) {
+	if f.degraded.Load() && time.Now().UnixNano() < f.probeAt.Load() {
+		return f.secondary.Allow(ctx, key, q)
+	}
+	d, err := f.primary.Allow(ctx, key, q)
+	if err == nil {
+		f.recovered(ctx)
+		return d, nil
+	}
+	f.failed(ctx, err)
+	return f.secondary.Allow(ctx, key, q)
+}
+
+func (f *FallbackLimiter) failed(ctx context.Context, err error) {
+	f.probeAt.Store(time.Now().Add(fallbackProbeInterval).UnixNano())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.degraded.Load() && time.Since(f.lastLog) < fallbackLogInterval {
+		f.suppressed++
+		return
+	}
+	slog.WarnContext(ctx, "Rate limiter backend failed, using in-memory fallback", "error", err, "suppressed", f.suppressed)
+	f.degraded.Store(true)
+	f.lastLog, f.suppressed = time.Now(), 0
+}
+
+func (f *FallbackLimiter) recovered(ctx context.Context) {
+	if f.degraded.CompareAndSwap(true, false) {
+		slog.InfoContext(ctx, "Rate limiter backend recovered")
+	}
+}
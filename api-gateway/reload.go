@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"securepay/api-gateway/config"
+	"securepay/api-gateway/logging"
+	"securepay/api-gateway/middleware"
+)
+
+// watchConfig reloads the configuration whenever a signal arrives on reload
+// and applies the settings that are safe to change at runtime. An invalid
+// configuration is logged and the current one is kept.
+func watchConfig(ctx context.Context, reload <-chan os.Signal, current *atomic.Pointer[config.Config], limiter *middleware.RateLimiter, logLevel *slog.LevelVar, redactor *logging.Redactor) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		}
+
+		next, err := config.Load()
+		if err != nil {
+			slog.Error("Failed to reload configuration, keeping the current one", "error", err)
+			continue
+		}
+		applied, ignored := current.Load().Reload(next)
+		if len(ignored) > 0 {
+			slog.Warn("Configuration changes require a restart and were not applied", "settings", ignored)
+		}
+
+		quotas := applied.RateLimit.Quotas
+		limiter.SetConfig(&quotas)
+		logLevel.Set(applied.LogLevel)
+		redactor.SetMode(applied.Logging.AccountIDs)
+		current.Store(applied)
+		slog.Info("Configuration reloaded", "log_level", applied.LogLevel, "log_account_ids", applied.Logging.AccountIDs)
+	}
+}
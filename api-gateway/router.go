@@ -1,116 +1,204 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
+	"securepay/api-gateway/apikey"
+	"securepay/api-gateway/config"
 	"securepay/api-gateway/endpoints"
 	"securepay/api-gateway/middleware"
-	accountv1 "securepay/proto/gen/go/account/v1"
-	paymentv1 "securepay/proto/gen/go/payment/v1"
+	"securepay/api-gateway/oauth"
+	"securepay/api-gateway/upstream"
+	"securepay/proto/gen/openapi"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// NewRouter sets up the routes and middleware for the API Gateway.
-// It accepts gRPC clients as dependencies.
-func NewRouter(paymentClient paymentv1.PaymentServiceClient, accountClient accountv1.AccountServiceClient) http.Handler {
-	mux := http.NewServeMux()
+// RouterOptions are the dependencies of NewRouter besides the gRPC clients.
+type RouterOptions struct {
+	// Validator verifies the JWTs of API requests.
+	Validator *middleware.JWTValidator
+	// Limiter rate limits API and OAuth requests.
+	Limiter *middleware.RateLimiter
+	// OAuth serves the OAuth 2.0 endpoints, which are not registered when nil.
+	OAuth *oauth.Server
+	// APIKeys serves API key management, which is not registered when nil.
+	APIKeys *apikey.Server
+	// APIKeyAuth authenticates X-API-Key requests; API keys are rejected
+	// when nil.
+	APIKeyAuth middleware.APIKeyAuthenticator
+	// Timeouts and BodyLimits return the current request timeout and body
+	// size limit of a route pattern.
+	Timeouts   func(pattern string) time.Duration
+	BodyLimits func(pattern string) int64
+	// API sets the deprecation headers of v1 routes.
+	API config.API
+	// Backends are reported by the readiness endpoint.
+	Backends []*upstream.Backend
+}
 
-	// Public Health Check
+// NewRouter sets up the routes and middleware for the API Gateway. Both API
+// versions are served by the handlers grpc-gateway generates from the protos
+// (see newGatewayMux), registered route by route so that every route keeps
+// its own middleware chain, scope, quota and timeout.
+func NewRouter(clients Clients, opts RouterOptions) (http.Handler, error) {
+	gateway, err := newGatewayMux(clients)
+	if err != nil {
+		return nil, err
+	}
+	openAPI, err := openAPIHandler(openapi.V1)
+	if err != nil {
+		return nil, err
+	}
+	openAPIV2, err := openAPIHandler(openapi.V2)
+	if err != nil {
+		return nil, err
+	}
+	paymentClient := authorizedPaymentClient{clients.Payment}
+
+	mux := http.NewServeMux()
+	timeout := middleware.TimeoutMiddleware(opts.Timeouts)
+	deprecation := middleware.DeprecationMiddleware(opts.API.V1Deprecated, opts.API.V1Sunset, endpoints.V1Successors)
+	body := middleware.BodyMiddleware(opts.BodyLimits, endpoints.RequestMediaTypes, endpoints.DefaultMediaTypes)
+	accessLog := middleware.LoggingMiddleware(opts.Limiter.ClientIP)
+	middlewareChain := newMiddlewareChain(opts.Validator, opts.APIKeyAuth, opts.Limiter, accessLog, deprecation, body, timeout)
+	oauthChain := newOAuthChain(opts.Limiter, accessLog, timeout)
+
+	// Public Health Check (the process is up)
 	mux.HandleFunc("GET "+endpoints.HealthCheckPath, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	// Public Metrics Endpoint
-	mux.Handle("GET "+endpoints.MetricsPath, promhttp.Handler())
+	// Public Readiness Check (every backend is reachable)
+	mux.HandleFunc("GET "+endpoints.ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		handleReady(w, r, opts.Backends)
+	})
+
+	// Public Metrics Endpoint. OpenMetrics is offered so that scrapers
+	// asking for it get the trace id exemplars of the HTTP metrics.
+	mux.Handle("GET "+endpoints.MetricsPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+
+	// Public OpenAPI descriptions of the REST API
+	mux.Handle("GET "+endpoints.OpenAPIPath, openAPI)
+	mux.Handle("GET "+endpoints.OpenAPIV2Path, openAPIV2)
+
+	// OAuth 2.0 Endpoints (client authentication instead of JWT)
+	if opts.OAuth != nil {
+		mux.Handle(endpoints.OAuthTokenPathPattern, oauthChain(http.HandlerFunc(opts.OAuth.HandleToken)))
+		mux.Handle(endpoints.OAuthRevokePathPattern, oauthChain(http.HandlerFunc(opts.OAuth.HandleRevoke)))
+		mux.Handle(endpoints.OAuthIntrospectPathPattern, oauthChain(http.HandlerFunc(opts.OAuth.HandleIntrospect)))
+	}
 
 	// Private API Endpoints (Middleware Applied)
 
 	// POST /api/v1/payments
-	mux.Handle(endpoints.InitiatePaymentPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handleInitiatePayment(w, r, paymentClient)
-	})))
+	mux.Handle(endpoints.InitiatePaymentPathPattern, middlewareChain(gateway))
 
 	// GET /api/v1/payments/{id}
-	mux.Handle(endpoints.GetPaymentPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle(endpoints.GetPaymentPathPattern, middlewareChain(gateway))
+
+	// GET /api/v1/payments/{id}/events
+	mux.Handle(endpoints.WatchPaymentPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
-		handleGetPayment(w, r, paymentClient, id)
+		handleWatchPayment(w, r, paymentClient, id)
 	})))
 
-	// GET /api/v1/accounts/{id}/balance
-	mux.Handle(endpoints.CheckBalancePathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id := r.PathValue("id")
-		handleCheckBalance(w, r, accountClient, id)
+	// POST /api/v1/payments/split
+	mux.Handle(endpoints.InitiateSplitPaymentPathPattern, middlewareChain(gateway))
+
+	// POST /api/v1/payment-batches (JSON or CSV)
+	mux.Handle(endpoints.InitiateBatchPaymentPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleInitiateBatchPayment(w, r, paymentClient)
 	})))
 
-	return mux
-}
+	// GET /api/v1/payment-batches/{id}
+	mux.Handle(endpoints.GetBatchPathPattern, middlewareChain(gateway))
 
-// middlewareChain applies metrics, rate limiting and JWT authentication middleware.
-func middlewareChain(next http.Handler) http.Handler {
-	// Order: Tracing (outer) -> Metrics -> RateLimit -> JWT (inner) -> Handler
-	return middleware.TracingMiddleware(middleware.MetricsMiddleware(middleware.RateLimitMiddleware(middleware.AuthMiddleware(next))))
-}
+	// POST /api/v1/webhooks
+	mux.Handle(endpoints.RegisterWebhookPathPattern, middlewareChain(withStatus(http.StatusCreated, gateway)))
 
-func handleInitiatePayment(w http.ResponseWriter, r *http.Request, client paymentv1.PaymentServiceClient) {
-	var req paymentv1.InitiatePaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+	// GET /api/v1/webhooks
+	mux.Handle(endpoints.ListWebhooksPathPattern, middlewareChain(gateway))
 
-	// Context with timeout for gRPC call
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	// DELETE /api/v1/webhooks/{id}
+	mux.Handle(endpoints.DeleteWebhookPathPattern, middlewareChain(withStatus(http.StatusNoContent, gateway)))
 
-	resp, err := client.InitiatePayment(ctx, &req)
-	if err != nil {
-		// In production, map gRPC codes to HTTP status codes
-		http.Error(w, fmt.Sprintf("Payment service error: %v", err), http.StatusInternalServerError)
-		return
-	}
+	// GET /api/v1/webhooks/{id}/deliveries
+	mux.Handle(endpoints.ListWebhookDeliveriesPathPattern, middlewareChain(gateway))
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
-}
+	// POST /api/v1/webhook-deliveries/{id}/redeliver
+	mux.Handle(endpoints.RedeliverWebhookPathPattern, middlewareChain(withStatus(http.StatusAccepted, gateway)))
 
-func handleGetPayment(w http.ResponseWriter, r *http.Request, client paymentv1.PaymentServiceClient, id string) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	// GET /api/v1/accounts/{id}/balance
+	mux.Handle(endpoints.CheckBalancePathPattern, middlewareChain(gateway))
 
-	req := &paymentv1.GetPaymentRequest{PaymentId: id}
-	resp, err := client.GetPayment(ctx, req)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Payment service error: %v", err), http.StatusInternalServerError)
-		return
-	}
+	// v2 API
+
+	// POST /api/v2/payments
+	mux.Handle(endpoints.CreatePaymentV2PathPattern, middlewareChain(withStatus(http.StatusCreated, gateway)))
+
+	// GET /api/v2/payments/{id}
+	mux.Handle(endpoints.GetPaymentV2PathPattern, middlewareChain(gateway))
+
+	// POST /api/v2/split-payments
+	mux.Handle(endpoints.CreateSplitPaymentV2PathPattern, middlewareChain(withStatus(http.StatusCreated, gateway)))
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	// GET /api/v2/accounts/{id}/payments
+	mux.Handle(endpoints.ListPaymentsV2PathPattern, middlewareChain(gateway))
+
+	// GET /api/v2/accounts/{id}/balance
+	mux.Handle(endpoints.GetBalanceV2PathPattern, middlewareChain(gateway))
+
+	// API key management (JWT only)
+	if apiKeys := opts.APIKeys; apiKeys != nil {
+		// POST /api/v1/api-keys
+		mux.Handle(endpoints.CreateAPIKeyPathPattern, middlewareChain(http.HandlerFunc(apiKeys.HandleCreate)))
+
+		// GET /api/v1/api-keys
+		mux.Handle(endpoints.ListAPIKeysPathPattern, middlewareChain(http.HandlerFunc(apiKeys.HandleList)))
+
+		// POST /api/v1/api-keys/{id}/rotate
+		mux.Handle(endpoints.RotateAPIKeyPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKeys.HandleRotate(w, r, r.PathValue("id"))
+		})))
+
+		// DELETE /api/v1/api-keys/{id}
+		mux.Handle(endpoints.RevokeAPIKeyPathPattern, middlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKeys.HandleRevoke(w, r, r.PathValue("id"))
+		})))
 	}
-}
 
-func handleCheckBalance(w http.ResponseWriter, r *http.Request, client accountv1.AccountServiceClient, id string) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	return mux, nil
+}
 
-	req := &accountv1.CheckBalanceRequest{AccountId: id}
-	resp, err := client.CheckBalance(ctx, req)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Account service error: %v", err), http.StatusInternalServerError)
-		return
+// newMiddlewareChain returns a function applying tracing, access logging, metrics, deprecation headers, JWT or API key authentication, rate limiting, scope, body and timeout middleware.
+func newMiddlewareChain(validator *middleware.JWTValidator, apiKeyAuth middleware.APIKeyAuthenticator, limiter *middleware.RateLimiter, accessLog, deprecation, body, timeout func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	var apiKeys *middleware.APIKeyConfig
+	if apiKeyAuth != nil {
+		apiKeys = &middleware.APIKeyConfig{Authenticator: apiKeyAuth, Routes: endpoints.APIKeyRoutes}
 	}
+	auth := middleware.AuthMiddleware(validator, apiKeys)
+	scopes := middleware.ScopeMiddleware(endpoints.RouteScopes)
+	return func(next http.Handler) http.Handler {
+		// Order: Tracing (outer) -> Logging -> Metrics -> Deprecation -> JWT/API key -> RateLimit -> Scope -> Body -> Timeout (inner) -> Handler
+		// Logging runs inside tracing so that its lines carry the trace id.
+		// Rate limiting runs after authentication so that quotas follow the caller, not its IP.
+		// Deprecation headers are set first so that rejected requests carry them too.
+		// Bodies are only read once the caller is known to be allowed to send them.
+		return middleware.TracingMiddleware(accessLog(middleware.MetricsMiddleware(deprecation(auth(limiter.Middleware(scopes(body(timeout(next)))))))))
+	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// newOAuthChain returns a function applying tracing, access logging,
+// metrics, rate limiting and timeouts to the OAuth endpoints. Callers are
+// anonymous there, so they are limited per client IP, which also throttles
+// client secret guessing.
+func newOAuthChain(limiter *middleware.RateLimiter, accessLog, timeout func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return middleware.TracingMiddleware(accessLog(middleware.MetricsMiddleware(limiter.Middleware(timeout(next)))))
 	}
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"securepay/api-gateway/middleware"
+	paymentv1 "securepay/proto/gen/go/payment/v1"
+)
+
+// sseHeartbeatInterval keeps idle SSE connections alive through proxies and
+// lets the gateway notice disconnected clients.
+const sseHeartbeatInterval = 15 * time.Second
+
+// handleWatchPayment bridges the WatchPayment gRPC stream to Server-Sent
+// Events. The gRPC stream is bound to the request context, so it is
+// cancelled as soon as the HTTP client disconnects. client must be an
+// authorizedPaymentClient.
+func handleWatchPayment(w http.ResponseWriter, r *http.Request, client paymentv1.PaymentServiceClient, id string) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stream, err := client.WatchPayment(ctx, &paymentv1.WatchPaymentRequest{PaymentId: id})
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	// Receive the initial status before committing to a 200 so that errors
+	// such as an unknown payment are still reported as a plain HTTP error.
+	first, err := stream.Recv()
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeSSEEvent(w, rc, "status", first); err != nil {
+		return
+	}
+
+	events := make(chan *paymentv1.PaymentStatusEvent)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			middleware.Logger(ctx).InfoContext(ctx, "SSE client disconnected", "payment_id", id)
+			return
+		case event := <-events:
+			if err := writeSSEEvent(w, rc, "status", event); err != nil {
+				return
+			}
+		case err := <-errc:
+			if errors.Is(err, io.EOF) {
+				// Terminal status reached; tell the browser not to reconnect.
+				writeSSEEvent(w, rc, "end", struct{}{})
+				return
+			}
+			if ctx.Err() == nil {
+				middleware.Logger(ctx).WarnContext(ctx, "Payment status stream failed", "error", err, "payment_id", id)
+				writeSSEEvent(w, rc, "error", map[string]string{"error": err.Error()})
+			}
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSEEvent writes one named event with a JSON data line and flushes it.
+// Messages are encoded like the REST responses.
+func writeSSEEvent(w io.Writer, rc *http.ResponseController, name string, v any) error {
+	data, err := gatewayMarshaler.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,188 @@
+// Package upstream manages the gateway's connections to backend gRPC
+// services. A Backend is created without a connection and dials on first
+// use, so the gateway starts and serves its other routes while a backend is
+// misconfigured or down.
+package upstream
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfter is the delay suggested to HTTP clients when a backend is
+// unavailable.
+const RetryAfter = 5 * time.Second
+
+const (
+	minDialBackoff = time.Second
+	maxDialBackoff = 30 * time.Second
+)
+
+// DialFunc creates the client connection of a backend.
+type DialFunc func(ctx context.Context) (*grpc.ClientConn, error)
+
+// Backend is a lazily dialled backend service. It implements
+// grpc.ClientConnInterface, so generated clients can be created from it
+// before the connection exists; calls fail with codes.Unavailable until it
+// does.
+type Backend struct {
+	name    string
+	dial    DialFunc
+	breaker *Breaker
+
+	mu          sync.Mutex
+	conn        *grpc.ClientConn
+	lastErr     error
+	backoff     time.Duration
+	nextAttempt time.Time
+}
+
+// NewBackend creates a Backend. No connection is made until the first call
+// or until Run is started. breaker, if non-nil, is the circuit breaker
+// installed by dial and is reported in Status.
+func NewBackend(name string, dial DialFunc, breaker *Breaker) *Backend {
+	return &Backend{name: name, dial: dial, breaker: breaker, backoff: minDialBackoff}
+}
+
+// Name returns the backend name.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Invoke implements grpc.ClientConnInterface.
+func (b *Backend) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface.
+func (b *Backend) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
+
+// connect returns the connection, dialling it if needed. Failed attempts
+// are retried with exponential backoff; until the next attempt is due,
+// callers fail fast instead of dialling again.
+func (b *Backend) connect(ctx context.Context) (*grpc.ClientConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn != nil {
+		return b.conn, nil
+	}
+	if time.Now().Before(b.nextAttempt) {
+		return nil, status.Errorf(codes.Unavailable, "%s unavailable: %v", b.name, b.lastErr)
+	}
+
+	conn, err := b.dial(ctx)
+	if err != nil {
+		b.lastErr = err
+		b.nextAttempt = time.Now().Add(b.backoff)
+		b.backoff = min(b.backoff*2, maxDialBackoff)
+		slog.WarnContext(ctx, "Failed to connect to backend", "backend", b.name, "error", err, "retry_in", time.Until(b.nextAttempt).Round(time.Second))
+		return nil, status.Errorf(codes.Unavailable, "%s unavailable: %v", b.name, err)
+	}
+	b.conn, b.lastErr = conn, nil
+	slog.InfoContext(ctx, "Connected to backend", "backend", b.name, "target", conn.Target())
+	return conn, nil
+}
+
+// Run dials the backend in the background until it succeeds, then keeps
+// the connection warm and logs connectivity changes until ctx is done.
+func (b *Backend) Run(ctx context.Context) {
+	var conn *grpc.ClientConn
+	for conn == nil {
+		var err error
+		if conn, err = b.connect(ctx); err == nil {
+			break
+		}
+		b.mu.Lock()
+		wait := time.Until(b.nextAttempt)
+		b.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(max(wait, 0)):
+		}
+	}
+
+	conn.Connect()
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		next := conn.GetState()
+		if next == connectivity.TransientFailure {
+			slog.WarnContext(ctx, "Backend connection failed", "backend", b.name, "from", state.String())
+		} else {
+			slog.InfoContext(ctx, "Backend connectivity changed", "backend", b.name, "from", state.String(), "to", next.String())
+		}
+		state = next
+	}
+}
+
+// Status is the health of a backend as reported by the readiness endpoint.
+type Status struct {
+	Name string `json:"name"`
+	// State is the gRPC connectivity state, or "UNAVAILABLE" when the
+	// connection could not be created.
+	State string `json:"state"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+	// Breaker is the circuit breaker state; an open breaker is not ready.
+	Breaker string `json:"circuit_breaker,omitempty"`
+	// RetryIn is the number of seconds until the next dial attempt.
+	RetryIn int `json:"retry_in,omitempty"`
+}
+
+// Status returns the current health of the backend. An idle connection
+// counts as ready and is asked to reconnect.
+func (b *Backend) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{Name: b.name}
+	breakerOpen := false
+	if b.breaker != nil {
+		state := b.breaker.State()
+		s.Breaker, breakerOpen = state.String(), state == BreakerOpen
+	}
+	if b.conn == nil {
+		s.State = "UNAVAILABLE"
+		if b.lastErr != nil {
+			s.Error = b.lastErr.Error()
+			s.RetryIn = int(math.Ceil(max(time.Until(b.nextAttempt), 0).Seconds()))
+		}
+		return s
+	}
+
+	state := b.conn.GetState()
+	if state == connectivity.Idle {
+		b.conn.Connect()
+	}
+	s.State = state.String()
+	s.Ready = (state == connectivity.Ready || state == connectivity.Idle) && !breakerOpen
+	return s
+}
+
+// Close closes the connection, if any.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	return b.conn.Close()
+}
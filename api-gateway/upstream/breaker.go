@@ -0,0 +1,183 @@
+package upstream
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all calls through.
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe call through.
+	BreakerHalfOpen
+	// BreakerOpen rejects calls until the open timeout has passed.
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig configures a circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker; 0 disables the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before a probe call is
+	// let through.
+	OpenTimeout time.Duration
+}
+
+// Breaker is a consecutive-failure circuit breaker. While it is open, calls
+// fail immediately with codes.Unavailable instead of piling up on a backend
+// that is down or overloaded.
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a closed Breaker for the named backend.
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	b := &Breaker{name: name, cfg: cfg}
+	breakerState.WithLabelValues(name).Set(float64(BreakerClosed))
+	return b
+}
+
+// State returns the current state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow reports whether a call may proceed. A call that is allowed must be
+// followed by done.
+func (b *Breaker) allow() bool {
+	if b.cfg.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// done records the outcome of an allowed call.
+func (b *Breaker) done(err error) {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failed := isBackendFailure(err)
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= b.cfg.FailureThreshold {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = time.Now()
+	b.transition(BreakerOpen)
+}
+
+func (b *Breaker) transition(to BreakerState) {
+	if b.state == to {
+		return
+	}
+	slog.Warn("Circuit breaker state changed", "backend", b.name, "from", b.state.String(), "to", to.String(), "failures", b.failures)
+	b.state = to
+	breakerState.WithLabelValues(b.name).Set(float64(to))
+	breakerTransitions.WithLabelValues(b.name, to.String()).Inc()
+}
+
+func (b *Breaker) rejected() error {
+	breakerRejections.WithLabelValues(b.name).Inc()
+	return status.Errorf(codes.Unavailable, "%s circuit breaker is open", b.name)
+}
+
+// isBackendFailure reports whether err indicates an unhealthy backend, as
+// opposed to an application error such as NotFound or InvalidArgument.
+// Cancellation by the caller is not the backend's fault.
+func isBackendFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// UnaryClientInterceptor guards unary calls with the breaker.
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return b.rejected()
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.done(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor guards the creation of streams with the breaker.
+// Errors later in the stream are not recorded; long-lived streams end with
+// cancellation far more often than with a backend failure.
+func (b *Breaker) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !b.allow() {
+			return nil, b.rejected()
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		b.done(err)
+		return stream, err
+	}
+}
@@ -0,0 +1,207 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	_ "google.golang.org/grpc/balancer/leastrequest" // registers least_request_experimental
+	"google.golang.org/grpc/balancer/roundrobin"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	"google.golang.org/grpc/resolver"
+)
+
+// resolverScheme is the scheme of the targets returned by Target.
+const resolverScheme = "upstream"
+
+const (
+	// minResolveInterval rate limits re-resolution requested by the
+	// channel, which happens whenever a subchannel fails.
+	minResolveInterval = time.Second
+	lookupTimeout      = 5 * time.Second
+)
+
+// Load balancing policies understood by ServiceConfig.
+const (
+	RoundRobin   = "round_robin"
+	LeastRequest = "least_request"
+)
+
+// Target returns the dial target of a backend at addr. It must be dialled
+// with grpc.WithResolvers(NewResolver(...)).
+func Target(addr string) string {
+	return resolverScheme + ":///" + addr
+}
+
+// ServiceConfig returns the default gRPC service config of a backend: the
+// load balancing policy and, when healthService is set, client-side health
+// checking through grpc.health.v1, so that replicas reporting NOT_SERVING
+// are not picked.
+func ServiceConfig(policy, healthService string) (string, error) {
+	var lb map[string]any
+	switch policy {
+	case RoundRobin, "":
+		lb = map[string]any{roundrobin.Name: struct{}{}}
+	case LeastRequest:
+		lb = map[string]any{"least_request_experimental": map[string]any{"choiceCount": 2}}
+	default:
+		return "", fmt.Errorf("unknown load balancing policy %q", policy)
+	}
+
+	sc := map[string]any{"loadBalancingConfig": []any{lb}}
+	if healthService != "" {
+		sc["healthCheckConfig"] = map[string]any{"serviceName": healthService}
+	}
+	b, err := json.Marshal(sc)
+	return string(b), err
+}
+
+// NewResolver returns a resolver for the targets created by Target. With a
+// non-empty endpoints list the backend resolves to exactly those addresses.
+// Otherwise the host of the target is looked up in DNS every interval, and
+// whenever a connection fails, so that replicas added or removed by the
+// autoscaler are picked up; point it at a headless service to get one
+// address per pod.
+func NewResolver(endpoints []string, interval time.Duration) resolver.Builder {
+	return &resolverBuilder{endpoints: endpoints, interval: interval, lookup: net.DefaultResolver.LookupHost}
+}
+
+type resolverBuilder struct {
+	endpoints []string
+	interval  time.Duration
+	lookup    func(ctx context.Context, host string) ([]string, error)
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return resolverScheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	if len(b.endpoints) > 0 {
+		if err := cc.UpdateState(resolver.State{Addresses: addresses(b.endpoints)}); err != nil {
+			return nil, err
+		}
+		return staticResolver{}, nil
+	}
+
+	host, port, err := net.SplitHostPort(target.Endpoint())
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream address %q: %w", target.Endpoint(), err)
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		if err := cc.UpdateState(resolver.State{Addresses: addresses([]string{target.Endpoint()})}); err != nil {
+			return nil, err
+		}
+		return staticResolver{}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &dnsResolver{
+		host:     host,
+		port:     port,
+		interval: b.interval,
+		lookup:   b.lookup,
+		cc:       cc,
+		cancel:   cancel,
+		resolve:  make(chan struct{}, 1),
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+func addresses(endpoints []string) []resolver.Address {
+	addrs := make([]resolver.Address, len(endpoints))
+	for i, e := range endpoints {
+		addrs[i] = resolver.Address{Addr: e}
+	}
+	return addrs
+}
+
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (staticResolver) Close()                                {}
+
+// dnsResolver resolves a host name to all of its addresses.
+type dnsResolver struct {
+	host, port string
+	interval   time.Duration
+	lookup     func(ctx context.Context, host string) ([]string, error)
+	cc         resolver.ClientConn
+
+	cancel  context.CancelFunc
+	resolve chan struct{}
+	wg      sync.WaitGroup
+}
+
+// ResolveNow asks for a lookup ahead of the next interval.
+func (r *dnsResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolve <- struct{}{}:
+	default:
+	}
+}
+
+func (r *dnsResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *dnsResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var current []string
+	for {
+		ips, err := r.lookupHost(ctx)
+		switch {
+		case err != nil:
+			slog.WarnContext(ctx, "Failed to resolve backend", "host", r.host, "error", err)
+			r.cc.ReportError(err)
+		case !slices.Equal(ips, current):
+			slog.InfoContext(ctx, "Backend addresses changed", "host", r.host, "addresses", ips)
+			endpoints := make([]string, len(ips))
+			for i, ip := range ips {
+				endpoints[i] = net.JoinHostPort(ip, r.port)
+			}
+			// An error means the balancer rejected the update; it asks for
+			// re-resolution itself.
+			_ = r.cc.UpdateState(resolver.State{Addresses: addresses(endpoints)})
+			current = ips
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(minResolveInterval):
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.resolve:
+		}
+	}
+}
+
+func (r *dnsResolver) lookupHost(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	ips, err := r.lookup(ctx, r.host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", r.host)
+	}
+	slices.Sort(ips)
+	return ips, nil
+}
@@ -0,0 +1,198 @@
+package upstream
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	testHealthService = "test.Backend"
+	testMethod        = "/test.Backend/Ping"
+)
+
+// replica is a gRPC server answering testMethod and counting the calls.
+type replica struct {
+	addr   string
+	health *health.Server
+	calls  atomic.Int64
+}
+
+// startReplica serves on lis until the test ends. It reports SERVING for
+// testHealthService.
+func startReplica(t *testing.T, lis net.Listener) *replica {
+	t.Helper()
+	r := &replica{addr: lis.Addr().String(), health: health.NewServer()}
+	r.health.SetServingStatus(testHealthService, healthpb.HealthCheckResponse_SERVING)
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		r.calls.Add(1)
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	healthpb.RegisterHealthServer(srv, r.health)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return r
+}
+
+func listen(t *testing.T, addr string) net.Listener {
+	t.Helper()
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen %s: %v", addr, err)
+	}
+	return lis
+}
+
+// dial connects to target the way the gateway does, with round_robin and
+// client-side health checking of testHealthService.
+func dial(t *testing.T, target string, builder *resolverBuilder) *grpc.ClientConn {
+	t.Helper()
+	sc, err := ServiceConfig(RoundRobin, testHealthService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(sc),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// callAll makes n calls and returns how many each replica received.
+func callAll(t *testing.T, conn *grpc.ClientConn, replicas []*replica, n int) []int64 {
+	t.Helper()
+	before := make([]int64, len(replicas))
+	for i, r := range replicas {
+		before[i] = r.calls.Load()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for range n {
+		if err := conn.Invoke(ctx, testMethod, &emptypb.Empty{}, &emptypb.Empty{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+
+	got := make([]int64, len(replicas))
+	for i, r := range replicas {
+		got[i] = r.calls.Load() - before[i]
+	}
+	return got
+}
+
+// waitFor calls until every replica has received a call when want is
+// true, or until the replicas with want false receive none.
+func waitFor(t *testing.T, conn *grpc.ClientConn, replicas []*replica, want []bool) []int64 {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got := callAll(t, conn, replicas, 30)
+		ok := true
+		for i := range replicas {
+			if (got[i] > 0) != want[i] {
+				ok = false
+			}
+		}
+		if ok {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("calls per replica = %v, want traffic %v", got, want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestResolverStaticEndpointsRoundRobin(t *testing.T) {
+	var replicas []*replica
+	var endpoints []string
+	for range 3 {
+		r := startReplica(t, listen(t, "127.0.0.1:0"))
+		replicas = append(replicas, r)
+		endpoints = append(endpoints, r.addr)
+	}
+
+	conn := dial(t, Target("payment-service:8081"), &resolverBuilder{endpoints: endpoints, interval: time.Minute})
+
+	// Wait until every subchannel is ready, then expect an even spread.
+	waitFor(t, conn, replicas, []bool{true, true, true})
+	got := callAll(t, conn, replicas, 90)
+	for i, n := range got {
+		if n != 30 {
+			t.Errorf("replica %d received %d of 90 calls, want 30 (spread %v)", i, n, got)
+		}
+	}
+
+	// A replica reporting NOT_SERVING is taken out of the rotation...
+	replicas[1].health.SetServingStatus(testHealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	waitFor(t, conn, replicas, []bool{true, false, true})
+	got = callAll(t, conn, replicas, 60)
+	if got[1] != 0 {
+		t.Errorf("NOT_SERVING replica received %d calls", got[1])
+	}
+	if got[0]+got[2] != 60 {
+		t.Errorf("serving replicas received %v, want all 60 calls", got)
+	}
+
+	// ...and put back once it is SERVING again.
+	replicas[1].health.SetServingStatus(testHealthService, healthpb.HealthCheckResponse_SERVING)
+	waitFor(t, conn, replicas, []bool{true, true, true})
+}
+
+func TestResolverDNSPicksUpReplicas(t *testing.T) {
+	// One port on several loopback addresses, as DNS returns addresses for
+	// a single service port.
+	first := listen(t, "127.0.0.1:0")
+	_, port, _ := net.SplitHostPort(first.Addr().String())
+	listeners := []net.Listener{first}
+	for _, ip := range []string{"127.0.0.2", "127.0.0.3"} {
+		lis, err := net.Listen("tcp", net.JoinHostPort(ip, port))
+		if err != nil {
+			t.Skipf("cannot listen on %s: %v", ip, err)
+		}
+		listeners = append(listeners, lis)
+	}
+	var replicas []*replica
+	for _, lis := range listeners {
+		replicas = append(replicas, startReplica(t, lis))
+	}
+
+	// The lookup first returns two replicas, then a scale-out adds a third.
+	var scaled atomic.Bool
+	lookup := func(_ context.Context, host string) ([]string, error) {
+		if host != "payment-service" {
+			t.Errorf("lookup of %q", host)
+		}
+		if scaled.Load() {
+			return []string{"127.0.0.3", "127.0.0.1", "127.0.0.2"}, nil
+		}
+		return []string{"127.0.0.2", "127.0.0.1"}, nil
+	}
+
+	conn := dial(t, Target(net.JoinHostPort("payment-service", port)), &resolverBuilder{interval: 100 * time.Millisecond, lookup: lookup})
+
+	waitFor(t, conn, replicas, []bool{true, true, false})
+	scaled.Store(true)
+	waitFor(t, conn, replicas, []bool{true, true, true})
+
+	replicas[0].health.SetServingStatus(testHealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	waitFor(t, conn, replicas, []bool{false, true, true})
+}
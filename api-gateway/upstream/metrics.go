@@ -0,0 +1,33 @@
+package upstream
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upstream_circuit_breaker_state",
+		Help: "Circuit breaker state per backend (0 closed, 1 half-open, 2 open).",
+	}, []string{"backend"})
+
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_circuit_breaker_transitions_total",
+		Help: "Total number of circuit breaker state changes per backend and target state.",
+	}, []string{"backend", "state"})
+
+	breakerRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_circuit_breaker_rejections_total",
+		Help: "Total number of calls rejected by an open circuit breaker.",
+	}, []string{"backend"})
+
+	retriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_retries_total",
+		Help: "Total number of retried backend calls by method and the code that caused the retry.",
+	}, []string{"backend", "method", "code"})
+
+	hedgedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_hedged_requests_total",
+		Help: "Total number of hedged backend calls by method.",
+	}, []string{"backend", "method"})
+)
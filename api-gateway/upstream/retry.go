@@ -0,0 +1,154 @@
+package upstream
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RetryPolicy configures retries of unary calls. Only calls that are safe
+// to repeat are retried: methods listed in Idempotent, and methods listed
+// in Keyed when the request carries an idempotency key.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; values below 2 disable retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// HedgeDelay, when positive, sends a second copy of an idempotent call
+	// if the first has not completed after this delay and uses whichever
+	// answers first.
+	HedgeDelay time.Duration
+	// Idempotent are full method names that are always safe to repeat.
+	Idempotent map[string]bool
+	// Keyed are full method names that are safe to repeat when the request
+	// has a non-empty idempotency key.
+	Keyed map[string]bool
+}
+
+type idempotencyKeyer interface {
+	GetIdempotencyKey() string
+}
+
+// retryable reports whether the call may be repeated.
+func (p RetryPolicy) retryable(method string, req any) bool {
+	if p.Idempotent[method] {
+		return true
+	}
+	if p.Keyed[method] {
+		k, ok := req.(idempotencyKeyer)
+		return ok && k.GetIdempotencyKey() != ""
+	}
+	return false
+}
+
+// retryableCode reports whether a failed attempt is worth repeating. Only
+// errors where the backend is unreachable or shedding load qualify.
+func retryableCode(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry n (1-based) with full jitter.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.InitialBackoff << (n - 1)
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return rand.N(d) + 1
+}
+
+// UnaryClientInterceptor retries and hedges calls to the named backend
+// according to the policy. Retries stop early when the next attempt could
+// not complete before the request deadline. It must run outside the
+// breaker so that every attempt is counted and rejected while it is open.
+func (p RetryPolicy) UnaryClientInterceptor(backend string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !p.retryable(method, req) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		attempt := func() error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if p.HedgeDelay > 0 && p.Idempotent[method] {
+			attempt = func() error {
+				return p.hedge(ctx, backend, method, req, reply, cc, invoker, opts...)
+			}
+		}
+
+		err := attempt()
+		for n := 1; n < p.MaxAttempts && retryableCode(err); n++ {
+			wait := p.backoff(n)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+			retriesTotal.WithLabelValues(backend, method, status.Code(err).String()).Inc()
+			err = attempt()
+		}
+		return err
+	}
+}
+
+// hedge runs the call and, if it is still pending after HedgeDelay, a
+// second copy into a separate reply. The first success wins; the loser is
+// cancelled. If both fail, the last error is returned.
+func (p RetryPolicy) hedge(ctx context.Context, backend, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	msg, ok := reply.(proto.Message)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	results := make(chan result, 2)
+	call := func() {
+		out := msg.ProtoReflect().New().Interface()
+		err := invoker(ctx, method, req, out, cc, opts...)
+		results <- result{out, err}
+	}
+
+	go call()
+	pending := 1
+	timer := time.NewTimer(p.HedgeDelay)
+	defer timer.Stop()
+
+	var err error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hedgedTotal.WithLabelValues(backend, method).Inc()
+			go call()
+			pending++
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				proto.Reset(msg)
+				proto.Merge(msg, r.reply)
+				return nil
+			}
+			err = r.err
+			if pending == 0 {
+				return err
+			}
+		}
+	}
+	return err
+}
@@ -2,7 +2,7 @@
 
 # Ensure bash runs this
 GATEWAY_HOST="localhost:8087"
-TOKEN="eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.dtmo7EtANIsh4V84XavB30DR4KCk2rINmRdyNZoLcG8"
+TOKEN="${TOKEN:-$(cd "$(dirname "$0")/../api-gateway" && go run ./cmd/token sign -key ../.secrets/jwt-signing.pem)}"
 
 curl -v -X POST http://$GATEWAY_HOST/api/v1/payments \
   -H "Authorization: Bearer $TOKEN" \
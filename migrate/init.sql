@@ -1,19 +1,102 @@
 -- Create schemas
 CREATE SCHEMA IF NOT EXISTS payments;
 CREATE SCHEMA IF NOT EXISTS accounts;
+CREATE SCHEMA IF NOT EXISTS gateway;
 
 -- Create payments.transactions table
 CREATE TABLE IF NOT EXISTS payments.transactions (
     id              UUID PRIMARY KEY,
     from_account    UUID NOT NULL,
-    to_account      UUID NOT NULL,
+    to_account      UUID, -- NULL for split payments, see payments.split_legs
     amount          NUMERIC(18,2) NOT NULL,
+    fee             NUMERIC(18,2) NOT NULL DEFAULT 0,
     currency        VARCHAR(3) NOT NULL,
     status          VARCHAR(20) NOT NULL DEFAULT 'PENDING',
-    idempotency_key VARCHAR(255) UNIQUE NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    initiated_by    VARCHAR(255) NOT NULL DEFAULT '', -- Subject of the end user (JWT sub)
     created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    version         INT NOT NULL DEFAULT 1
+    version         INT NOT NULL DEFAULT 1,
+    -- Idempotency keys are chosen by clients: unique per initiator and payer
+    CONSTRAINT transactions_idempotency_scope_key UNIQUE (initiated_by, from_account, idempotency_key)
+);
+
+-- Payments of an account, newest first (ListPayments)
+CREATE INDEX IF NOT EXISTS idx_transactions_from_account ON payments.transactions (from_account, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_to_account ON payments.transactions (to_account, created_at DESC);
+
+-- Create payments.split_legs table (recipients of a split payment)
+CREATE TABLE IF NOT EXISTS payments.split_legs (
+    payment_id  UUID NOT NULL REFERENCES payments.transactions(id),
+    leg_no      INT NOT NULL,
+    to_account  UUID NOT NULL,
+    amount      NUMERIC(18,2) NOT NULL,
+    PRIMARY KEY (payment_id, leg_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_legs_to_account ON payments.split_legs (to_account);
+
+-- Create payments.batches table (bulk submissions)
+CREATE TABLE IF NOT EXISTS payments.batches (
+    id              UUID PRIMARY KEY,
+    idempotency_key VARCHAR(255) NOT NULL,
+    total_items     INT NOT NULL,
+    initiated_by    VARCHAR(255) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT batches_idempotency_scope_key UNIQUE (initiated_by, idempotency_key)
+);
+
+-- Create payments.batch_items table (per-line outcome of a batch)
+CREATE TABLE IF NOT EXISTS payments.batch_items (
+    batch_id    UUID NOT NULL REFERENCES payments.batches(id),
+    line_no     INT NOT NULL,
+    payment_id  VARCHAR(255) NOT NULL DEFAULT '',
+    status      VARCHAR(20) NOT NULL,
+    error       TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (batch_id, line_no)
+);
+
+-- Create payments.webhook_endpoints table (merchant-registered receivers)
+CREATE TABLE IF NOT EXISTS payments.webhook_endpoints (
+    id          UUID PRIMARY KEY,
+    client_id   VARCHAR(255) NOT NULL,
+    url         TEXT NOT NULL,
+    secret      VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    account_ids TEXT[] NOT NULL,
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_client ON payments.webhook_endpoints (client_id);
+
+-- Create payments.webhook_deliveries table (delivery queue and log)
+CREATE TABLE IF NOT EXISTS payments.webhook_deliveries (
+    id               UUID PRIMARY KEY,
+    endpoint_id      UUID NOT NULL REFERENCES payments.webhook_endpoints(id),
+    event_id         UUID NOT NULL,
+    event_type       VARCHAR(50) NOT NULL,
+    payment_id       UUID NOT NULL,
+    payload          TEXT NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts         INT NOT NULL DEFAULT 0,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error       TEXT NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON payments.webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON payments.webhook_deliveries (endpoint_id, created_at DESC);
+
+-- Create payments.outbox table (events committed with their payments, awaiting Kafka)
+CREATE TABLE IF NOT EXISTS payments.outbox (
+    id          BIGSERIAL PRIMARY KEY,
+    message_key VARCHAR(255) NOT NULL, -- Kafka message key (payment id)
+    payload     TEXT NOT NULL,
+    headers     JSONB NOT NULL DEFAULT '{}', -- Trace context of the originating request
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 -- Create accounts.balances table
@@ -26,8 +109,72 @@ CREATE TABLE IF NOT EXISTS accounts.balances (
     version     INT NOT NULL DEFAULT 1
 );
 
+-- Create accounts.processed_payments table (outcome of every applied payment
+-- event, so redelivered events are not applied twice)
+CREATE TABLE IF NOT EXISTS accounts.processed_payments (
+    payment_id   UUID PRIMARY KEY,
+    status       VARCHAR(20) NOT NULL,
+    reason       TEXT NOT NULL DEFAULT '',
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create gateway.oauth_clients table (client credentials grant)
+CREATE TABLE IF NOT EXISTS gateway.oauth_clients (
+    client_id   VARCHAR(255) PRIMARY KEY,
+    secret_hash TEXT NOT NULL, -- pbkdf2-sha256$<iterations>$<salt>$<hash>, see `token hash-secret`
+    tenant      VARCHAR(255) NOT NULL DEFAULT '',
+    scopes      TEXT[] NOT NULL, -- Scopes the client may request
+    roles       TEXT[] NOT NULL DEFAULT '{}',
+    account_ids TEXT[] NOT NULL DEFAULT '{}',
+    plan        VARCHAR(50) NOT NULL DEFAULT '', -- Rate limit plan, '' for the default quota
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create gateway.revoked_tokens table (kept until the token expires)
+CREATE TABLE IF NOT EXISTS gateway.revoked_tokens (
+    jti         VARCHAR(255) PRIMARY KEY,
+    client_id   VARCHAR(255) NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON gateway.revoked_tokens (expires_at);
+
+-- Create gateway.api_keys table (partner API keys, X-API-Key header)
+CREATE TABLE IF NOT EXISTS gateway.api_keys (
+    id           UUID PRIMARY KEY,
+    prefix       VARCHAR(32) UNIQUE NOT NULL, -- Public part of spk_<prefix>_<secret>
+    secret_hash  VARCHAR(64) NOT NULL, -- Hex SHA-256 of the secret
+    name         VARCHAR(100) NOT NULL,
+    owner        VARCHAR(255) NOT NULL, -- Subject that created the key
+    tenant       VARCHAR(255) NOT NULL DEFAULT '',
+    scopes       TEXT[] NOT NULL,
+    account_ids  TEXT[] NOT NULL DEFAULT '{}',
+    plan         VARCHAR(50) NOT NULL DEFAULT '', -- Owner's rate limit plan at creation
+    rotated_from UUID REFERENCES gateway.api_keys(id),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON gateway.api_keys (owner, created_at DESC);
+
 -- Seed data for accounts
 INSERT INTO accounts.balances (account_id, balance, currency) VALUES
 ('11111111-1111-1111-1111-111111111111', 1000.00, 'TRY'),
-('22222222-2222-2222-2222-222222222222', 500.00, 'TRY')
+('22222222-2222-2222-2222-222222222222', 500.00, 'TRY'),
+('00000000-0000-0000-0000-000000000001', 0.00, 'TRY'), -- House accounts (fees), one per currency
+('00000000-0000-0000-0000-000000000002', 0.00, 'USD'),
+('00000000-0000-0000-0000-000000000003', 0.00, 'EUR')
 ON CONFLICT (account_id) DO NOTHING;
+
+-- Seed OAuth client for local development (secret: dev-client-secret)
+INSERT INTO gateway.oauth_clients (client_id, secret_hash, tenant, scopes, account_ids) VALUES
+('securepay-dev-client',
+ 'pbkdf2-sha256$600000$ee2f_mkoeRQ0fL3Z3qq0Ww$0PYS8ZDVxHYxLcYObFUM93IULAZijm4dqHpYTyRWpG0',
+ 'securepay-dev',
+ ARRAY['payments:read', 'payments:write', 'accounts:read', 'webhooks:manage', 'apikeys:manage'],
+ ARRAY['11111111-1111-1111-1111-111111111111', '22222222-2222-2222-2222-222222222222'])
+ON CONFLICT (client_id) DO NOTHING;
@@ -2,51 +2,86 @@ package config
 
 import (
 	"log/slog"
-	"os"
-	"strings"
 
-	"github.com/joho/godotenv"
+	"securepay/pkg/envconfig"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Port         string
-	DatabaseURL  string
-	KafkaBrokers []string
-	KafkaTopic   string
+	Port          string
+	DatabaseURL   string
+	KafkaBrokers  []string
+	KafkaTopic    string
+	ResultsTopic  string
 	SpiffeSocket  string
 	RedisAddr     string
 	RedisPassword string
+	// FeeSchedule is the JSON fee schedule (see internal/fee). FeeScheduleFile,
+	// when set, takes precedence and points to a file with the same content.
+	FeeSchedule     string
+	FeeScheduleFile string
+	// HouseAccounts are the CURRENCY=ACCOUNT_ID house accounts configured
+	// for account-service. Startup fails when the fee schedule charges a
+	// fee in a currency without one.
+	HouseAccounts map[string]string
+	// WebhookAllowHTTP permits plain http:// webhook URLs (local development).
+	WebhookAllowHTTP bool
+	// WebhookAllowPrivate permits webhook deliveries to loopback and private
+	// network addresses (local development).
+	WebhookAllowPrivate bool
+	// PrincipalIssuers are the SPIFFE IDs allowed to sign caller principals.
+	PrincipalIssuers []string
+	// HealthPort serves /livez and /readyz over plain HTTP for probes.
+	HealthPort string
+	// ClientSpiffeIDs are the SPIFFE IDs allowed to connect over mTLS; empty
+	// accepts any workload of a trusted domain.
+	ClientSpiffeIDs []string
+	LogLevel        slog.Level
+	// TraceSampleRatio is the fraction of root spans sampled, from 0 to 1,
+	// unless OTEL_TRACES_SAMPLER is set.
+	TraceSampleRatio float64
 }
 
 // Load loads the configuration from environment variables
-func Load() *Config {
+func Load() (*Config, error) {
 	// Load .env file if exists (optional)
-	if err := godotenv.Load(); err != nil {
-		slog.Warn("No .env file found, using system environment variables")
-	}
+	envconfig.LoadDotEnv()
 
-	return &Config{
-		Port:         getEnv("PORT", ":8081"),
-		DatabaseURL:  getEnv("DATABASE_URL", ""),
-		KafkaBrokers: getEnvList("KAFKA_BROKERS", []string{"localhost:9092"}),
-		KafkaTopic:   getEnv("KAFKA_TOPIC", "payment.initiated"),
-		SpiffeSocket: getEnv("SPIFFE_ENDPOINT_SOCKET", "unix:///tmp/spire-agent/public/api.sock"),
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+	cfg := &Config{
+		Port:             ":8081",
+		KafkaBrokers:     []string{"localhost:9092"},
+		KafkaTopic:       "payment.initiated",
+		ResultsTopic:     "payment.results",
+		SpiffeSocket:     "unix:///tmp/spire-agent/public/api.sock",
+		RedisAddr:        "localhost:6379",
+		PrincipalIssuers: []string{"spiffe://securepay.dev/api-gateway"},
+		HealthPort:       ":9081",
+		ClientSpiffeIDs:  []string{"spiffe://securepay.dev/api-gateway"},
+		LogLevel:         slog.LevelInfo,
+		TraceSampleRatio: 1,
 	}
-}
-
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return fallback
-}
 
-func getEnvList(key string, fallback []string) []string {
-	if value, exists := os.LookupEnv(key); exists {
-		return strings.Split(value, ",")
+	var env envconfig.Loader
+	env.String("PORT", &cfg.Port)
+	env.String("DATABASE_URL", &cfg.DatabaseURL)
+	env.List("KAFKA_BROKERS", &cfg.KafkaBrokers)
+	env.String("KAFKA_TOPIC", &cfg.KafkaTopic)
+	env.String("PAYMENT_RESULTS_TOPIC", &cfg.ResultsTopic)
+	env.String("SPIFFE_ENDPOINT_SOCKET", &cfg.SpiffeSocket)
+	env.String("REDIS_ADDR", &cfg.RedisAddr)
+	env.String("REDIS_PASSWORD", &cfg.RedisPassword)
+	env.String("FEE_SCHEDULE", &cfg.FeeSchedule)
+	env.String("FEE_SCHEDULE_FILE", &cfg.FeeScheduleFile)
+	env.Map("HOUSE_ACCOUNTS", &cfg.HouseAccounts)
+	env.Bool("WEBHOOK_ALLOW_HTTP", &cfg.WebhookAllowHTTP)
+	env.Bool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", &cfg.WebhookAllowPrivate)
+	env.List("PRINCIPAL_ISSUERS", &cfg.PrincipalIssuers)
+	env.String("HEALTH_PORT", &cfg.HealthPort)
+	env.List("CLIENT_SPIFFE_IDS", &cfg.ClientSpiffeIDs)
+	env.Level("LOG_LEVEL", &cfg.LogLevel)
+	env.Float("TRACE_SAMPLE_RATIO", &cfg.TraceSampleRatio)
+	if err := env.Err(); err != nil {
+		return nil, err
 	}
-	return fallback
+	return cfg, nil
 }
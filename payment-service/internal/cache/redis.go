@@ -12,6 +12,8 @@ import (
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	// Ping checks connectivity to the cache.
+	Ping(ctx context.Context) error
 }
 
 type redisCache struct {
@@ -49,3 +51,10 @@ func (r *redisCache) Set(ctx context.Context, key string, value interface{}, exp
 	}
 	return nil
 }
+
+func (r *redisCache) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package fee computes payment fees from a per-currency fee schedule.
+//
+// A schedule is configured as JSON keyed by currency code, with "*" as the
+// fallback for currencies without an explicit rule:
+//
+//	{
+//	  "TRY": {"type": "percentage", "percent": 1.5, "min": 1, "max": 50},
+//	  "USD": {"type": "tiered", "tiers": [
+//	    {"up_to": 100, "flat": 0.5},
+//	    {"up_to": 1000, "percent": 1},
+//	    {"percent": 0.5}
+//	  ]},
+//	  "*":   {"type": "flat", "flat": 2}
+//	}
+package fee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Type selects how a Rule computes the fee.
+type Type string
+
+const (
+	// TypeFlat charges Rule.Flat regardless of the amount.
+	TypeFlat Type = "flat"
+	// TypePercentage charges Rule.Percent percent of the amount plus Rule.Flat.
+	TypePercentage Type = "percentage"
+	// TypeTiered picks the first tier whose UpTo covers the amount (or the
+	// last tier) and charges its Flat plus Percent of the whole amount.
+	TypeTiered Type = "tiered"
+)
+
+// wildcard is the schedule key used for currencies without their own rule.
+const wildcard = "*"
+
+// Tier is one band of a tiered rule. UpTo == 0 means unbounded and is only
+// allowed on the last tier.
+type Tier struct {
+	UpTo    float64 `json:"up_to"`
+	Flat    float64 `json:"flat"`
+	Percent float64 `json:"percent"`
+}
+
+// Rule describes the fee for one currency. Min and Max cap the computed fee;
+// Max == 0 means no upper cap.
+type Rule struct {
+	Type    Type    `json:"type"`
+	Flat    float64 `json:"flat"`
+	Percent float64 `json:"percent"`
+	Tiers   []Tier  `json:"tiers"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// Schedule maps currency codes to fee rules.
+// The zero value charges no fees.
+type Schedule struct {
+	rules map[string]Rule
+}
+
+// ParseSchedule parses and validates a JSON fee schedule.
+// An empty input yields a schedule that charges no fees.
+func ParseSchedule(data []byte) (*Schedule, error) {
+	s := &Schedule{rules: make(map[string]Rule)}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return s, nil
+	}
+
+	var raw map[string]Rule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedule: %w", err)
+	}
+
+	for currency, rule := range raw {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid fee rule for %s: %w", currency, err)
+		}
+		if rule.Type == TypeTiered {
+			// Bounded tiers ascending, the unbounded tier (UpTo == 0) last.
+			sort.SliceStable(rule.Tiers, func(i, j int) bool {
+				a, b := rule.Tiers[i].UpTo, rule.Tiers[j].UpTo
+				if a == 0 || b == 0 {
+					return b == 0 && a != 0
+				}
+				return a < b
+			})
+		}
+		s.rules[strings.ToUpper(currency)] = rule
+	}
+
+	return s, nil
+}
+
+// LoadSchedule parses the schedule from path when set, otherwise from inline.
+func LoadSchedule(inline, path string) (*Schedule, error) {
+	data := []byte(inline)
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fee schedule file: %w", err)
+		}
+	}
+	return ParseSchedule(data)
+}
+
+// Compute returns the fee for amount in currency, rounded to 2 decimals.
+func (s *Schedule) Compute(amount float64, currency string) float64 {
+	if s == nil {
+		return 0
+	}
+
+	rule, ok := s.rule(currency)
+	if !ok {
+		return 0
+	}
+
+	var fee float64
+	switch rule.Type {
+	case TypeFlat:
+		fee = rule.Flat
+	case TypePercentage:
+		fee = rule.Flat + amount*rule.Percent/100
+	case TypeTiered:
+		// Amounts above the last bounded tier fall into the last tier.
+		tier := rule.Tiers[len(rule.Tiers)-1]
+		for _, t := range rule.Tiers {
+			if t.UpTo == 0 || amount <= t.UpTo {
+				tier = t
+				break
+			}
+		}
+		fee = tier.Flat + amount*tier.Percent/100
+	}
+
+	if fee < rule.Min {
+		fee = rule.Min
+	}
+	if rule.Max > 0 && fee > rule.Max {
+		fee = rule.Max
+	}
+
+	return math.Round(fee*100) / 100
+}
+
+// Charges reports whether the rule for currency can charge a fee.
+func (s *Schedule) Charges(currency string) bool {
+	if s == nil {
+		return false
+	}
+	rule, ok := s.rule(currency)
+	if !ok {
+		return false
+	}
+	if rule.Flat > 0 || rule.Percent > 0 || rule.Min > 0 {
+		return true
+	}
+	for _, t := range rule.Tiers {
+		if t.Flat > 0 || t.Percent > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHouseAccounts returns an error naming the currencies the schedule
+// charges a fee in but that have no house account to credit it to.
+func (s *Schedule) CheckHouseAccounts(currencies []string, houseAccounts map[string]string) error {
+	configured := make(map[string]bool, len(houseAccounts))
+	for currency := range houseAccounts {
+		configured[strings.ToUpper(currency)] = true
+	}
+
+	var missing []string
+	for _, currency := range currencies {
+		if s.Charges(currency) && !configured[strings.ToUpper(currency)] {
+			missing = append(missing, currency)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("fees are charged in %s without a house account", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// rule returns the rule for currency, falling back to the wildcard rule.
+func (s *Schedule) rule(currency string) (Rule, bool) {
+	rule, ok := s.rules[strings.ToUpper(currency)]
+	if !ok {
+		rule, ok = s.rules[wildcard]
+	}
+	return rule, ok
+}
+
+func (r Rule) validate() error {
+	if r.Flat < 0 || r.Percent < 0 || r.Min < 0 || r.Max < 0 {
+		return errors.New("flat, percent, min and max must not be negative")
+	}
+	if r.Max > 0 && r.Min > r.Max {
+		return fmt.Errorf("min %.2f exceeds max %.2f", r.Min, r.Max)
+	}
+
+	switch r.Type {
+	case TypeFlat, TypePercentage:
+		if len(r.Tiers) > 0 {
+			return fmt.Errorf("tiers are only allowed for type %q", TypeTiered)
+		}
+	case TypeTiered:
+		if len(r.Tiers) == 0 {
+			return errors.New("tiered rule requires at least one tier")
+		}
+		unbounded := 0
+		for _, t := range r.Tiers {
+			if t.Flat < 0 || t.Percent < 0 || t.UpTo < 0 {
+				return errors.New("tier values must not be negative")
+			}
+			if t.UpTo == 0 {
+				unbounded++
+			}
+		}
+		if unbounded > 1 {
+			return errors.New("only one tier may be unbounded")
+		}
+	default:
+		return fmt.Errorf("unknown fee type %q", r.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package fee
+
+import "testing"
+
+func TestCheckHouseAccounts(t *testing.T) {
+	currencies := []string{"EUR", "TRY", "USD"}
+	tests := []struct {
+		name     string
+		schedule string
+		accounts map[string]string
+		wantErr  bool
+	}{
+		{"no fees", ``, nil, false},
+		{"wildcard without accounts", `{"TRY": {"type": "percentage", "percent": 1}, "*": {"type": "flat", "flat": 0.5}}`, map[string]string{"TRY": "house-try"}, true},
+		{"wildcard with accounts", `{"TRY": {"type": "percentage", "percent": 1}, "*": {"type": "flat", "flat": 0.5}}`, map[string]string{"TRY": "house-try", "usd": "house-usd", "EUR": "house-eur"}, false},
+		{"free currency needs no account", `{"TRY": {"type": "flat", "flat": 1}, "*": {"type": "flat"}}`, map[string]string{"TRY": "house-try"}, false},
+		{"tiered fee", `{"USD": {"type": "tiered", "tiers": [{"up_to": 100}, {"percent": 1}]}}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule([]byte(tt.schedule))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.CheckHouseAccounts(currencies, tt.accounts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckHouseAccounts() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/payment-service/internal/kafka"
+	"securepay/payment-service/internal/repository"
+	"securepay/payment-service/models"
+	"securepay/pkg/principal"
+	pb "securepay/proto/gen/go/payment/v1"
+)
+
+// InitiateBatchPayment validates every line of a batch and persists the batch
+// with per-line status together with an outbox event for each accepted
+// payment. Invalid or duplicate lines are recorded as FAILED without
+// rejecting the batch. A retry with the idempotency key of a batch saved by
+// the same caller returns that batch.
+func (h *PaymentHandler) InitiateBatchPayment(ctx context.Context, req *pb.InitiateBatchPaymentRequest) (*pb.InitiateBatchPaymentResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.InitiateBatchPayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "InitiateBatchPayment called", "batch_id", req.BatchId, "count", len(req.Payments))
+
+	// Validator
+	if err := h.validator.ValidateInitiateBatchPayment(req); err != nil {
+		slog.ErrorContext(ctx, "Batch validation failed", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	caller, _ := principal.FromContext(ctx)
+
+	// Idempotency Check (keys are chosen by clients, so they are scoped to
+	// the caller like the unique index on payments.batches)
+	idempotencyKey := fmt.Sprintf("idempotency:batch:%s:%s", caller.Subject, req.IdempotencyKey)
+	cachedResp, err := h.cache.Get(ctx, idempotencyKey)
+	recordIdempotencyLookup(cachedResp != "", err)
+	if err == nil && cachedResp != "" {
+		slog.InfoContext(ctx, "Returning cached batch response for idempotency", "key", req.IdempotencyKey)
+		var resp pb.InitiateBatchPaymentResponse
+		if err := json.Unmarshal([]byte(cachedResp), &resp); err == nil {
+			return replayBatch(&resp, req)
+		}
+		slog.WarnContext(ctx, "Failed to unmarshal cached batch response", "error", err)
+	}
+
+	// The cache may have missed or expired; the database is authoritative
+	existing, err := h.findBatch(ctx, caller.Subject, req.IdempotencyKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up batch by idempotency key", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to look up batch: %v", err)
+	}
+	if existing != nil {
+		slog.InfoContext(ctx, "Returning saved batch for idempotency", "key", req.IdempotencyKey, "batch_id", existing.BatchId)
+		return replayBatch(existing, req)
+	}
+
+	batchID := req.BatchId
+	if batchID == "" {
+		batchID = uuid.NewString()
+	}
+
+	items, accepted, err := h.screenBatch(ctx, caller.Subject, batchID, req.Payments)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to screen batch", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to screen batch: %v", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	payments := make([]models.Payment, 0, len(accepted))
+	events := make([]models.OutboxMessage, 0, len(accepted))
+	for _, p := range accepted {
+		currency := strings.ToUpper(p.Currency)
+		payment := models.Payment{
+			ID:             p.PaymentId,
+			FromAccount:    p.FromAccount,
+			ToAccount:      p.ToAccount,
+			Amount:         p.Amount,
+			Fee:            h.fees.Compute(p.Amount, currency),
+			Currency:       currency,
+			IdempotencyKey: p.IdempotencyKey,
+			InitiatedBy:    caller.Subject,
+		}
+		payments = append(payments, payment)
+
+		event, err := kafka.NewOutboxMessage(ctx, models.PaymentInitiatedEvent{
+			PaymentID:   payment.ID,
+			FromAccount: payment.FromAccount,
+			ToAccount:   payment.ToAccount,
+			Amount:      payment.Amount,
+			Fee:         payment.Fee,
+			Currency:    payment.Currency,
+			Timestamp:   now,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to create batch payment event", "error", err)
+			return nil, status.Errorf(codes.Internal, "failed to create payment event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	// Save batch, items, accepted payments and their events atomically; the
+	// outbox relay publishes the events once committed
+	batch := &models.Batch{
+		ID:             batchID,
+		IdempotencyKey: req.IdempotencyKey,
+		TotalItems:     len(items),
+		InitiatedBy:    caller.Subject,
+	}
+	if err := h.repo.SaveBatch(ctx, batch, items, payments, events); err != nil {
+		if errors.Is(err, repository.ErrIdempotencyConflict) {
+			// A concurrent request with the same key won the race
+			if existing, findErr := h.findBatch(ctx, caller.Subject, req.IdempotencyKey); findErr == nil && existing != nil {
+				slog.InfoContext(ctx, "Returning concurrently saved batch for idempotency", "key", req.IdempotencyKey, "batch_id", existing.BatchId)
+				return replayBatch(existing, req)
+			}
+		}
+		slog.ErrorContext(ctx, "Failed to save batch", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to save batch: %v", err)
+	}
+	h.outbox.Notify()
+
+	for _, p := range payments {
+		p.Status = string(models.StatusPending)
+		recordStatus(p)
+		h.notifyStatus(ctx, p, "")
+	}
+
+	resp := &pb.InitiateBatchPaymentResponse{
+		BatchId:  batchID,
+		Total:    int32(len(items)),
+		Accepted: int32(len(accepted)),
+		Rejected: int32(len(items) - len(accepted)),
+		Items:    toProtoBatchItems(items),
+	}
+
+	// Save idempotency record to Redis
+	respJSON, _ := json.Marshal(resp)
+	if err := h.cache.Set(ctx, idempotencyKey, string(respJSON), 24*time.Hour); err != nil {
+		slog.WarnContext(ctx, "Failed to set batch idempotency key in cache", "error", err)
+	}
+
+	slog.InfoContext(ctx, "Batch initiated successfully", "batch_id", batchID, "accepted", resp.Accepted, "rejected", resp.Rejected)
+	return resp, nil
+}
+
+// findBatch returns the response for the batch initiatedBy saved with
+// idempotencyKey, or nil when there is none. Item statuses reflect the
+// current payment status.
+func (h *PaymentHandler) findBatch(ctx context.Context, initiatedBy, idempotencyKey string) (*pb.InitiateBatchPaymentResponse, error) {
+	batch, items, err := h.repo.FindBatchByIdempotencyKey(ctx, initiatedBy, idempotencyKey)
+	if err != nil || batch == nil {
+		return nil, err
+	}
+
+	resp := &pb.InitiateBatchPaymentResponse{
+		BatchId: batch.ID,
+		Total:   int32(batch.TotalItems),
+		Items:   toProtoBatchItems(items),
+	}
+	for _, item := range items {
+		if item.Error == "" {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+	return resp, nil
+}
+
+// replayBatch returns saved if req is a retry of it, and
+// errIdempotencyMismatch otherwise: the number of lines and, when the client
+// chose one, the batch_id must match.
+func replayBatch(saved *pb.InitiateBatchPaymentResponse, req *pb.InitiateBatchPaymentRequest) (*pb.InitiateBatchPaymentResponse, error) {
+	if int(saved.Total) != len(req.Payments) || (req.BatchId != "" && req.BatchId != saved.BatchId) {
+		return nil, errIdempotencyMismatch
+	}
+	return saved, nil
+}
+
+// GetBatch returns the batch items together with succeeded/failed/pending counts.
+func (h *PaymentHandler) GetBatch(ctx context.Context, req *pb.GetBatchRequest) (*pb.GetBatchResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.GetBatch")
+	defer span.End()
+
+	slog.InfoContext(ctx, "GetBatch called", "batch_id", req.BatchId)
+
+	if req.BatchId == "" {
+		return nil, status.Error(codes.InvalidArgument, "batch_id is required")
+	}
+
+	batch, items, err := h.repo.GetBatch(ctx, req.BatchId)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get batch", "error", err)
+		return nil, status.Errorf(codes.NotFound, "batch not found: %v", err)
+	}
+
+	resp := &pb.GetBatchResponse{
+		BatchId:     batch.ID,
+		Total:       int32(batch.TotalItems),
+		Items:       toProtoBatchItems(items),
+		InitiatedBy: batch.InitiatedBy,
+	}
+	for _, item := range items {
+		switch models.PaymentStatus(item.Status) {
+		case models.StatusCompleted:
+			resp.Succeeded++
+		case models.StatusFailed:
+			resp.Failed++
+		case models.StatusPending:
+			resp.Pending++
+		}
+	}
+
+	return resp, nil
+}
+
+// screenBatch validates every line and filters out duplicates, both within
+// the batch and against already persisted payments; idempotency keys are
+// compared within the scope of initiatedBy and the paying account. It
+// returns one item per line and the subset of requests that should be
+// persisted.
+func (h *PaymentHandler) screenBatch(ctx context.Context, initiatedBy, batchID string, payments []*pb.InitiatePaymentRequest) ([]models.BatchItem, []*pb.InitiatePaymentRequest, error) {
+	items := make([]models.BatchItem, len(payments))
+	candidates := make([]*pb.InitiatePaymentRequest, 0, len(payments))
+	seenIDs := make(map[string]bool, len(payments))
+	seenKeys := make(map[repository.PaymentKey]bool, len(payments))
+
+	for i, p := range payments {
+		line := i + 1
+
+		// Lines without explicit ids get a generated payment_id and a
+		// batch-scoped idempotency key so that payroll files stay simple.
+		if p.PaymentId == "" {
+			p.PaymentId = uuid.NewString()
+		}
+		if p.IdempotencyKey == "" {
+			p.IdempotencyKey = fmt.Sprintf("%s:%d", batchID, line)
+		}
+
+		items[i] = models.BatchItem{
+			BatchID:   batchID,
+			Line:      line,
+			PaymentID: p.PaymentId,
+			Status:    string(models.StatusPending),
+		}
+
+		if err := h.validator.ValidateBatchLine(p); err != nil {
+			items[i].Status = string(models.StatusFailed)
+			items[i].Error = err.Error()
+			continue
+		}
+		key := repository.PaymentKey{FromAccount: p.FromAccount, IdempotencyKey: p.IdempotencyKey}
+		if seenIDs[p.PaymentId] || seenKeys[key] {
+			items[i].Status = string(models.StatusFailed)
+			items[i].Error = "duplicate payment_id or idempotency_key within batch"
+			continue
+		}
+		seenIDs[p.PaymentId] = true
+		seenKeys[key] = true
+		candidates = append(candidates, p)
+	}
+
+	if len(candidates) == 0 {
+		return items, candidates, nil
+	}
+
+	ids := make([]string, 0, len(candidates))
+	keys := make([]repository.PaymentKey, 0, len(candidates))
+	for _, p := range candidates {
+		ids = append(ids, p.PaymentId)
+		keys = append(keys, repository.PaymentKey{FromAccount: p.FromAccount, IdempotencyKey: p.IdempotencyKey})
+	}
+
+	existingIDs, existingKeys, err := h.repo.FindDuplicatePayments(ctx, initiatedBy, ids, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accepted := make([]*pb.InitiatePaymentRequest, 0, len(candidates))
+	for i, p := range payments {
+		if items[i].Error != "" {
+			continue
+		}
+		if existingIDs[p.PaymentId] || existingKeys[repository.PaymentKey{FromAccount: p.FromAccount, IdempotencyKey: p.IdempotencyKey}] {
+			items[i].Status = string(models.StatusFailed)
+			items[i].Error = "payment_id or idempotency_key already exists"
+			continue
+		}
+		accepted = append(accepted, p)
+	}
+
+	return items, accepted, nil
+}
+
+func toProtoBatchItems(items []models.BatchItem) []*pb.BatchItem {
+	out := make([]*pb.BatchItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, &pb.BatchItem{
+			Line:      int32(item.Line),
+			PaymentId: item.PaymentID,
+			Status:    toProtoStatus(item.Status),
+			Error:     item.Error,
+		})
+	}
+	return out
+}
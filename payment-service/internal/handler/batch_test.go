@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"securepay/payment-service/internal/repository"
+	"securepay/payment-service/internal/validator"
+	pb "securepay/proto/gen/go/payment/v1"
+)
+
+// duplicateRepo reports the configured payment IDs and keys as existing.
+// Other Repository methods are not used by screenBatch.
+type duplicateRepo struct {
+	repository.Repository
+	ids  map[string]bool
+	keys map[repository.PaymentKey]bool
+}
+
+func (r duplicateRepo) FindDuplicatePayments(context.Context, string, []string, []repository.PaymentKey) (map[string]bool, map[repository.PaymentKey]bool, error) {
+	return r.ids, r.keys, nil
+}
+
+func TestReplayBatch(t *testing.T) {
+	saved := &pb.InitiateBatchPaymentResponse{BatchId: "batch-1", Total: 2, Accepted: 2}
+	lines := []*pb.InitiatePaymentRequest{{}, {}}
+
+	tests := []struct {
+		name string
+		req  *pb.InitiateBatchPaymentRequest
+		want error
+	}{
+		{"same batch id", &pb.InitiateBatchPaymentRequest{BatchId: "batch-1", Payments: lines}, nil},
+		{"no batch id", &pb.InitiateBatchPaymentRequest{Payments: lines}, nil},
+		{"other batch id", &pb.InitiateBatchPaymentRequest{BatchId: "batch-2", Payments: lines}, errIdempotencyMismatch},
+		{"other line count", &pb.InitiateBatchPaymentRequest{BatchId: "batch-1", Payments: lines[:1]}, errIdempotencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replayBatch(saved, tt.req)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("replayBatch() error = %v, want %v", err, tt.want)
+			}
+			if err == nil && got != saved {
+				t.Errorf("replayBatch() = %v, want the saved batch", got)
+			}
+		})
+	}
+}
+
+func TestScreenBatch(t *testing.T) {
+	const (
+		id1 = "11111111-1111-1111-1111-111111111111"
+		id2 = "22222222-2222-2222-2222-222222222222"
+		id3 = "33333333-3333-3333-3333-333333333333"
+		id4 = "44444444-4444-4444-4444-444444444444"
+	)
+	line := func(paymentID, from, key string) *pb.InitiatePaymentRequest {
+		return &pb.InitiatePaymentRequest{
+			PaymentId: paymentID, FromAccount: from, ToAccount: recipient1,
+			Amount: 10, Currency: "TRY", IdempotencyKey: key,
+		}
+	}
+
+	h := &PaymentHandler{
+		validator: validator.New(),
+		repo: duplicateRepo{
+			ids:  map[string]bool{id4: true},
+			keys: map[repository.PaymentKey]bool{{FromAccount: payer, IdempotencyKey: "saved"}: true},
+		},
+	}
+	payments := []*pb.InitiatePaymentRequest{
+		line(id1, payer, "a"),
+		line(id2, payer, id1), // a key equal to another line's payment_id is no duplicate
+		line(id1, payer, "b"), // payment_id used by line 1
+		line(id3, payer, "a"), // key used by line 1 for the same payer
+		line(id3, recipient2, "a"),
+		line(id4, payer, "c"), // payment_id already saved
+		line("55555555-5555-5555-5555-555555555555", payer, "saved"), // key already saved
+	}
+
+	items, accepted, err := h.screenBatch(context.Background(), "user-1", "batch-1", payments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantErr := []string{
+		"",
+		"",
+		"duplicate payment_id or idempotency_key within batch",
+		"duplicate payment_id or idempotency_key within batch",
+		"",
+		"payment_id or idempotency_key already exists",
+		"payment_id or idempotency_key already exists",
+	}
+	for i, item := range items {
+		if item.Error != wantErr[i] {
+			t.Errorf("line %d: error = %q, want %q", item.Line, item.Error, wantErr[i])
+		}
+	}
+	if len(accepted) != 3 {
+		t.Errorf("accepted %d lines, want 3", len(accepted))
+	}
+}
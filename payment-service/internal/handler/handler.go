@@ -2,42 +2,56 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log/slog"
-	"time"
+	"strings"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"securepay/payment-service/internal/cache"
-	"securepay/payment-service/internal/kafka"
+	"securepay/payment-service/internal/fee"
+	"securepay/payment-service/internal/outbox"
+	"securepay/payment-service/internal/pubsub"
 	"securepay/payment-service/internal/repository"
 	"securepay/payment-service/internal/validator"
+	"securepay/payment-service/internal/webhook"
 	"securepay/payment-service/models"
+	"securepay/pkg/money"
 	pb "securepay/proto/gen/go/payment/v1"
+	pbv2 "securepay/proto/gen/go/payment/v2"
 )
 
-// PaymentHandler implements pb.PaymentServiceServer
+// PaymentHandler implements pb.PaymentServiceServer. Payments are created and
+// read through the v2 implementation in v2.go; batches and WatchPayment
+// exist in v1 only.
 type PaymentHandler struct {
 	pb.UnimplementedPaymentServiceServer
 	repo      repository.Repository
 	validator *validator.Validator
-	producer  *kafka.Producer
 	cache     cache.Cache
+	fees      *fee.Schedule
+	webhooks  *webhook.Dispatcher
+	broker    pubsub.Broker
+	outbox    *outbox.Relay
 }
 
 // NewPaymentHandler creates a new PaymentHandler
-func NewPaymentHandler(repo repository.Repository, val *validator.Validator, producer *kafka.Producer, cache cache.Cache) *PaymentHandler {
+func NewPaymentHandler(repo repository.Repository, val *validator.Validator, cache cache.Cache, fees *fee.Schedule, webhooks *webhook.Dispatcher, broker pubsub.Broker, outbox *outbox.Relay) *PaymentHandler {
 	return &PaymentHandler{
 		repo:      repo,
 		validator: val,
-		producer:  producer,
 		cache:     cache,
+		fees:      fees,
+		webhooks:  webhooks,
+		broker:    broker,
+		outbox:    outbox,
 	}
 }
 
+// InitiatePayment creates the payment through the v2 implementation. The
+// payment_id chosen by the client is kept; one is generated when it is empty.
 func (h *PaymentHandler) InitiatePayment(ctx context.Context, req *pb.InitiatePaymentRequest) (*pb.InitiatePaymentResponse, error) {
 	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.InitiatePayment")
 	defer span.End()
@@ -49,57 +63,34 @@ func (h *PaymentHandler) InitiatePayment(ctx context.Context, req *pb.InitiatePa
 		slog.ErrorContext(ctx, "Validation failed", "error", err)
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-
-	// Idempotency Check
-	idempotencyKey := fmt.Sprintf("idempotency:%s", req.IdempotencyKey)
-	cachedResp, err := h.cache.Get(ctx, idempotencyKey)
-	if err == nil && cachedResp != "" {
-		slog.InfoContext(ctx, "Returning cached response for idempotency", "key", req.IdempotencyKey)
-		var resp pb.InitiatePaymentResponse
-		if err := json.Unmarshal([]byte(cachedResp), &resp); err == nil {
-			return &resp, nil
-		}
-		slog.WarnContext(ctx, "Failed to unmarshal cached response", "error", err)
-	}
-
-	// TODO: Balance Check (via Account Service gRPC)
-
-	// Save to DB
-	if err := h.repo.SavePayment(ctx, req); err != nil {
-		slog.ErrorContext(ctx, "Failed to save payment", "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to save payment: %v", err)
+	// Amounts below one minor unit pass the validator but round to zero
+	amount := money.ToMinorUnits(req.Amount)
+	if amount < 1 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be at least 0.01")
 	}
+	// v1 accepts currencies in any case; v2 and the fee schedule use upper case
+	currency := strings.ToUpper(req.Currency)
 
-	// Create Kafka Event
-	event := models.PaymentInitiatedEvent{
-		PaymentID:   req.PaymentId,
-		FromAccount: req.FromAccount,
-		ToAccount:   req.ToAccount,
-		Amount:      req.Amount,
-		Currency:    req.Currency,
-		Timestamp:   time.Now().Format(time.RFC3339),
+	paymentID := req.PaymentId
+	if paymentID == "" {
+		paymentID = uuid.NewString()
 	}
-
-	if err := h.producer.ProducePaymentInitiatedEvent(ctx, event); err != nil {
-		slog.ErrorContext(ctx, "Failed to produce payment initiated event", "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to produce payment event: %v", err)
+	payment, err := h.createPayment(ctx, paymentID, &pbv2.CreatePaymentRequest{
+		FromAccount:    req.FromAccount,
+		ToAccount:      req.ToAccount,
+		Amount:         &pbv2.Money{MinorUnits: amount, Currency: currency},
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Prepare PENDING response
-	resp := &pb.InitiatePaymentResponse{
-		PaymentId: req.PaymentId,
-		Status:    pb.PaymentStatus_PENDING,
+	return &pb.InitiatePaymentResponse{
+		PaymentId: payment.PaymentId,
+		Status:    fromProtoStatusV2(payment.Status),
 		Message:   "Payment initiated",
-	}
-
-	// Save idempotency record to Redis
-	respJSON, _ := json.Marshal(resp)
-	if err := h.cache.Set(ctx, idempotencyKey, string(respJSON), 24*time.Hour); err != nil {
-		slog.WarnContext(ctx, "Failed to set idempotency key in cache", "error", err)
-	}
-
-	slog.InfoContext(ctx, "Payment initiated successfully", "payment_id", req.PaymentId)
-	return resp, nil
+		Fee:       money.FromMinorUnits(payment.Fee.GetMinorUnits()),
+	}, nil
 }
 
 func (h *PaymentHandler) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error) {
@@ -108,37 +99,52 @@ func (h *PaymentHandler) GetPayment(ctx context.Context, req *pb.GetPaymentReque
 
 	slog.InfoContext(ctx, "GetPayment called", "payment_id", req.PaymentId)
 
-	if req.PaymentId == "" {
-		return nil, status.Error(codes.InvalidArgument, "payment_id is required")
+	payment, err := h.getPayment(ctx, req.PaymentId)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fetch from DB
-	payment, err := h.repo.GetPayment(ctx, req.PaymentId)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get payment", "error", err)
-		return nil, status.Errorf(codes.NotFound, "payment not found: %v", err)
+	resp := &pb.GetPaymentResponse{
+		PaymentId:   payment.PaymentId,
+		Status:      fromProtoStatusV2(payment.Status),
+		Message:     "Payment details retrieved",
+		Amount:      money.FromMinorUnits(payment.Amount.GetMinorUnits()),
+		Currency:    payment.Amount.GetCurrency(),
+		FromAccount: payment.FromAccount,
+		ToAccount:   payment.ToAccount,
+		Fee:         money.FromMinorUnits(payment.Fee.GetMinorUnits()),
+		InitiatedBy: payment.InitiatedBy,
+	}
+	for _, leg := range payment.Legs {
+		resp.Legs = append(resp.Legs, &pb.SplitLeg{ToAccount: leg.ToAccount, Amount: money.FromMinorUnits(leg.Amount.GetMinorUnits())})
 	}
+	return resp, nil
+}
 
-	// Map status string to enum using models constants
-	var paymentStatus pb.PaymentStatus
-	switch models.PaymentStatus(payment.Status) {
+// toProtoStatus maps a stored status string to the enum using models constants
+func toProtoStatus(s string) pb.PaymentStatus {
+	switch models.PaymentStatus(s) {
 	case models.StatusPending:
-		paymentStatus = pb.PaymentStatus_PENDING
+		return pb.PaymentStatus_PENDING
 	case models.StatusCompleted:
-		paymentStatus = pb.PaymentStatus_COMPLETED
+		return pb.PaymentStatus_COMPLETED
 	case models.StatusFailed:
-		paymentStatus = pb.PaymentStatus_FAILED
+		return pb.PaymentStatus_FAILED
 	default:
-		paymentStatus = pb.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+		return pb.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
 	}
+}
 
-	return &pb.GetPaymentResponse{
-		PaymentId:   payment.ID,
-		Status:      paymentStatus,
-		Message:     "Payment details retrieved",
-		Amount:      payment.Amount,
-		Currency:    payment.Currency,
-		FromAccount: payment.FromAccount,
-		ToAccount:   payment.ToAccount,
-	}, nil
+// fromProtoStatusV2 maps a v2 status to the v1 enum
+func fromProtoStatusV2(s pbv2.PaymentStatus) pb.PaymentStatus {
+	switch s {
+	case pbv2.PaymentStatus_PAYMENT_STATUS_PENDING:
+		return pb.PaymentStatus_PENDING
+	case pbv2.PaymentStatus_PAYMENT_STATUS_COMPLETED:
+		return pb.PaymentStatus_COMPLETED
+	case pbv2.PaymentStatus_PAYMENT_STATUS_FAILED:
+		return pb.PaymentStatus_FAILED
+	default:
+		return pb.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+	}
 }
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/payment-service/internal/validator"
+	pb "securepay/proto/gen/go/payment/v1"
+)
+
+const (
+	payer      = "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	recipient1 = "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	recipient2 = "cccccccc-cccc-cccc-cccc-cccccccccccc"
+)
+
+// The v1 API takes decimal amounts; those that round to zero minor units
+// are rejected before anything is saved.
+func TestV1RejectsAmountsBelowOneMinorUnit(t *testing.T) {
+	h := &PaymentHandler{validator: validator.New()}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantMsg string
+	}{
+		{"payment", func() error {
+			_, err := h.InitiatePayment(ctx, &pb.InitiatePaymentRequest{
+				FromAccount: payer, ToAccount: recipient1, Amount: 0.004, Currency: "TRY", IdempotencyKey: "k1",
+			})
+			return err
+		}, "amount must be at least 0.01"},
+		{"split leg", func() error {
+			_, err := h.InitiateSplitPayment(ctx, &pb.InitiateSplitPaymentRequest{
+				PaymentId:   "11111111-1111-1111-1111-111111111111",
+				FromAccount: payer,
+				Currency:    "TRY",
+				Legs: []*pb.SplitLeg{
+					{ToAccount: recipient1, Amount: 5},
+					{ToAccount: recipient2, Amount: 0.001},
+				},
+				IdempotencyKey: "k2",
+			})
+			return err
+		}, "leg 2: amount must be at least 0.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("error = %v, want code %s", err, codes.InvalidArgument)
+			}
+			if got := status.Convert(err).Message(); got != tt.wantMsg {
+				t.Errorf("message = %q, want %q", got, tt.wantMsg)
+			}
+		})
+	}
+}
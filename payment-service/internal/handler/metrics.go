@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"securepay/payment-service/models"
+)
+
+var (
+	paymentsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_total",
+		Help: "Payments that reached a status, by status and currency.",
+	}, []string{"status", "currency"})
+
+	paymentAmount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "payment_amount",
+		Help:    "Amounts of initiated payments in units of the currency, fees excluded.",
+		Buckets: []float64{1, 5, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000, 1000000},
+	}, []string{"currency"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Redis lookups by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+)
+
+// recordStatus counts a payment entering its current status. Amounts are
+// recorded once, when the payment is initiated.
+func recordStatus(p models.Payment) {
+	paymentsTotal.WithLabelValues(p.Status, p.Currency).Inc()
+	if models.PaymentStatus(p.Status) == models.StatusPending {
+		paymentAmount.WithLabelValues(p.Currency).Observe(p.Amount)
+	}
+}
+
+// recordIdempotencyLookup counts a lookup of a cached idempotent response.
+func recordIdempotencyLookup(hit bool, err error) {
+	result := "miss"
+	switch {
+	case err != nil:
+		result = "error"
+	case hit:
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues("idempotency", result).Inc()
+}
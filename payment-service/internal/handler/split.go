@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/pkg/money"
+	pb "securepay/proto/gen/go/payment/v1"
+	pbv2 "securepay/proto/gen/go/payment/v2"
+)
+
+// InitiateSplitPayment creates the split payment through the v2
+// implementation, keeping the payment_id chosen by the client.
+func (h *PaymentHandler) InitiateSplitPayment(ctx context.Context, req *pb.InitiateSplitPaymentRequest) (*pb.InitiatePaymentResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.InitiateSplitPayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "InitiateSplitPayment called", "payment_id", req.PaymentId, "legs", len(req.Legs))
+
+	// Validator
+	if err := h.validator.ValidateInitiateSplitPayment(req); err != nil {
+		slog.ErrorContext(ctx, "Validation failed", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// v1 accepts currencies in any case; v2 and the fee schedule use upper case
+	currency := strings.ToUpper(req.Currency)
+	legs := make([]*pbv2.SplitLeg, 0, len(req.Legs))
+	for i, leg := range req.Legs {
+		amount := money.ToMinorUnits(leg.Amount)
+		if amount < 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "leg %d: amount must be at least 0.01", i+1)
+		}
+		legs = append(legs, &pbv2.SplitLeg{
+			ToAccount: leg.ToAccount,
+			Amount:    &pbv2.Money{MinorUnits: amount, Currency: currency},
+		})
+	}
+
+	payment, err := h.createSplitPayment(ctx, req.PaymentId, &pbv2.CreateSplitPaymentRequest{
+		FromAccount:    req.FromAccount,
+		Legs:           legs,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.InitiatePaymentResponse{
+		PaymentId: payment.PaymentId,
+		Status:    fromProtoStatusV2(payment.Status),
+		Message:   "Split payment initiated",
+		Fee:       money.FromMinorUnits(payment.Fee.GetMinorUnits()),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+
+	"securepay/payment-service/internal/repository"
+	"securepay/payment-service/internal/webhook"
+	"securepay/payment-service/models"
+)
+
+// HandlePaymentResult applies a result reported by account-service to the
+// payment and notifies subscribers of the state change. Redelivered results
+// for a payment that already has the status are ignored.
+func (h *PaymentHandler) HandlePaymentResult(ctx context.Context, event models.PaymentResultEvent) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.HandlePaymentResult")
+	defer span.End()
+
+	next := models.PaymentStatus(event.Status)
+	if next != models.StatusCompleted && next != models.StatusFailed {
+		return fmt.Errorf("unexpected result status %q", event.Status)
+	}
+
+	if err := h.repo.UpdatePaymentStatus(ctx, event.PaymentID, next); err != nil {
+		if errors.Is(err, repository.ErrStatusUnchanged) {
+			slog.InfoContext(ctx, "Payment already in result status", "payment_id", event.PaymentID, "status", next)
+			return nil
+		}
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+	slog.InfoContext(ctx, "Payment status updated", "payment_id", event.PaymentID, "status", next)
+
+	// Wake up WatchPayment streams on every replica
+	if err := h.broker.Publish(ctx, event); err != nil {
+		slog.WarnContext(ctx, "Failed to publish status change", "error", err, "payment_id", event.PaymentID)
+	}
+
+	payment, err := h.repo.GetPayment(ctx, event.PaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to load payment after status update: %w", err)
+	}
+	recordStatus(*payment)
+	h.notifyStatus(ctx, *payment, event.Reason)
+
+	return nil
+}
+
+// notifyStatus publishes a webhook event for the payment's current status.
+// Failures are logged only; notifications never fail the payment itself.
+func (h *PaymentHandler) notifyStatus(ctx context.Context, p models.Payment, reason string) {
+	var eventType string
+	switch models.PaymentStatus(p.Status) {
+	case models.StatusPending:
+		eventType = webhook.EventPaymentPending
+	case models.StatusCompleted:
+		eventType = webhook.EventPaymentCompleted
+	case models.StatusFailed:
+		eventType = webhook.EventPaymentFailed
+	default:
+		return
+	}
+
+	accounts := []string{p.FromAccount}
+	if p.ToAccount != "" {
+		accounts = append(accounts, p.ToAccount)
+	}
+	for _, leg := range p.Legs {
+		accounts = append(accounts, leg.ToAccount)
+	}
+
+	data := webhook.PaymentData{
+		PaymentID:   p.ID,
+		Status:      p.Status,
+		Amount:      p.Amount,
+		Fee:         p.Fee,
+		Currency:    p.Currency,
+		FromAccount: p.FromAccount,
+		ToAccount:   p.ToAccount,
+		Reason:      reason,
+	}
+	if err := h.webhooks.Publish(ctx, eventType, data, accounts); err != nil {
+		slog.WarnContext(ctx, "Failed to publish webhook event", "error", err, "payment_id", p.ID)
+	}
+}
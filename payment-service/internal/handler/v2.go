@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"securepay/payment-service/internal/kafka"
+	"securepay/payment-service/internal/repository"
+	"securepay/payment-service/models"
+	"securepay/pkg/money"
+	"securepay/pkg/principal"
+	pbv2 "securepay/proto/gen/go/payment/v2"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// PaymentHandlerV2 implements pbv2.PaymentServiceServer. Payments of both
+// API versions are created by createPayment and createSplitPayment, which
+// take v2 requests; the v1 methods of PaymentHandler translate to them.
+type PaymentHandlerV2 struct {
+	pbv2.UnimplementedPaymentServiceServer
+	h *PaymentHandler
+}
+
+// NewPaymentHandlerV2 creates a PaymentHandlerV2 sharing the dependencies of h
+func NewPaymentHandlerV2(h *PaymentHandler) *PaymentHandlerV2 {
+	return &PaymentHandlerV2{h: h}
+}
+
+// CreatePayment creates a payment with a server-generated id
+func (v *PaymentHandlerV2) CreatePayment(ctx context.Context, req *pbv2.CreatePaymentRequest) (*pbv2.Payment, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.v2.CreatePayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "CreatePayment called", "amount", req.GetAmount().GetMinorUnits(), "currency", req.GetAmount().GetCurrency())
+
+	if err := v.h.validator.ValidateCreatePayment(req); err != nil {
+		slog.ErrorContext(ctx, "Validation failed", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return v.h.createPayment(ctx, uuid.NewString(), req)
+}
+
+func (v *PaymentHandlerV2) GetPayment(ctx context.Context, req *pbv2.GetPaymentRequest) (*pbv2.Payment, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.v2.GetPayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "GetPayment called", "payment_id", req.PaymentId)
+
+	return v.h.getPayment(ctx, req.PaymentId)
+}
+
+// CreateSplitPayment creates a split payment with a server-generated id
+func (v *PaymentHandlerV2) CreateSplitPayment(ctx context.Context, req *pbv2.CreateSplitPaymentRequest) (*pbv2.Payment, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.v2.CreateSplitPayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "CreateSplitPayment called", "legs", len(req.Legs))
+
+	if err := v.h.validator.ValidateCreateSplitPayment(req); err != nil {
+		slog.ErrorContext(ctx, "Validation failed", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return v.h.createSplitPayment(ctx, uuid.NewString(), req)
+}
+
+// ListPayments pages through the payments of an account, newest first.
+// The page token is the position of the last payment of the previous page.
+func (v *PaymentHandlerV2) ListPayments(ctx context.Context, req *pbv2.ListPaymentsRequest) (*pbv2.ListPaymentsResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.v2.ListPayments")
+	defer span.End()
+
+	slog.InfoContext(ctx, "ListPayments called", "account_id", req.AccountId, "page_size", req.PageSize)
+
+	if _, err := uuid.Parse(req.AccountId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "account_id must be a valid UUID")
+	}
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var after *repository.Cursor
+	if req.PageToken != "" {
+		cursor, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		after = &cursor
+	}
+
+	// One extra row tells whether there is a next page
+	payments, err := v.h.repo.ListPayments(ctx, req.AccountId, after, pageSize+1)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list payments", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to list payments: %v", err)
+	}
+
+	resp := &pbv2.ListPaymentsResponse{}
+	if len(payments) > pageSize {
+		payments = payments[:pageSize]
+		last := payments[pageSize-1]
+		resp.NextPageToken = encodePageToken(repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, p := range payments {
+		resp.Payments = append(resp.Payments, toProtoPaymentV2(p))
+	}
+	return resp, nil
+}
+
+// createPayment records a payment with the given id and, through the outbox,
+// publishes it to account-service. Repeated calls of the same caller with the same payer and
+// idempotency key return the payment created by the first one.
+func (h *PaymentHandler) createPayment(ctx context.Context, paymentID string, req *pbv2.CreatePaymentRequest) (*pbv2.Payment, error) {
+	// TODO: Balance Check (via Account Service gRPC)
+
+	amount := money.FromMinorUnits(req.Amount.MinorUnits)
+	caller, _ := principal.FromContext(ctx)
+	payment := models.Payment{
+		ID:             paymentID,
+		FromAccount:    req.FromAccount,
+		ToAccount:      req.ToAccount,
+		Amount:         amount,
+		Fee:            h.fees.Compute(amount, req.Amount.Currency),
+		Currency:       req.Amount.Currency,
+		Status:         string(models.StatusPending),
+		IdempotencyKey: req.IdempotencyKey,
+		InitiatedBy:    caller.Subject,
+	}
+
+	// Idempotency Check
+	idempotencyKey := idempotencyCacheKey(payment)
+	if resp, err := h.idempotentPayment(ctx, idempotencyKey, payment); resp != nil || err != nil {
+		return resp, err
+	}
+
+	// Create Kafka Event
+	event, err := kafka.NewOutboxMessage(ctx, models.PaymentInitiatedEvent{
+		PaymentID:   payment.ID,
+		FromAccount: payment.FromAccount,
+		ToAccount:   payment.ToAccount,
+		Amount:      payment.Amount,
+		Fee:         payment.Fee,
+		Currency:    payment.Currency,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create payment initiated event", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to create payment event: %v", err)
+	}
+
+	// Save the payment and its event atomically; the outbox relay publishes
+	// the event once committed
+	if err := h.repo.SavePayment(ctx, &payment, []models.OutboxMessage{event}); err != nil {
+		if resp, err := h.concurrentPayment(ctx, err, payment); resp != nil || err != nil {
+			return resp, err
+		}
+		slog.ErrorContext(ctx, "Failed to save payment", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to save payment: %v", err)
+	}
+	h.outbox.Notify()
+
+	recordStatus(payment)
+	h.notifyStatus(ctx, payment, "")
+
+	resp := toProtoPaymentV2(payment)
+	h.cachePayment(ctx, idempotencyKey, resp)
+
+	slog.InfoContext(ctx, "Payment initiated successfully", "payment_id", payment.ID)
+	return resp, nil
+}
+
+// createSplitPayment records a single debit from from_account that is
+// fanned out to several recipients. The legs travel in one Kafka event so
+// that account-service applies all credits in one DB transaction.
+func (h *PaymentHandler) createSplitPayment(ctx context.Context, paymentID string, req *pbv2.CreateSplitPaymentRequest) (*pbv2.Payment, error) {
+	currency := req.Legs[0].Amount.Currency
+	legs := make([]models.SplitLeg, 0, len(req.Legs))
+	var total int64
+	for _, leg := range req.Legs {
+		legs = append(legs, models.SplitLeg{ToAccount: leg.ToAccount, Amount: money.FromMinorUnits(leg.Amount.MinorUnits)})
+		total += leg.Amount.MinorUnits
+	}
+	amount := money.FromMinorUnits(total)
+	caller, _ := principal.FromContext(ctx)
+	payment := models.Payment{
+		ID:             paymentID,
+		FromAccount:    req.FromAccount,
+		Amount:         amount,
+		Fee:            h.fees.Compute(amount, currency),
+		Currency:       currency,
+		Status:         string(models.StatusPending),
+		IdempotencyKey: req.IdempotencyKey,
+		InitiatedBy:    caller.Subject,
+		Legs:           legs,
+	}
+
+	// Idempotency Check (shares the namespace of single payments, the key is
+	// unique across payments.transactions)
+	idempotencyKey := idempotencyCacheKey(payment)
+	if resp, err := h.idempotentPayment(ctx, idempotencyKey, payment); resp != nil || err != nil {
+		return resp, err
+	}
+
+	// Create Kafka Event
+	event, err := kafka.NewOutboxMessage(ctx, models.PaymentInitiatedEvent{
+		PaymentID:   payment.ID,
+		FromAccount: payment.FromAccount,
+		Amount:      payment.Amount,
+		Fee:         payment.Fee,
+		Currency:    payment.Currency,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Legs:        legs,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create payment initiated event", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to create payment event: %v", err)
+	}
+
+	// Save the payment, its legs and its event atomically
+	if err := h.repo.SaveSplitPayment(ctx, &payment, []models.OutboxMessage{event}); err != nil {
+		if resp, err := h.concurrentPayment(ctx, err, payment); resp != nil || err != nil {
+			return resp, err
+		}
+		slog.ErrorContext(ctx, "Failed to save split payment", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to save payment: %v", err)
+	}
+	h.outbox.Notify()
+
+	recordStatus(payment)
+	h.notifyStatus(ctx, payment, "")
+
+	resp := toProtoPaymentV2(payment)
+	h.cachePayment(ctx, idempotencyKey, resp)
+
+	slog.InfoContext(ctx, "Split payment initiated successfully", "payment_id", payment.ID, "total", payment.Amount)
+	return resp, nil
+}
+
+// getPayment fetches a payment by id
+func (h *PaymentHandler) getPayment(ctx context.Context, paymentID string) (*pbv2.Payment, error) {
+	if paymentID == "" {
+		return nil, status.Error(codes.InvalidArgument, "payment_id is required")
+	}
+
+	// Fetch from DB
+	payment, err := h.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get payment", "error", err)
+		return nil, status.Errorf(codes.NotFound, "payment not found: %v", err)
+	}
+
+	return toProtoPaymentV2(*payment), nil
+}
+
+// errIdempotencyMismatch is returned when an idempotency key is reused for
+// a request that differs from the one saved with it.
+var errIdempotencyMismatch = status.Error(codes.FailedPrecondition, "idempotency_key was already used for a different request")
+
+// idempotencyCacheKey is the cache key of the idempotency record of p. Like
+// the unique index on payments.transactions, it is scoped to the initiator
+// and the paying account.
+func idempotencyCacheKey(p models.Payment) string {
+	return fmt.Sprintf("idempotency:v2:%s:%s:%s", p.InitiatedBy, p.FromAccount, p.IdempotencyKey)
+}
+
+// idempotentPayment returns the payment already created for p's idempotency
+// key, or nil when there is none. The cache is consulted first; on a miss
+// the database is authoritative, since cache entries expire and earlier
+// releases cached their responses under unscoped keys.
+func (h *PaymentHandler) idempotentPayment(ctx context.Context, cacheKey string, p models.Payment) (*pbv2.Payment, error) {
+	if resp, ok := h.cachedPayment(ctx, cacheKey); ok {
+		return replayPayment(resp, p)
+	}
+
+	payment, err := h.repo.FindPaymentByIdempotencyKey(ctx, p.InitiatedBy, p.FromAccount, p.IdempotencyKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up payment by idempotency key", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to look up payment: %v", err)
+	}
+	if payment == nil {
+		return nil, nil
+	}
+
+	slog.InfoContext(ctx, "Returning saved payment for idempotency", "key", p.IdempotencyKey, "payment_id", payment.ID)
+	resp := toProtoPaymentV2(*payment)
+	h.cachePayment(ctx, cacheKey, resp)
+	return replayPayment(resp, p)
+}
+
+// concurrentPayment returns the payment saved by a concurrent request with
+// the same idempotency key when saveErr is an idempotency conflict.
+func (h *PaymentHandler) concurrentPayment(ctx context.Context, saveErr error, p models.Payment) (*pbv2.Payment, error) {
+	if !errors.Is(saveErr, repository.ErrIdempotencyConflict) {
+		return nil, nil
+	}
+	payment, err := h.repo.FindPaymentByIdempotencyKey(ctx, p.InitiatedBy, p.FromAccount, p.IdempotencyKey)
+	if err != nil || payment == nil {
+		return nil, nil
+	}
+	slog.InfoContext(ctx, "Returning concurrently saved payment for idempotency", "key", p.IdempotencyKey, "payment_id", payment.ID)
+	return replayPayment(toProtoPaymentV2(*payment), p)
+}
+
+// replayPayment returns saved if it is the payment p asks for again, and
+// errIdempotencyMismatch otherwise. Ids are not compared: v2 generates them
+// per call.
+func replayPayment(saved *pbv2.Payment, p models.Payment) (*pbv2.Payment, error) {
+	want := toProtoPaymentV2(p)
+	if saved.InitiatedBy != want.InitiatedBy ||
+		saved.FromAccount != want.FromAccount ||
+		saved.ToAccount != want.ToAccount ||
+		!proto.Equal(saved.Amount, want.Amount) ||
+		len(saved.Legs) != len(want.Legs) {
+		return nil, errIdempotencyMismatch
+	}
+	for i := range saved.Legs {
+		if !proto.Equal(saved.Legs[i], want.Legs[i]) {
+			return nil, errIdempotencyMismatch
+		}
+	}
+	return saved, nil
+}
+
+// cachedPayment returns the payment stored under an idempotency key
+func (h *PaymentHandler) cachedPayment(ctx context.Context, key string) (*pbv2.Payment, bool) {
+	cachedResp, err := h.cache.Get(ctx, key)
+	recordIdempotencyLookup(cachedResp != "", err)
+	if err != nil || cachedResp == "" {
+		return nil, false
+	}
+	var resp pbv2.Payment
+	if err := protojson.Unmarshal([]byte(cachedResp), &resp); err != nil {
+		slog.WarnContext(ctx, "Failed to unmarshal cached response", "error", err)
+		return nil, false
+	}
+	slog.InfoContext(ctx, "Returning cached response for idempotency", "key", key)
+	return &resp, true
+}
+
+// cachePayment saves the idempotency record to Redis
+func (h *PaymentHandler) cachePayment(ctx context.Context, key string, p *pbv2.Payment) {
+	respJSON, _ := protojson.Marshal(p)
+	if err := h.cache.Set(ctx, key, string(respJSON), 24*time.Hour); err != nil {
+		slog.WarnContext(ctx, "Failed to set idempotency key in cache", "error", err)
+	}
+}
+
+func toProtoPaymentV2(p models.Payment) *pbv2.Payment {
+	resp := &pbv2.Payment{
+		PaymentId:   p.ID,
+		Status:      toProtoStatusV2(p.Status),
+		FromAccount: p.FromAccount,
+		ToAccount:   p.ToAccount,
+		Amount:      &pbv2.Money{MinorUnits: money.ToMinorUnits(p.Amount), Currency: p.Currency},
+		Fee:         &pbv2.Money{MinorUnits: money.ToMinorUnits(p.Fee), Currency: p.Currency},
+		InitiatedBy: p.InitiatedBy,
+		CreateTime:  timestamppb.New(p.CreatedAt),
+		UpdateTime:  timestamppb.New(p.UpdatedAt),
+	}
+	for _, leg := range p.Legs {
+		resp.Legs = append(resp.Legs, &pbv2.SplitLeg{
+			ToAccount: leg.ToAccount,
+			Amount:    &pbv2.Money{MinorUnits: money.ToMinorUnits(leg.Amount), Currency: p.Currency},
+		})
+	}
+	return resp
+}
+
+// toProtoStatusV2 maps a stored status string to the v2 enum
+func toProtoStatusV2(s string) pbv2.PaymentStatus {
+	switch models.PaymentStatus(s) {
+	case models.StatusPending:
+		return pbv2.PaymentStatus_PAYMENT_STATUS_PENDING
+	case models.StatusCompleted:
+		return pbv2.PaymentStatus_PAYMENT_STATUS_COMPLETED
+	case models.StatusFailed:
+		return pbv2.PaymentStatus_PAYMENT_STATUS_FAILED
+	default:
+		return pbv2.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+	}
+}
+
+// encodePageToken encodes a cursor as an opaque page token
+func encodePageToken(c repository.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + c.ID))
+}
+
+func decodePageToken(token string) (repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return repository.Cursor{}, err
+	}
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return repository.Cursor{}, fmt.Errorf("malformed page token")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return repository.Cursor{}, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return repository.Cursor{}, err
+	}
+	return repository.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"securepay/payment-service/models"
+)
+
+func TestReplayPayment(t *testing.T) {
+	saved := models.Payment{
+		ID:             "11111111-1111-1111-1111-111111111111",
+		FromAccount:    "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+		ToAccount:      "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb",
+		Amount:         12.5,
+		Currency:       "TRY",
+		IdempotencyKey: "key-1",
+		InitiatedBy:    "user-1",
+	}
+	split := models.Payment{
+		FromAccount:    saved.FromAccount,
+		Amount:         30,
+		Currency:       "TRY",
+		IdempotencyKey: "key-2",
+		InitiatedBy:    "user-1",
+		Legs: []models.SplitLeg{
+			{ToAccount: "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb", Amount: 10},
+			{ToAccount: "cccccccc-cccc-cccc-cccc-cccccccccccc", Amount: 20},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		saved  models.Payment
+		modify func(p *models.Payment)
+		want   error
+	}{
+		{"same request", saved, func(p *models.Payment) {}, nil},
+		{"new payment id", saved, func(p *models.Payment) { p.ID = "22222222-2222-2222-2222-222222222222" }, nil},
+		{"other amount", saved, func(p *models.Payment) { p.Amount = 13 }, errIdempotencyMismatch},
+		{"other currency", saved, func(p *models.Payment) { p.Currency = "USD" }, errIdempotencyMismatch},
+		{"other recipient", saved, func(p *models.Payment) { p.ToAccount = "cccccccc-cccc-cccc-cccc-cccccccccccc" }, errIdempotencyMismatch},
+		{"other initiator", saved, func(p *models.Payment) { p.InitiatedBy = "user-2" }, errIdempotencyMismatch},
+		{"other payer", saved, func(p *models.Payment) { p.FromAccount = "dddddddd-dddd-dddd-dddd-dddddddddddd" }, errIdempotencyMismatch},
+		{"same split", split, func(p *models.Payment) {}, nil},
+		{"split leg amounts swapped", split, func(p *models.Payment) {
+			p.Legs = []models.SplitLeg{{ToAccount: split.Legs[0].ToAccount, Amount: 20}, {ToAccount: split.Legs[1].ToAccount, Amount: 10}}
+		}, errIdempotencyMismatch},
+		{"split leg missing", split, func(p *models.Payment) { p.Legs = split.Legs[:1] }, errIdempotencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.saved
+			p.Legs = append([]models.SplitLeg(nil), tt.saved.Legs...)
+			tt.modify(&p)
+
+			got, err := replayPayment(toProtoPaymentV2(tt.saved), p)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("replayPayment() error = %v, want %v", err, tt.want)
+			}
+			if err == nil && got.PaymentId != tt.saved.ID {
+				t.Errorf("replayPayment() returned payment %s, want the saved %s", got.PaymentId, tt.saved.ID)
+			}
+		})
+	}
+}
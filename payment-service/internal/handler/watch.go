@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/payment-service/models"
+	pb "securepay/proto/gen/go/payment/v1"
+)
+
+// WatchPayment streams the payment's current status followed by every status
+// transition. The stream ends once a terminal status has been sent or when
+// the client cancels.
+func (h *PaymentHandler) WatchPayment(req *pb.WatchPaymentRequest, stream pb.PaymentService_WatchPaymentServer) error {
+	ctx, span := otel.Tracer("payment-service").Start(stream.Context(), "handler.WatchPayment")
+	defer span.End()
+
+	slog.InfoContext(ctx, "WatchPayment called", "payment_id", req.PaymentId)
+
+	if _, err := uuid.Parse(req.PaymentId); err != nil {
+		return status.Error(codes.InvalidArgument, "payment_id must be a valid UUID")
+	}
+
+	// Subscribe before reading the current status so that a transition
+	// between the two cannot be lost.
+	events, err := h.broker.Subscribe(ctx, req.PaymentId)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to subscribe to status changes", "error", err)
+		return status.Errorf(codes.Unavailable, "failed to subscribe to status changes: %v", err)
+	}
+
+	payment, err := h.repo.GetPayment(ctx, req.PaymentId)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get payment", "error", err)
+		return status.Errorf(codes.NotFound, "payment not found: %v", err)
+	}
+
+	current := models.PaymentStatus(payment.Status)
+	if err := stream.Send(&pb.PaymentStatusEvent{
+		PaymentId: payment.ID,
+		Status:    toProtoStatus(payment.Status),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+
+	for !isTerminal(current) {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "WatchPayment cancelled by client", "payment_id", req.PaymentId)
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-events:
+			if !ok {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			next := models.PaymentStatus(event.Status)
+			if next == current {
+				continue
+			}
+			current = next
+			if err := stream.Send(&pb.PaymentStatusEvent{
+				PaymentId: event.PaymentID,
+				Status:    toProtoStatus(event.Status),
+				Reason:    event.Reason,
+				Timestamp: event.Timestamp,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isTerminal(s models.PaymentStatus) bool {
+	return s == models.StatusCompleted || s == models.StatusFailed
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"securepay/payment-service/internal/webhook"
+	pb "securepay/proto/gen/go/payment/v1"
+)
+
+const (
+	defaultDeliveryListLimit = 50
+	maxDeliveryListLimit     = 500
+)
+
+// WebhookHandler implements pb.WebhookServiceServer
+type WebhookHandler struct {
+	pb.UnimplementedWebhookServiceServer
+	store      webhook.Store
+	dispatcher *webhook.Dispatcher
+	allowHTTP  bool
+}
+
+// NewWebhookHandler creates a new WebhookHandler. allowHTTP permits plain
+// http:// endpoint URLs and should only be enabled for local development.
+func NewWebhookHandler(store webhook.Store, dispatcher *webhook.Dispatcher, allowHTTP bool) *WebhookHandler {
+	return &WebhookHandler{
+		store:      store,
+		dispatcher: dispatcher,
+		allowHTTP:  allowHTTP,
+	}
+}
+
+func (h *WebhookHandler) RegisterWebhook(ctx context.Context, req *pb.RegisterWebhookRequest) (*pb.RegisterWebhookResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.RegisterWebhook")
+	defer span.End()
+
+	if err := h.validateRegisterWebhook(req); err != nil {
+		slog.ErrorContext(ctx, "Validation failed", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	eventTypes := req.EventTypes
+	if len(eventTypes) == 0 {
+		eventTypes = webhook.EventTypes
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate webhook secret", "error", err)
+		return nil, status.Error(codes.Internal, "failed to generate webhook secret")
+	}
+
+	endpoint := &webhook.Endpoint{
+		ClientID:   req.ClientId,
+		URL:        req.Url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		AccountIDs: req.AccountIds,
+	}
+	if err := h.store.CreateEndpoint(ctx, endpoint); err != nil {
+		slog.ErrorContext(ctx, "Failed to save webhook endpoint", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to save webhook: %v", err)
+	}
+
+	slog.InfoContext(ctx, "Webhook registered", "webhook_id", endpoint.ID, "client_id", endpoint.ClientID)
+	return &pb.RegisterWebhookResponse{
+		Webhook: toProtoWebhook(*endpoint),
+		Secret:  secret,
+	}, nil
+}
+
+func (h *WebhookHandler) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.ListWebhooks")
+	defer span.End()
+
+	if req.ClientId == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id is required")
+	}
+
+	endpoints, err := h.store.ListEndpoints(ctx, req.ClientId)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list webhooks", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to list webhooks: %v", err)
+	}
+
+	resp := &pb.ListWebhooksResponse{Webhooks: make([]*pb.Webhook, 0, len(endpoints))}
+	for _, e := range endpoints {
+		resp.Webhooks = append(resp.Webhooks, toProtoWebhook(e))
+	}
+	return resp, nil
+}
+
+func (h *WebhookHandler) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.DeleteWebhook")
+	defer span.End()
+
+	if req.ClientId == "" || req.WebhookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and webhook_id are required")
+	}
+
+	if err := h.store.DeleteEndpoint(ctx, req.ClientId, req.WebhookId); err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "webhook not found")
+		}
+		slog.ErrorContext(ctx, "Failed to delete webhook", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to delete webhook: %v", err)
+	}
+
+	slog.InfoContext(ctx, "Webhook deleted", "webhook_id", req.WebhookId, "client_id", req.ClientId)
+	return &pb.DeleteWebhookResponse{}, nil
+}
+
+func (h *WebhookHandler) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.ListWebhookDeliveries")
+	defer span.End()
+
+	if req.ClientId == "" || req.WebhookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and webhook_id are required")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultDeliveryListLimit
+	}
+	limit = min(limit, maxDeliveryListLimit)
+
+	deliveries, err := h.store.ListDeliveries(ctx, req.ClientId, req.WebhookId, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list webhook deliveries", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to list webhook deliveries: %v", err)
+	}
+
+	resp := &pb.ListWebhookDeliveriesResponse{Deliveries: make([]*pb.WebhookDelivery, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toProtoDelivery(d))
+	}
+	return resp, nil
+}
+
+func (h *WebhookHandler) RedeliverWebhook(ctx context.Context, req *pb.RedeliverWebhookRequest) (*pb.RedeliverWebhookResponse, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "handler.RedeliverWebhook")
+	defer span.End()
+
+	if req.ClientId == "" || req.DeliveryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and delivery_id are required")
+	}
+
+	delivery, err := h.dispatcher.Redeliver(ctx, req.ClientId, req.DeliveryId)
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "delivery not found")
+		}
+		slog.ErrorContext(ctx, "Failed to redeliver webhook", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to redeliver webhook: %v", err)
+	}
+
+	slog.InfoContext(ctx, "Webhook redelivery scheduled", "delivery_id", delivery.ID, "original_delivery_id", req.DeliveryId)
+	return &pb.RedeliverWebhookResponse{Delivery: toProtoDelivery(*delivery)}, nil
+}
+
+func (h *WebhookHandler) validateRegisterWebhook(req *pb.RegisterWebhookRequest) error {
+	if req.ClientId == "" {
+		return fmt.Errorf("client_id is required")
+	}
+
+	u, err := url.Parse(req.Url)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("url must be an absolute URL")
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !h.allowHTTP {
+			return fmt.Errorf("url must use https")
+		}
+	default:
+		return fmt.Errorf("url must use https")
+	}
+
+	for _, t := range req.EventTypes {
+		if !slices.Contains(webhook.EventTypes, t) {
+			return fmt.Errorf("unsupported event type %q", t)
+		}
+	}
+
+	if len(req.AccountIds) == 0 {
+		return fmt.Errorf("at least one account_id is required")
+	}
+	for _, id := range req.AccountIds {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("account_id %q must be a valid UUID", id)
+		}
+	}
+
+	return nil
+}
+
+func toProtoWebhook(e webhook.Endpoint) *pb.Webhook {
+	return &pb.Webhook{
+		WebhookId:  e.ID,
+		ClientId:   e.ClientID,
+		Url:        e.URL,
+		EventTypes: e.EventTypes,
+		AccountIds: e.AccountIDs,
+		Active:     e.Active,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toProtoDelivery(d webhook.Delivery) *pb.WebhookDelivery {
+	var s pb.WebhookDeliveryStatus
+	switch d.Status {
+	case webhook.DeliveryPending:
+		s = pb.WebhookDeliveryStatus_DELIVERY_PENDING
+	case webhook.DeliverySucceeded:
+		s = pb.WebhookDeliveryStatus_DELIVERY_SUCCEEDED
+	case webhook.DeliveryFailed:
+		s = pb.WebhookDeliveryStatus_DELIVERY_FAILED
+	}
+
+	resp := &pb.WebhookDelivery{
+		DeliveryId:     d.ID,
+		WebhookId:      d.EndpointID,
+		EventId:        d.EventID,
+		EventType:      d.EventType,
+		PaymentId:      d.PaymentID,
+		Status:         s,
+		Attempts:       int32(d.Attempts),
+		LastStatusCode: int32(d.LastStatusCode),
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == webhook.DeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"securepay/payment-service/models"
+	"securepay/pkg/metrics"
+)
+
+// ResultConsumer reads payment results published by account-service
+type ResultConsumer struct {
+	reader *kafka.Reader
+}
+
+// NewResultConsumer creates a new consumer for the payment results topic
+func NewResultConsumer(brokers []string, topic string) *ResultConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  brokers,
+		Topic:    topic,
+		GroupID:  "payment-service-group", // Convention
+		MinBytes: 10e3,                    // 10KB
+		MaxBytes: 10e6,                    // 10MB
+	})
+
+	return &ResultConsumer{reader: reader}
+}
+
+// Start consumes result events in a goroutine and passes each one to handle.
+// Messages are committed after handle returns, whether or not it failed.
+func (c *ResultConsumer) Start(ctx context.Context, handle func(context.Context, models.PaymentResultEvent) error) {
+	slog.InfoContext(ctx, "Starting Kafka Consumer", "topic", c.reader.Config().Topic)
+	go func() {
+		for {
+			m, err := c.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return // Context closed
+				}
+				slog.ErrorContext(ctx, "Failed to fetch message", "error", err)
+				time.Sleep(time.Second) // Backoff
+				continue
+			}
+
+			// Extract Trace Context from Kafka headers
+			carrier := propagation.MapCarrier{}
+			for _, h := range m.Headers {
+				carrier[h.Key] = string(h.Value)
+			}
+			msgCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
+			msgCtx, span := otel.Tracer("payment-service").Start(msgCtx, "kafka.ConsumePaymentResultEvent")
+
+			var event models.PaymentResultEvent
+			err = json.Unmarshal(m.Value, &event)
+			if err != nil {
+				slog.ErrorContext(msgCtx, "Failed to unmarshal result event", "error", err)
+				span.RecordError(err)
+			} else if err = handle(msgCtx, event); err != nil {
+				slog.ErrorContext(msgCtx, "Failed to handle result event", "error", err, "payment_id", event.PaymentID)
+				span.RecordError(err)
+			}
+			metrics.ObserveKafkaConsume(c.reader, m, err)
+
+			if err := c.reader.CommitMessages(msgCtx, m); err != nil {
+				slog.ErrorContext(msgCtx, "Failed to commit message", "error", err)
+				span.RecordError(err)
+			}
+			span.End()
+		}
+	}()
+}
+
+// Close closes the consumer
+func (c *ResultConsumer) Close() error {
+	return c.reader.Close()
+}
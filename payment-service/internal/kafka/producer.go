@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"securepay/payment-service/models"
+	"securepay/pkg/metrics"
 )
 
 // Producer wrapper
@@ -36,36 +38,58 @@ func (kp *Producer) Close() error {
 	return kp.writer.Close()
 }
 
-// ProducePaymentInitiatedEvent sends the event to Kafka
-func (kp *Producer) ProducePaymentInitiatedEvent(ctx context.Context, event models.PaymentInitiatedEvent) error {
-	ctx, span := otel.Tracer("payment-service").Start(ctx, "kafka.ProducePaymentInitiatedEvent")
+// PublishOutbox sends outbox messages to Kafka in a single WriteMessages
+// call, so that large batches are flushed together instead of waiting for
+// one write round-trip per payment.
+func (kp *Producer) PublishOutbox(ctx context.Context, outbox []models.OutboxMessage) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "kafka.PublishOutbox")
 	defer span.End()
 
+	msgs := make([]kafka.Message, 0, len(outbox))
+	for _, m := range outbox {
+		msgs = append(msgs, toKafkaMessage(m))
+	}
+
+	start := time.Now()
+	err := kp.writer.WriteMessages(ctx, msgs...)
+	metrics.ObserveKafkaProduce(kp.writer.Topic, start, err)
+	if err != nil {
+		return fmt.Errorf("failed to write messages to kafka: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Produced events to Kafka", "topic", kp.writer.Topic, "count", len(msgs))
+	return nil
+}
+
+// NewOutboxMessage marshals the event for payments.outbox and captures the
+// trace context of ctx, so that downstream consumers continue the trace of
+// the request rather than that of the relay publishing it.
+func NewOutboxMessage(ctx context.Context, event models.PaymentInitiatedEvent) (models.OutboxMessage, error) {
 	payload, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return models.OutboxMessage{}, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
 	// Inject Trace Context into Kafka headers for downstream consumers to extract
 	carrier := propagation.MapCarrier{}
 	otel.GetTextMapPropagator().Inject(ctx, carrier)
 
-	headers := make([]kafka.Header, 0, len(carrier))
-	for k, v := range carrier {
+	return models.OutboxMessage{
+		Key:     event.PaymentID, // Use PaymentID as key for ordering guarantees
+		Payload: payload,
+		Headers: carrier,
+	}, nil
+}
+
+func toKafkaMessage(m models.OutboxMessage) kafka.Message {
+	headers := make([]kafka.Header, 0, len(m.Headers))
+	for k, v := range m.Headers {
 		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
 	}
 
-	msg := kafka.Message{
-		Key:     []byte(event.PaymentID), // Use PaymentID as key for ordering guarantees
-		Value:   payload,
+	return kafka.Message{
+		Key:     []byte(m.Key),
+		Value:   m.Payload,
 		Headers: headers,
 	}
-
-	// WriteMessages blocks until the message is sent
-	if err := kp.writer.WriteMessages(ctx, msg); err != nil {
-		return fmt.Errorf("failed to write message to kafka: %w", err)
-	}
-
-	slog.InfoContext(ctx, "Produced event to Kafka", "topic", kp.writer.Topic, "payment_id", event.PaymentID)
-	return nil
 }
@@ -0,0 +1,88 @@
+// Package outbox publishes the events stored in payments.outbox.
+//
+// Events are written by the transaction that persists their payments, so a
+// crash or Kafka outage between saving a payment and announcing it delays
+// the event instead of losing it. A Relay polls the table and hands pending
+// messages to Kafka.
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"securepay/payment-service/models"
+)
+
+// Store claims and removes pending outbox messages.
+type Store interface {
+	// RelayOutbox locks up to limit pending messages, passes them to publish
+	// and deletes them once publish succeeds, returning how many were sent.
+	RelayOutbox(ctx context.Context, limit int, publish func(context.Context, []models.OutboxMessage) error) (int, error)
+}
+
+// Publisher sends outbox messages to Kafka.
+type Publisher interface {
+	PublishOutbox(ctx context.Context, msgs []models.OutboxMessage) error
+}
+
+// Relay moves outbox messages to Kafka. The exported fields may be adjusted
+// before Run is called.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	wake      chan struct{}
+
+	// PollInterval is how often the outbox is scanned when not notified,
+	// and the delay before retrying after a failed publish.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of messages published at once.
+	BatchSize int
+}
+
+// NewRelay creates a Relay.
+func NewRelay(store Store, publisher Publisher) *Relay {
+	return &Relay{
+		store:        store,
+		publisher:    publisher,
+		wake:         make(chan struct{}, 1),
+		PollInterval: time.Second,
+		BatchSize:    500,
+	}
+}
+
+// Notify wakes the relay after new messages were committed.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes pending messages until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Starting outbox relay", "poll_interval", r.PollInterval)
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+
+		// Drain everything that is pending before waiting again.
+		for ctx.Err() == nil {
+			n, err := r.store.RelayOutbox(ctx, r.BatchSize, r.publisher.PublishOutbox)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to relay outbox", "error", err)
+				break
+			}
+			if n < r.BatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package pubsub broadcasts payment status changes to every payment-service
+// replica, so a WatchPayment stream sees transitions that were consumed from
+// Kafka by a different replica.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+
+	"securepay/payment-service/models"
+)
+
+// Broker publishes and subscribes to status changes of individual payments.
+type Broker interface {
+	Publish(ctx context.Context, event models.PaymentResultEvent) error
+	// Subscribe returns a channel of status changes for the payment. The
+	// subscription is active when Subscribe returns and ends when ctx is
+	// cancelled, after which the channel is closed.
+	Subscribe(ctx context.Context, paymentID string) (<-chan models.PaymentResultEvent, error)
+}
+
+type redisBroker struct {
+	client *redis.Client
+}
+
+// NewRedisBroker creates a Broker backed by Redis pub/sub.
+func NewRedisBroker(addr, password string) Broker {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       0, // default DB
+	})
+
+	return &redisBroker{
+		client: client,
+	}
+}
+
+func channelName(paymentID string) string {
+	return "payment-status:" + paymentID
+}
+
+func (b *redisBroker) Publish(ctx context.Context, event models.PaymentResultEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status event: %w", err)
+	}
+	if err := b.client.Publish(ctx, channelName(event.PaymentID), payload).Err(); err != nil {
+		return fmt.Errorf("redis publish error: %w", err)
+	}
+	return nil
+}
+
+func (b *redisBroker) Subscribe(ctx context.Context, paymentID string) (<-chan models.PaymentResultEvent, error) {
+	sub := b.client.Subscribe(ctx, channelName(paymentID))
+
+	// Wait for the subscription confirmation so that no publish issued after
+	// Subscribe returns can be missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("redis subscribe error: %w", err)
+	}
+
+	events := make(chan models.PaymentResultEvent)
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event models.PaymentResultEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					slog.WarnContext(ctx, "Failed to unmarshal status event", "error", err, "channel", msg.Channel)
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"securepay/payment-service/models"
+)
+
+// insertOutbox stores events in payments.outbox inside tx.
+func insertOutbox(ctx context.Context, tx *sql.Tx, events []models.OutboxMessage) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO payments.outbox (message_key, payload, headers, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare outbox insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		headers, err := json.Marshal(e.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox headers: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, e.Key, string(e.Payload), string(headers)); err != nil {
+			return fmt.Errorf("failed to insert outbox message %s: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// RelayOutbox locks up to limit of the oldest outbox messages with FOR UPDATE
+// SKIP LOCKED, passes them to publish and deletes them in the same
+// transaction once publish succeeds. A failed publish leaves them for the
+// next call; a failed commit after publishing sends them again, so delivery
+// is at least once. It returns the number of messages published.
+func (r *PostgresRepository) RelayOutbox(ctx context.Context, limit int, publish func(context.Context, []models.OutboxMessage) error) (n int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("failed to commit outbox: %w", commitErr)
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, message_key, payload, headers
+		FROM payments.outbox
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []models.OutboxMessage
+	var ids []int64
+	for rows.Next() {
+		var m models.OutboxMessage
+		var payload string
+		var headers []byte
+		if err = rows.Scan(&m.ID, &m.Key, &payload, &headers); err != nil {
+			return 0, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		if err = json.Unmarshal(headers, &m.Headers); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal outbox headers: %w", err)
+		}
+		m.Payload = []byte(payload)
+		msgs = append(msgs, m)
+		ids = append(ids, m.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate outbox messages: %w", err)
+	}
+	rows.Close()
+
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	if err = publish(ctx, msgs); err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM payments.outbox WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to delete outbox messages: %w", err)
+	}
+
+	return len(msgs), nil
+}
@@ -5,19 +5,37 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
-	_ "github.com/lib/pq"
-	
 	"go.opentelemetry.io/otel"
 	"securepay/payment-service/models"
-	pb "securepay/proto/gen/go/payment/v1"
 )
 
 // Repository defines the interface for database operations
 type Repository interface {
-	SavePayment(ctx context.Context, req *pb.InitiatePaymentRequest) error
+	SavePayment(ctx context.Context, p *models.Payment, events []models.OutboxMessage) error
 	GetPayment(ctx context.Context, paymentId string) (*models.Payment, error)
+	FindPaymentByIdempotencyKey(ctx context.Context, initiatedBy, fromAccount, idempotencyKey string) (*models.Payment, error)
+	ListPayments(ctx context.Context, accountID string, after *Cursor, limit int) ([]models.Payment, error)
 	UpdatePaymentStatus(ctx context.Context, paymentId string, status models.PaymentStatus) error
+	FindDuplicatePayments(ctx context.Context, initiatedBy string, paymentIDs []string, keys []PaymentKey) (map[string]bool, map[PaymentKey]bool, error)
+	SaveBatch(ctx context.Context, batch *models.Batch, items []models.BatchItem, payments []models.Payment, events []models.OutboxMessage) error
+	GetBatch(ctx context.Context, batchID string) (*models.Batch, []models.BatchItem, error)
+	FindBatchByIdempotencyKey(ctx context.Context, initiatedBy, idempotencyKey string) (*models.Batch, []models.BatchItem, error)
+	SaveSplitPayment(ctx context.Context, p *models.Payment, events []models.OutboxMessage) error
+}
+
+// ErrIdempotencyConflict is returned when a record with the same idempotency
+// key was saved concurrently.
+var ErrIdempotencyConflict = errors.New("idempotency key already used")
+
+// Cursor is the position of the last payment of a page returned by
+// ListPayments. The next page starts after it.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
 }
 
 // PostgresRepository implements Repository
@@ -30,33 +48,57 @@ func NewPostgresRepository(db *sql.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// SavePayment saves a new payment to the database
-func (r *PostgresRepository) SavePayment(ctx context.Context, req *pb.InitiatePaymentRequest) error {
+// SavePayment saves a new PENDING payment and its events in payments.outbox
+// in a single transaction and sets its CreatedAt and UpdatedAt. It returns
+// ErrIdempotencyConflict when the initiator already saved a payment from
+// the same account with the same idempotency key.
+func (r *PostgresRepository) SavePayment(ctx context.Context, p *models.Payment, events []models.OutboxMessage) (err error) {
 	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.SavePayment")
 	defer span.End()
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("failed to commit payment: %w", commitErr)
+		}
+	}()
+
 	query := `
 		INSERT INTO payments.transactions (
-			id, from_account, to_account, amount, currency, status, idempotency_key, created_at, updated_at, version
+			id, from_account, to_account, amount, fee, currency, status, idempotency_key, initiated_by, created_at, updated_at, version
 		) VALUES (
-			$1, $2, $3, $4, $5, 'PENDING', $6, NOW(), NOW(), 1
+			$1, $2, $3, $4, $5, $6, 'PENDING', $7, $8, NOW(), NOW(), 1
 		)
+		RETURNING created_at, updated_at
 	`
-	
-	_, err := r.db.ExecContext(ctx, query,
-		req.PaymentId,
-		req.FromAccount,
-		req.ToAccount,
-		req.Amount,
-		req.Currency,
-		req.IdempotencyKey,
-	)
+
+	err = tx.QueryRowContext(ctx, query,
+		p.ID,
+		p.FromAccount,
+		p.ToAccount,
+		p.Amount,
+		p.Fee,
+		p.Currency,
+		p.IdempotencyKey,
+		p.InitiatedBy,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
 
 	if err != nil {
+		if isUniqueViolation(err, "transactions_idempotency_scope_key") {
+			return fmt.Errorf("failed to insert payment: %w", ErrIdempotencyConflict)
+		}
 		return fmt.Errorf("failed to insert payment: %w", err)
 	}
 
-	return nil
+	return insertOutbox(ctx, tx, events)
 }
 
 // GetPayment fetches a payment by ID
@@ -65,20 +107,22 @@ func (r *PostgresRepository) GetPayment(ctx context.Context, paymentId string) (
 	defer span.End()
 
 	query := `
-		SELECT id, from_account, to_account, amount, currency, status, idempotency_key, created_at, updated_at, version
+		SELECT id, from_account, COALESCE(to_account::text, ''), amount, fee, currency, status, idempotency_key, initiated_by, created_at, updated_at, version
 		FROM payments.transactions
 		WHERE id = $1
 	`
-	
+
 	var p models.Payment
 	err := r.db.QueryRowContext(ctx, query, paymentId).Scan(
 		&p.ID,
 		&p.FromAccount,
 		&p.ToAccount,
 		&p.Amount,
+		&p.Fee,
 		&p.Currency,
 		&p.Status,
 		&p.IdempotencyKey,
+		&p.InitiatedBy,
 		&p.CreatedAt,
 		&p.UpdatedAt,
 		&p.Version,
@@ -91,29 +135,421 @@ func (r *PostgresRepository) GetPayment(ctx context.Context, paymentId string) (
 		return nil, fmt.Errorf("failed to get payment: %w", err)
 	}
 
+	legs, err := r.getSplitLegs(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	p.Legs = legs
+
 	return &p, nil
 }
 
-// UpdatePaymentStatus updates the status of a payment
+// FindPaymentByIdempotencyKey returns the payment initiatedBy saved from
+// fromAccount with idempotencyKey, or nil when there is none. Keys are
+// chosen by clients, so they are only unique within that scope.
+func (r *PostgresRepository) FindPaymentByIdempotencyKey(ctx context.Context, initiatedBy, fromAccount, idempotencyKey string) (*models.Payment, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.FindPaymentByIdempotencyKey")
+	defer span.End()
+
+	var paymentID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id FROM payments.transactions
+		WHERE initiated_by = $1 AND from_account = $2 AND idempotency_key = $3
+	`, initiatedBy, fromAccount, idempotencyKey).Scan(&paymentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find payment: %w", err)
+	}
+
+	return r.GetPayment(ctx, paymentID)
+}
+
+// ListPayments returns up to limit payments from or to accountID, including
+// split payments with a leg to it, newest first. With a non-nil after, the
+// page starts after that payment.
+func (r *PostgresRepository) ListPayments(ctx context.Context, accountID string, after *Cursor, limit int) ([]models.Payment, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.ListPayments")
+	defer span.End()
+
+	query := `
+		SELECT t.id, t.from_account, COALESCE(t.to_account::text, ''), t.amount, t.fee, t.currency, t.status, t.idempotency_key, t.initiated_by, t.created_at, t.updated_at, t.version
+		FROM payments.transactions t
+		WHERE (t.from_account = $1 OR t.to_account = $1 OR EXISTS (
+			SELECT 1 FROM payments.split_legs l WHERE l.payment_id = t.id AND l.to_account = $1
+		))
+	`
+	args := []any{accountID, limit}
+	if after != nil {
+		query += " AND (t.created_at, t.id) < ($3, $4)"
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += " ORDER BY t.created_at DESC, t.id DESC LIMIT $2"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.FromAccount, &p.ToAccount, &p.Amount, &p.Fee, &p.Currency, &p.Status,
+			&p.IdempotencyKey, &p.InitiatedBy, &p.CreatedAt, &p.UpdatedAt, &p.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate payments: %w", err)
+	}
+
+	for i := range payments {
+		if payments[i].ToAccount != "" {
+			continue
+		}
+		if payments[i].Legs, err = r.getSplitLegs(ctx, payments[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return payments, nil
+}
+
+// getSplitLegs fetches the legs of a split payment in order.
+// It returns an empty slice for ordinary payments.
+func (r *PostgresRepository) getSplitLegs(ctx context.Context, paymentID string) ([]models.SplitLeg, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT to_account, amount
+		FROM payments.split_legs
+		WHERE payment_id = $1
+		ORDER BY leg_no
+	`, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get split legs: %w", err)
+	}
+	defer rows.Close()
+
+	var legs []models.SplitLeg
+	for rows.Next() {
+		var leg models.SplitLeg
+		if err := rows.Scan(&leg.ToAccount, &leg.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan split leg: %w", err)
+		}
+		legs = append(legs, leg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate split legs: %w", err)
+	}
+
+	return legs, nil
+}
+
+// ErrStatusUnchanged is returned by UpdatePaymentStatus when the payment
+// already has the requested status (e.g. a redelivered result event).
+var ErrStatusUnchanged = errors.New("payment status unchanged")
+
+// UpdatePaymentStatus moves a payment to status if the transition is valid.
+// The version column guards against concurrent updates.
 func (r *PostgresRepository) UpdatePaymentStatus(ctx context.Context, paymentID string, status models.PaymentStatus) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.UpdatePaymentStatus")
+	defer span.End()
+
+	var current string
+	var version int
+	err := r.db.QueryRowContext(ctx, "SELECT status, version FROM payments.transactions WHERE id = $1", paymentID).Scan(&current, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("payment not found")
+		}
+		return fmt.Errorf("failed to get payment status: %w", err)
+	}
+
+	if models.PaymentStatus(current) == status {
+		return ErrStatusUnchanged
+	}
+	if err := models.IsValidTransition(models.PaymentStatus(current), status); err != nil {
+		return err
+	}
+
 	query := `
-		UPDATE payments.transactions 
-		SET status = $1, updated_at = NOW(), version = version + 1 
-		WHERE id = $2
+		UPDATE payments.transactions
+		SET status = $1, updated_at = NOW(), version = version + 1
+		WHERE id = $2 AND version = $3
 	`
-	
-	result, err := r.db.ExecContext(ctx, query, string(status), paymentID)
+
+	result, err := r.db.ExecContext(ctx, query, string(status), paymentID, version)
 	if err != nil {
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
-	
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return errors.New("payment not found")
+		return errors.New("payment was modified concurrently")
+	}
+
+	return nil
+}
+
+// PaymentKey is an idempotency key together with the paying account; an
+// initiator's keys are unique within that scope.
+type PaymentKey struct {
+	FromAccount    string
+	IdempotencyKey string
+}
+
+// FindDuplicatePayments returns which of the given payment IDs already exist
+// and which of keys initiatedBy already used.
+func (r *PostgresRepository) FindDuplicatePayments(ctx context.Context, initiatedBy string, paymentIDs []string, keys []PaymentKey) (map[string]bool, map[PaymentKey]bool, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.FindDuplicatePayments")
+	defer span.End()
+
+	fromAccounts := make([]string, len(keys))
+	idempotencyKeys := make([]string, len(keys))
+	for i, k := range keys {
+		fromAccounts[i] = k.FromAccount
+		idempotencyKeys[i] = k.IdempotencyKey
+	}
+
+	query := `
+		SELECT id::text, from_account::text, idempotency_key, initiated_by
+		FROM payments.transactions
+		WHERE id::text = ANY($1)
+		   OR (initiated_by = $2 AND (from_account::text, idempotency_key) IN (
+				SELECT * FROM unnest($3::text[], $4::text[])
+		   ))
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(paymentIDs), initiatedBy, pq.Array(fromAccounts), pq.Array(idempotencyKeys))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query duplicate payments: %w", err)
+	}
+	defer rows.Close()
+
+	existingIDs := make(map[string]bool)
+	existingKeys := make(map[PaymentKey]bool)
+	for rows.Next() {
+		var id, by string
+		var key PaymentKey
+		if err := rows.Scan(&id, &key.FromAccount, &key.IdempotencyKey, &by); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan duplicate payment: %w", err)
+		}
+		existingIDs[id] = true
+		if by == initiatedBy {
+			existingKeys[key] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate duplicate payments: %w", err)
+	}
+
+	return existingIDs, existingKeys, nil
+}
+
+// SaveBatch persists a batch record, its per-line items, the accepted
+// payments and their events in payments.outbox in a single transaction. It
+// returns ErrIdempotencyConflict when the initiator already saved a batch
+// with the same idempotency key.
+func (r *PostgresRepository) SaveBatch(ctx context.Context, batch *models.Batch, items []models.BatchItem, payments []models.Payment, events []models.OutboxMessage) (err error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.SaveBatch")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("failed to commit batch: %w", commitErr)
+		}
+	}()
+
+	// 1. Batch record
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO payments.batches (id, idempotency_key, total_items, initiated_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, batch.ID, batch.IdempotencyKey, batch.TotalItems, batch.InitiatedBy)
+	if err != nil {
+		if isUniqueViolation(err, "batches_idempotency_scope_key") {
+			return fmt.Errorf("failed to insert batch: %w", ErrIdempotencyConflict)
+		}
+		return fmt.Errorf("failed to insert batch: %w", err)
+	}
+
+	// 2. Accepted payments
+	paymentStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO payments.transactions (
+			id, from_account, to_account, amount, fee, currency, status, idempotency_key, initiated_by, created_at, updated_at, version
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, 'PENDING', $7, $8, NOW(), NOW(), 1
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare payment insert: %w", err)
+	}
+	defer paymentStmt.Close()
+
+	for _, p := range payments {
+		if _, err = paymentStmt.ExecContext(ctx, p.ID, p.FromAccount, p.ToAccount, p.Amount, p.Fee, p.Currency, p.IdempotencyKey, p.InitiatedBy); err != nil {
+			return fmt.Errorf("failed to insert payment %s: %w", p.ID, err)
+		}
+	}
+
+	// 3. Per-line items
+	itemStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO payments.batch_items (batch_id, line_no, payment_id, status, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch item insert: %w", err)
+	}
+	defer itemStmt.Close()
+
+	for _, item := range items {
+		if _, err = itemStmt.ExecContext(ctx, batch.ID, item.Line, item.PaymentID, item.Status, item.Error); err != nil {
+			return fmt.Errorf("failed to insert batch item %d: %w", item.Line, err)
+		}
+	}
+
+	// 4. Events, published by the outbox relay once committed
+	if err = insertOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// FindBatchByIdempotencyKey returns the batch initiatedBy saved with
+// idempotencyKey and its items, or a nil batch when there is none.
+func (r *PostgresRepository) FindBatchByIdempotencyKey(ctx context.Context, initiatedBy, idempotencyKey string) (*models.Batch, []models.BatchItem, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.FindBatchByIdempotencyKey")
+	defer span.End()
+
+	var batchID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id FROM payments.batches WHERE initiated_by = $1 AND idempotency_key = $2
+	`, initiatedBy, idempotencyKey).Scan(&batchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to find batch: %w", err)
+	}
+
+	return r.GetBatch(ctx, batchID)
+}
+
+// GetBatch fetches a batch and its items. The status of accepted items is
+// read from the underlying payment so that it reflects later transitions.
+func (r *PostgresRepository) GetBatch(ctx context.Context, batchID string) (*models.Batch, []models.BatchItem, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.GetBatch")
+	defer span.End()
+
+	var b models.Batch
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, idempotency_key, total_items, initiated_by, created_at
+		FROM payments.batches
+		WHERE id = $1
+	`, batchID).Scan(&b.ID, &b.IdempotencyKey, &b.TotalItems, &b.InitiatedBy, &b.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("batch not found: %w", err)
+		}
+		return nil, nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT bi.line_no, bi.payment_id, COALESCE(t.status, bi.status), bi.error
+		FROM payments.batch_items bi
+		LEFT JOIN payments.transactions t
+			ON bi.error = '' AND t.id::text = bi.payment_id
+		WHERE bi.batch_id = $1
+		ORDER BY bi.line_no
+	`, batchID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get batch items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.BatchItem, 0, b.TotalItems)
+	for rows.Next() {
+		item := models.BatchItem{BatchID: b.ID}
+		if err := rows.Scan(&item.Line, &item.PaymentID, &item.Status, &item.Error); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate batch items: %w", err)
+	}
+
+	return &b, items, nil
+}
+
+// SaveSplitPayment saves a PENDING split payment and its legs in a single
+// transaction and sets its CreatedAt and UpdatedAt. The parent row carries
+// the total amount and no to_account. Like SavePayment, it stores events in
+// payments.outbox in the same transaction and returns ErrIdempotencyConflict
+// for a used idempotency key.
+func (r *PostgresRepository) SaveSplitPayment(ctx context.Context, p *models.Payment, events []models.OutboxMessage) (err error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.SaveSplitPayment")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("failed to commit split payment: %w", commitErr)
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments.transactions (
+			id, from_account, to_account, amount, fee, currency, status, idempotency_key, initiated_by, created_at, updated_at, version
+		) VALUES (
+			$1, $2, NULL, $3, $4, $5, 'PENDING', $6, $7, NOW(), NOW(), 1
+		)
+		RETURNING created_at, updated_at
+	`, p.ID, p.FromAccount, p.Amount, p.Fee, p.Currency, p.IdempotencyKey, p.InitiatedBy).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err, "transactions_idempotency_scope_key") {
+			return fmt.Errorf("failed to insert payment: %w", ErrIdempotencyConflict)
+		}
+		return fmt.Errorf("failed to insert payment: %w", err)
+	}
+
+	for i, leg := range p.Legs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO payments.split_legs (payment_id, leg_no, to_account, amount)
+			VALUES ($1, $2, $3, $4)
+		`, p.ID, i+1, leg.ToAccount, leg.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to insert split leg %d: %w", i+1, err)
+		}
+	}
+
+	return insertOutbox(ctx, tx, events)
+}
+
+// isUniqueViolation reports whether err violates the unique constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
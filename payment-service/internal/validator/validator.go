@@ -3,10 +3,13 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	pb "securepay/proto/gen/go/payment/v1"
+	pbv2 "securepay/proto/gen/go/payment/v2"
 )
 
 // Validator handles request validation logic
@@ -20,6 +23,18 @@ func New() *Validator {
 // UUID regex pattern
 var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 
+// validCurrencies are the supported currency codes
+var validCurrencies = map[string]bool{
+	"TRY": true,
+	"USD": true,
+	"EUR": true,
+}
+
+// Currencies returns the supported currency codes, sorted.
+func Currencies() []string {
+	return slices.Sorted(maps.Keys(validCurrencies))
+}
+
 // ValidateInitiatePayment validates the InitiatePaymentRequest
 func (v *Validator) ValidateInitiatePayment(req *pb.InitiatePaymentRequest) error {
 	if req == nil {
@@ -32,11 +47,6 @@ func (v *Validator) ValidateInitiatePayment(req *pb.InitiatePaymentRequest) erro
 	}
 
 	// 2. Currency valid (TRY, USD, EUR)
-	validCurrencies := map[string]bool{
-		"TRY": true,
-		"USD": true,
-		"EUR": true,
-	}
 	if !validCurrencies[strings.ToUpper(req.Currency)] {
 		return fmt.Errorf("invalid currency: %s (supported: TRY, USD, EUR)", req.Currency)
 	}
@@ -56,3 +66,194 @@ func (v *Validator) ValidateInitiatePayment(req *pb.InitiatePaymentRequest) erro
 
 	return nil
 }
+
+// MaxBatchSize is the maximum number of payments accepted in a single batch.
+const MaxBatchSize = 5000
+
+// ValidateInitiateBatchPayment validates the batch envelope of an InitiateBatchPaymentRequest.
+// Individual lines are validated separately with ValidateInitiatePayment so that
+// a single bad line does not reject the whole batch.
+func (v *Validator) ValidateInitiateBatchPayment(req *pb.InitiateBatchPaymentRequest) error {
+	if req == nil {
+		return errors.New("request cannot be nil")
+	}
+
+	// 1. batch_id in UUID format (optional, generated server-side when empty)
+	if req.BatchId != "" && !uuidRegex.MatchString(req.BatchId) {
+		return fmt.Errorf("invalid batch_id format: %s", req.BatchId)
+	}
+
+	// 2. idempotency_key is mandatory for batches
+	if req.IdempotencyKey == "" {
+		return errors.New("idempotency_key is required")
+	}
+
+	// 3. 1 <= len(payments) <= MaxBatchSize
+	if len(req.Payments) == 0 {
+		return errors.New("batch must contain at least one payment")
+	}
+	if len(req.Payments) > MaxBatchSize {
+		return fmt.Errorf("batch contains %d payments (max %d)", len(req.Payments), MaxBatchSize)
+	}
+
+	return nil
+}
+
+// ValidateBatchLine validates a single line of a batch. In addition to the
+// InitiatePayment rules it requires payment_id to be a UUID, since batch lines
+// are persisted without further checks.
+func (v *Validator) ValidateBatchLine(req *pb.InitiatePaymentRequest) error {
+	if err := v.ValidateInitiatePayment(req); err != nil {
+		return err
+	}
+	if !uuidRegex.MatchString(req.PaymentId) {
+		return fmt.Errorf("invalid payment_id format: %s", req.PaymentId)
+	}
+	return nil
+}
+
+// MaxSplitLegs is the maximum number of recipients of a split payment.
+const MaxSplitLegs = 100
+
+// ValidateInitiateSplitPayment validates the InitiateSplitPaymentRequest
+func (v *Validator) ValidateInitiateSplitPayment(req *pb.InitiateSplitPaymentRequest) error {
+	if req == nil {
+		return errors.New("request cannot be nil")
+	}
+
+	// 1. payment_id and from_account in UUID format
+	if !uuidRegex.MatchString(req.PaymentId) {
+		return fmt.Errorf("invalid payment_id format: %s", req.PaymentId)
+	}
+	if !uuidRegex.MatchString(req.FromAccount) {
+		return fmt.Errorf("invalid from_account format: %s", req.FromAccount)
+	}
+
+	// 2. Currency valid (TRY, USD, EUR)
+	if !validCurrencies[strings.ToUpper(req.Currency)] {
+		return fmt.Errorf("invalid currency: %s (supported: TRY, USD, EUR)", req.Currency)
+	}
+
+	// 3. 1 <= len(legs) <= MaxSplitLegs
+	if len(req.Legs) == 0 {
+		return errors.New("split payment must have at least one leg")
+	}
+	if len(req.Legs) > MaxSplitLegs {
+		return fmt.Errorf("split payment has %d legs (max %d)", len(req.Legs), MaxSplitLegs)
+	}
+
+	// 4. Every leg pays a distinct account other than the payer, with amount > 0
+	recipients := make(map[string]bool, len(req.Legs))
+	for i, leg := range req.Legs {
+		if leg.Amount <= 0 {
+			return fmt.Errorf("leg %d: amount must be greater than 0", i+1)
+		}
+		if !uuidRegex.MatchString(leg.ToAccount) {
+			return fmt.Errorf("leg %d: invalid to_account format: %s", i+1, leg.ToAccount)
+		}
+		if leg.ToAccount == req.FromAccount {
+			return fmt.Errorf("leg %d: to_account cannot be the same as from_account", i+1)
+		}
+		if recipients[leg.ToAccount] {
+			return fmt.Errorf("leg %d: duplicate to_account %s", i+1, leg.ToAccount)
+		}
+		recipients[leg.ToAccount] = true
+	}
+
+	return nil
+}
+
+// ValidateCreatePayment validates a v2 CreatePaymentRequest. Unlike v1, the
+// idempotency key is required, since the payment id is generated by the
+// server and a retry without the key would create a second payment.
+func (v *Validator) ValidateCreatePayment(req *pbv2.CreatePaymentRequest) error {
+	if req == nil {
+		return errors.New("request cannot be nil")
+	}
+
+	// 1. Amount > 0 in a supported currency
+	if err := validateMoney(req.Amount); err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+
+	// 2. from_account and to_account in UUID format and different
+	if !uuidRegex.MatchString(req.FromAccount) {
+		return fmt.Errorf("invalid from_account format: %s", req.FromAccount)
+	}
+	if !uuidRegex.MatchString(req.ToAccount) {
+		return fmt.Errorf("invalid to_account format: %s", req.ToAccount)
+	}
+	if req.FromAccount == req.ToAccount {
+		return errors.New("from_account and to_account cannot be the same")
+	}
+
+	// 3. idempotency_key is mandatory
+	if req.IdempotencyKey == "" {
+		return errors.New("idempotency_key is required")
+	}
+
+	return nil
+}
+
+// ValidateCreateSplitPayment validates a v2 CreateSplitPaymentRequest with
+// the rules of ValidateInitiateSplitPayment. All legs must be in the same
+// currency.
+func (v *Validator) ValidateCreateSplitPayment(req *pbv2.CreateSplitPaymentRequest) error {
+	if req == nil {
+		return errors.New("request cannot be nil")
+	}
+
+	// 1. from_account in UUID format, idempotency_key set
+	if !uuidRegex.MatchString(req.FromAccount) {
+		return fmt.Errorf("invalid from_account format: %s", req.FromAccount)
+	}
+	if req.IdempotencyKey == "" {
+		return errors.New("idempotency_key is required")
+	}
+
+	// 2. 1 <= len(legs) <= MaxSplitLegs
+	if len(req.Legs) == 0 {
+		return errors.New("split payment must have at least one leg")
+	}
+	if len(req.Legs) > MaxSplitLegs {
+		return fmt.Errorf("split payment has %d legs (max %d)", len(req.Legs), MaxSplitLegs)
+	}
+
+	// 3. Every leg pays a distinct account other than the payer, with an
+	// amount > 0 in the currency of the first leg
+	recipients := make(map[string]bool, len(req.Legs))
+	for i, leg := range req.Legs {
+		if err := validateMoney(leg.Amount); err != nil {
+			return fmt.Errorf("leg %d: amount: %w", i+1, err)
+		}
+		if leg.Amount.Currency != req.Legs[0].Amount.Currency {
+			return fmt.Errorf("leg %d: currency %s differs from %s", i+1, leg.Amount.Currency, req.Legs[0].Amount.Currency)
+		}
+		if !uuidRegex.MatchString(leg.ToAccount) {
+			return fmt.Errorf("leg %d: invalid to_account format: %s", i+1, leg.ToAccount)
+		}
+		if leg.ToAccount == req.FromAccount {
+			return fmt.Errorf("leg %d: to_account cannot be the same as from_account", i+1)
+		}
+		if recipients[leg.ToAccount] {
+			return fmt.Errorf("leg %d: duplicate to_account %s", i+1, leg.ToAccount)
+		}
+		recipients[leg.ToAccount] = true
+	}
+
+	return nil
+}
+
+// validateMoney requires a positive amount in a supported currency.
+func validateMoney(m *pbv2.Money) error {
+	if m == nil {
+		return errors.New("is required")
+	}
+	if m.MinorUnits <= 0 {
+		return errors.New("must be greater than 0")
+	}
+	if !validCurrencies[m.Currency] {
+		return fmt.Errorf("invalid currency: %s (supported: TRY, USD, EUR)", m.Currency)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// Dispatcher publishes events into the delivery log and sends due deliveries.
+// The exported fields may be adjusted before Run is called.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	wake   chan struct{}
+
+	// MaxAttempts is the number of attempts before a delivery is FAILED.
+	MaxAttempts int
+	// BaseBackoff is the delay after the first failed attempt; it doubles
+	// with every further attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// PollInterval is how often the delivery log is scanned for due rows.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of deliveries sent concurrently.
+	BatchSize int
+}
+
+// NewDispatcher creates a Dispatcher. A nil client uses NewClient with a 10s
+// timeout and private addresses blocked.
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = NewClient(10*time.Second, false)
+	}
+	return &Dispatcher{
+		store:        store,
+		client:       client,
+		wake:         make(chan struct{}, 1),
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+		PollInterval: 2 * time.Second,
+		BatchSize:    20,
+	}
+}
+
+// Publish records an event for every endpoint subscribed to eventType that
+// watches one of accountIDs. Sending happens asynchronously in Run.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data PaymentData, accountIDs []string) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "webhook.Publish")
+	defer span.End()
+
+	event := Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	n, err := d.store.EnqueueEvent(ctx, event, payload, accountIDs)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "Webhook event enqueued", "event_id", event.ID, "type", eventType, "payment_id", data.PaymentID, "deliveries", n)
+		d.notify()
+	}
+	return nil
+}
+
+// Redeliver enqueues a copy of an earlier delivery for immediate sending.
+func (d *Dispatcher) Redeliver(ctx context.Context, clientID, deliveryID string) (*Delivery, error) {
+	delivery, err := d.store.Redeliver(ctx, clientID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	d.notify()
+	return delivery, nil
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Starting webhook dispatcher", "poll_interval", d.PollInterval)
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		// Drain everything that is due before waiting again.
+		for ctx.Err() == nil {
+			n, err := d.dispatchDue(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to dispatch webhooks", "error", err)
+				break
+			}
+			if n < d.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// dispatchDue claims and sends one batch, returning the number claimed.
+func (d *Dispatcher) dispatchDue(ctx context.Context) (int, error) {
+	// Lease long enough to cover the HTTP timeout, so a crashed replica's
+	// deliveries become due again instead of being lost.
+	lease := time.Now().Add(d.client.Timeout + time.Minute)
+	deliveries, err := d.store.ClaimDue(ctx, d.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery Delivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver performs a single attempt and records its outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "webhook.Deliver")
+	defer span.End()
+
+	logger := slog.With("delivery_id", delivery.ID, "webhook_id", delivery.EndpointID, "event_type", delivery.EventType)
+
+	if delivery.URL == "" {
+		if err := d.store.MarkFailed(ctx, delivery.ID, 0, "endpoint deleted"); err != nil {
+			logger.ErrorContext(ctx, "Failed to record webhook outcome", "error", err)
+		}
+		return
+	}
+
+	statusCode, sendErr := d.send(ctx, delivery)
+
+	var err error
+	attempt := delivery.Attempts + 1
+	switch {
+	case sendErr == nil:
+		logger.InfoContext(ctx, "Webhook delivered", "status_code", statusCode, "attempt", attempt)
+		err = d.store.MarkSucceeded(ctx, delivery.ID, statusCode)
+	case attempt >= d.MaxAttempts:
+		logger.WarnContext(ctx, "Webhook delivery failed permanently", "error", sendErr, "status_code", statusCode, "attempt", attempt)
+		err = d.store.MarkFailed(ctx, delivery.ID, statusCode, sendErr.Error())
+	default:
+		next := time.Now().Add(d.backoff(attempt))
+		logger.WarnContext(ctx, "Webhook delivery failed, retrying", "error", sendErr, "status_code", statusCode, "attempt", attempt, "next_attempt_at", next)
+		err = d.store.MarkRetry(ctx, delivery.ID, statusCode, sendErr.Error(), next)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record webhook outcome", "error", err)
+	}
+}
+
+// send POSTs the signed payload; any non-2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SecurePay-Webhooks/1.0")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), delivery.Payload))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the attempt following attempt, doubling
+// from BaseBackoff up to MaxBackoff with up to 50% random jitter removed.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempt && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
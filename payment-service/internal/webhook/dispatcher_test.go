@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore is an in-memory Store holding the deliveries the tests claim.
+type memoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+	retries    []time.Time
+}
+
+func newMemoryStore(deliveries ...Delivery) *memoryStore {
+	s := &memoryStore{deliveries: make(map[string]*Delivery)}
+	for i := range deliveries {
+		d := deliveries[i]
+		s.deliveries[d.ID] = &d
+	}
+	return s
+}
+
+func (s *memoryStore) CreateEndpoint(context.Context, *Endpoint) error { return nil }
+
+func (s *memoryStore) ListEndpoints(context.Context, string) ([]Endpoint, error) { return nil, nil }
+
+func (s *memoryStore) DeleteEndpoint(context.Context, string, string) error { return nil }
+
+func (s *memoryStore) EnqueueEvent(context.Context, Event, []byte, []string) (int, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) ClaimDue(_ context.Context, limit int, leaseUntil time.Time) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []Delivery
+	for _, d := range s.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == DeliveryPending && !d.NextAttemptAt.After(time.Now()) {
+			d.NextAttemptAt = leaseUntil
+			claimed = append(claimed, *d)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkSucceeded(_ context.Context, deliveryID string, statusCode int) error {
+	return s.update(deliveryID, DeliverySucceeded, statusCode, "", time.Now())
+}
+
+func (s *memoryStore) MarkRetry(_ context.Context, deliveryID string, statusCode int, lastErr string, next time.Time) error {
+	s.mu.Lock()
+	s.retries = append(s.retries, next)
+	s.mu.Unlock()
+	return s.update(deliveryID, DeliveryPending, statusCode, lastErr, next)
+}
+
+func (s *memoryStore) MarkFailed(_ context.Context, deliveryID string, statusCode int, lastErr string) error {
+	return s.update(deliveryID, DeliveryFailed, statusCode, lastErr, time.Now())
+}
+
+func (s *memoryStore) update(deliveryID, status string, statusCode int, lastErr string, next time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.deliveries[deliveryID]
+	d.Status = status
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = lastErr
+	d.NextAttemptAt = next
+	return nil
+}
+
+func (s *memoryStore) ListDeliveries(context.Context, string, string, int) ([]Delivery, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) Redeliver(context.Context, string, string) (*Delivery, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) get(deliveryID string) Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[deliveryID]
+}
+
+func TestDispatcherRetriesUntilFailed(t *testing.T) {
+	const secret = "whsec_test"
+
+	var mu sync.Mutex
+	var hits []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(SignatureHeader), body, DefaultTolerance, time.Now()); err != nil {
+			t.Errorf("delivery signature: %v", err)
+		}
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := newMemoryStore(Delivery{
+		ID:            "dlv_1",
+		EventType:     EventPaymentCompleted,
+		Payload:       []byte(`{"id":"evt_1"}`),
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now(),
+		URL:           srv.URL,
+		Secret:        secret,
+	})
+
+	// The test server listens on loopback, so private addresses are allowed.
+	d := NewDispatcher(store, NewClient(time.Second, true))
+	d.MaxAttempts = 4
+	d.BaseBackoff = 40 * time.Millisecond
+	d.MaxBackoff = time.Second
+	d.PollInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.get("dlv_1").Status != DeliveryFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery not FAILED in time: %+v", store.get("dlv_1"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	got := store.get("dlv_1")
+	if got.Attempts != d.MaxAttempts {
+		t.Errorf("attempts = %d, want %d", got.Attempts, d.MaxAttempts)
+	}
+	if got.LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("last status code = %d, want %d", got.LastStatusCode, http.StatusInternalServerError)
+	}
+	if len(store.retries) != d.MaxAttempts-1 {
+		t.Errorf("retries scheduled = %d, want %d", len(store.retries), d.MaxAttempts-1)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) != d.MaxAttempts {
+		t.Fatalf("requests = %d, want %d", len(hits), d.MaxAttempts)
+	}
+	// The delay doubles per attempt and jitter removes at most half of it.
+	delay := d.BaseBackoff
+	for i := 1; i < len(hits); i++ {
+		if gap := hits[i].Sub(hits[i-1]); gap < delay/2 {
+			t.Errorf("gap before attempt %d = %s, want at least %s", i+1, gap, delay/2)
+		}
+		delay *= 2
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(newMemoryStore(), nil)
+	d.BaseBackoff = time.Second
+	d.MaxBackoff = 4 * time.Second
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 4 * time.Second} {
+		for range 20 {
+			if got := d.backoff(attempt); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
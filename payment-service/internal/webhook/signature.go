@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery.
+const (
+	SignatureHeader = "X-SecurePay-Signature"
+	EventHeader     = "X-SecurePay-Event"
+	DeliveryHeader  = "X-SecurePay-Delivery"
+)
+
+// DefaultTolerance is the maximum accepted age of a signature timestamp.
+const DefaultTolerance = 5 * time.Minute
+
+// NewSecret generates a random signing secret for a new endpoint.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for payload sent at ts:
+//
+//	t=<unix seconds>,v1=<hex(HMAC-SHA256(secret, "<t>.<payload>"))>
+//
+// Binding the timestamp into the MAC lets receivers reject replays.
+func Sign(secret string, ts time.Time, payload []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + computeMAC(secret, t, payload)
+}
+
+// Verify checks a signature header produced by Sign against payload and
+// rejects timestamps older (or newer) than tolerance relative to now.
+func Verify(secret, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var t, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			sig = v
+		}
+	}
+	if t == "" || sig == "" {
+		return errors.New("malformed signature header")
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid signature timestamp: %w", err)
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+
+	expected := computeMAC(secret, t, payload)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func computeMAC(secret, t string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
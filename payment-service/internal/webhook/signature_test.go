@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"id":"evt_1","type":"payment.completed"}`)
+	now := time.Now()
+
+	header := Sign(secret, now, payload)
+	if !strings.HasPrefix(header, "t=") || !strings.Contains(header, ",v1=") {
+		t.Fatalf("unexpected header format %q", header)
+	}
+	if err := Verify(secret, header, payload, DefaultTolerance, now); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+	}{
+		{"too old", secret, Sign(secret, now.Add(-DefaultTolerance-time.Second), payload), payload},
+		{"too far in the future", secret, Sign(secret, now.Add(DefaultTolerance+time.Second), payload), payload},
+		{"tampered payload", secret, Sign(secret, now, payload), []byte(`{"id":"evt_2"}`)},
+		{"wrong secret", "whsec_other", Sign(secret, now, payload), payload},
+		{"malformed header", secret, "v1=deadbeef", payload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.header, tt.payload, DefaultTolerance, now); err == nil {
+				t.Fatal("Verify() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsWithinTolerance(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+
+	for _, skew := range []time.Duration{-DefaultTolerance + time.Second, DefaultTolerance - time.Second} {
+		if err := Verify(secret, Sign(secret, now.Add(skew), payload), payload, DefaultTolerance, now); err != nil {
+			t.Errorf("Verify() with skew %s = %v, want nil", skew, err)
+		}
+	}
+}
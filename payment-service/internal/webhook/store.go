@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+// ErrNotFound is returned when an endpoint or delivery does not exist or
+// belongs to another client.
+var ErrNotFound = errors.New("not found")
+
+// PostgresStore implements Store
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const deliveryColumns = `d.id, d.endpoint_id, d.event_id, d.event_type, d.payment_id, d.payload, d.status,
+	d.attempts, d.last_status_code, d.last_error, d.next_attempt_at, d.created_at, d.updated_at`
+
+// CreateEndpoint inserts a new active endpoint; ID and CreatedAt are set by the database.
+func (s *PostgresStore) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.CreateWebhookEndpoint")
+	defer span.End()
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO payments.webhook_endpoints (id, client_id, url, secret, event_types, account_ids, active, created_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, TRUE, NOW())
+		RETURNING id, active, created_at
+	`, e.ClientID, e.URL, e.Secret, pq.Array(e.EventTypes), pq.Array(e.AccountIDs)).Scan(&e.ID, &e.Active, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// ListEndpoints returns the active endpoints of a client.
+func (s *PostgresStore) ListEndpoints(ctx context.Context, clientID string) ([]Endpoint, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.ListWebhookEndpoints")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, client_id, url, event_types, account_ids, active, created_at
+		FROM payments.webhook_endpoints
+		WHERE client_id = $1 AND active
+		ORDER BY created_at
+	`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []Endpoint
+	for rows.Next() {
+		var e Endpoint
+		if err := rows.Scan(&e.ID, &e.ClientID, &e.URL, pq.Array(&e.EventTypes), pq.Array(&e.AccountIDs), &e.Active, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint deactivates an endpoint. The row is kept so that its
+// delivery log stays queryable.
+func (s *PostgresStore) DeleteEndpoint(ctx context.Context, clientID, endpointID string) error {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.DeleteWebhookEndpoint")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE payments.webhook_endpoints SET active = FALSE
+		WHERE id::text = $1 AND client_id = $2 AND active
+	`, endpointID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueEvent fans the event out to all matching endpoints in one statement.
+func (s *PostgresStore) EnqueueEvent(ctx context.Context, event Event, payload []byte, accountIDs []string) (int, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.EnqueueWebhookEvent")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO payments.webhook_deliveries (
+			id, endpoint_id, event_id, event_type, payment_id, payload, status, attempts, next_attempt_at, created_at, updated_at
+		)
+		SELECT gen_random_uuid(), e.id, $1, $2, $3, $4, 'PENDING', 0, NOW(), NOW(), NOW()
+		FROM payments.webhook_endpoints e
+		WHERE e.active AND $2 = ANY(e.event_types) AND e.account_ids && $5::text[]
+	`, event.ID, event.Type, event.Data.PaymentID, string(payload), pq.Array(accountIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ClaimDue leases due deliveries with FOR UPDATE SKIP LOCKED so several
+// payment-service replicas can dispatch concurrently.
+func (s *PostgresStore) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE payments.webhook_deliveries d
+		SET next_attempt_at = $2, updated_at = NOW()
+		FROM payments.webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id IN (
+			SELECT id FROM payments.webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns+`, e.url, e.secret, e.active
+	`, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		var active bool
+		dest := append(deliveryDest(&d), &d.URL, &d.Secret, &active)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		if !active {
+			// Endpoint was deleted after the delivery was enqueued.
+			d.URL = ""
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// MarkSucceeded records a 2xx response.
+func (s *PostgresStore) MarkSucceeded(ctx context.Context, deliveryID string, statusCode int) error {
+	return s.updateDelivery(ctx, deliveryID, DeliverySucceeded, statusCode, "", time.Now())
+}
+
+// MarkRetry records a failed attempt and schedules the next one.
+func (s *PostgresStore) MarkRetry(ctx context.Context, deliveryID string, statusCode int, lastErr string, next time.Time) error {
+	return s.updateDelivery(ctx, deliveryID, DeliveryPending, statusCode, lastErr, next)
+}
+
+// MarkFailed records the final failed attempt.
+func (s *PostgresStore) MarkFailed(ctx context.Context, deliveryID string, statusCode int, lastErr string) error {
+	return s.updateDelivery(ctx, deliveryID, DeliveryFailed, statusCode, lastErr, time.Now())
+}
+
+func (s *PostgresStore) updateDelivery(ctx context.Context, deliveryID, status string, statusCode int, lastErr string, next time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE payments.webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4, next_attempt_at = $5, updated_at = NOW()
+		WHERE id = $1
+	`, deliveryID, status, statusCode, lastErr, next)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries of a client's endpoint.
+func (s *PostgresStore) ListDeliveries(ctx context.Context, clientID, endpointID string, limit int) ([]Delivery, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.ListWebhookDeliveries")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM payments.webhook_deliveries d
+		JOIN payments.webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE e.id::text = $1 AND e.client_id = $2
+		ORDER BY d.created_at DESC
+		LIMIT $3
+	`, endpointID, clientID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(deliveryDest(&d)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver enqueues a fresh copy of a delivery, keeping the original in the log.
+func (s *PostgresStore) Redeliver(ctx context.Context, clientID, deliveryID string) (*Delivery, error) {
+	ctx, span := otel.Tracer("payment-service").Start(ctx, "postgres.RedeliverWebhook")
+	defer span.End()
+
+	var d Delivery
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO payments.webhook_deliveries AS d (
+			id, endpoint_id, event_id, event_type, payment_id, payload, status, attempts, next_attempt_at, created_at, updated_at
+		)
+		SELECT gen_random_uuid(), o.endpoint_id, o.event_id, o.event_type, o.payment_id, o.payload, 'PENDING', 0, NOW(), NOW(), NOW()
+		FROM payments.webhook_deliveries o
+		JOIN payments.webhook_endpoints e ON e.id = o.endpoint_id
+		WHERE o.id::text = $1 AND e.client_id = $2 AND e.active
+		RETURNING `+deliveryColumns+`
+	`, deliveryID, clientID).Scan(deliveryDest(&d)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return &d, nil
+}
+
+// deliveryDest returns scan destinations matching deliveryColumns.
+func deliveryDest(d *Delivery) []any {
+	return []any{
+		&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.PaymentID, &d.Payload, &d.Status,
+		&d.Attempts, &d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt,
+	}
+}
@@ -0,0 +1,104 @@
+// Package webhook delivers signed notifications about payment state changes
+// to merchant-registered HTTP endpoints.
+//
+// Events are fanned out to matching endpoints as rows in
+// payments.webhook_deliveries, which doubles as the delivery log. A
+// Dispatcher polls due deliveries, POSTs the payload with an HMAC-SHA256
+// signature and reschedules failures with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+// Event types a webhook can subscribe to.
+const (
+	EventPaymentPending   = "payment.pending"
+	EventPaymentCompleted = "payment.completed"
+	EventPaymentFailed    = "payment.failed"
+)
+
+// EventTypes lists every supported event type.
+var EventTypes = []string{EventPaymentPending, EventPaymentCompleted, EventPaymentFailed}
+
+// Delivery statuses as stored in payments.webhook_deliveries.
+const (
+	DeliveryPending   = "PENDING"
+	DeliverySucceeded = "SUCCEEDED"
+	DeliveryFailed    = "FAILED"
+)
+
+// Endpoint is a registered webhook receiver.
+type Endpoint struct {
+	ID         string
+	ClientID   string
+	URL        string
+	Secret     string
+	EventTypes []string
+	AccountIDs []string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Delivery is one attempt series of sending an event to an endpoint.
+type Delivery struct {
+	ID             string
+	EndpointID     string
+	EventID        string
+	EventType      string
+	PaymentID      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	// Populated by ClaimDue so the dispatcher does not need a second lookup.
+	URL    string
+	Secret string
+}
+
+// Event is the JSON body POSTed to webhook endpoints.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt string      `json:"created_at"`
+	Data      PaymentData `json:"data"`
+}
+
+// PaymentData is the payment snapshot carried by an Event.
+type PaymentData struct {
+	PaymentID   string  `json:"payment_id"`
+	Status      string  `json:"status"`
+	Amount      float64 `json:"amount"`
+	Fee         float64 `json:"fee"`
+	Currency    string  `json:"currency"`
+	FromAccount string  `json:"from_account"`
+	ToAccount   string  `json:"to_account,omitempty"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// Store persists endpoints and the delivery log.
+type Store interface {
+	CreateEndpoint(ctx context.Context, e *Endpoint) error
+	ListEndpoints(ctx context.Context, clientID string) ([]Endpoint, error)
+	DeleteEndpoint(ctx context.Context, clientID, endpointID string) error
+
+	// EnqueueEvent creates a PENDING delivery for every active endpoint that
+	// subscribes to the event type and watches one of the given accounts.
+	EnqueueEvent(ctx context.Context, event Event, payload []byte, accountIDs []string) (int, error)
+	// ClaimDue leases up to limit due PENDING deliveries until leaseUntil so
+	// that concurrent dispatchers do not send the same delivery twice.
+	ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]Delivery, error)
+	MarkSucceeded(ctx context.Context, deliveryID string, statusCode int) error
+	MarkRetry(ctx context.Context, deliveryID string, statusCode int, lastErr string, next time.Time) error
+	MarkFailed(ctx context.Context, deliveryID string, statusCode int, lastErr string) error
+
+	ListDeliveries(ctx context.Context, clientID, endpointID string, limit int) ([]Delivery, error)
+	// Redeliver copies a delivery into a new PENDING delivery due now.
+	Redeliver(ctx context.Context, clientID, deliveryID string) (*Delivery, error)
+}
@@ -8,43 +8,73 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"securepay/payment-service/config"
 	"securepay/payment-service/internal/cache"
+	"securepay/payment-service/internal/fee"
 	"securepay/payment-service/internal/handler"
 	"securepay/payment-service/internal/kafka"
-	"securepay/payment-service/internal/logger"
+	"securepay/payment-service/internal/outbox"
+	"securepay/payment-service/internal/pubsub"
 	"securepay/payment-service/internal/repository"
-	"securepay/payment-service/internal/spiffe"
-	"securepay/payment-service/internal/telemetry"
 	"securepay/payment-service/internal/validator"
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"securepay/payment-service/internal/webhook"
+	"securepay/pkg/health"
+	"securepay/pkg/logger"
+	"securepay/pkg/metrics"
+	"securepay/pkg/principal"
+	"securepay/pkg/spiffe"
+	"securepay/pkg/telemetry"
 	pb "securepay/proto/gen/go/payment/v1"
+	pbv2 "securepay/proto/gen/go/payment/v2"
 )
 
+// version is reported in the telemetry resource; builds set it with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
+// healthCheckInterval is how often dependencies are checked.
+const healthCheckInterval = 10 * time.Second
+
 func main() {
 	// Use the OTel-aware JSON logger so that trace_id and span_id are
-	// automatically injected into every log record that carries a span.
-	slog.SetDefault(logger.New())
+	// automatically injected into every log record that carries a span. The
+	// level is set once the configuration is loaded.
+	var logLevel slog.LevelVar
+	slog.SetDefault(logger.New(&logLevel))
 
 	// Load configuration
-	cfg := config.Load()
-
-	// Initialize Tracer
-	shutdownTracer, err := telemetry.InitTracer(context.Background(), "payment-service")
+	cfg, err := config.Load()
 	if err != nil {
-		slog.Error("Failed to initialize tracer", "error", err)
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(cfg.LogLevel)
+
+	// Initialize Telemetry (traces, and metrics and logs when exported)
+	tel, err := telemetry.Init(context.Background(), telemetry.Config{
+		ServiceName:    "payment-service",
+		ServiceVersion: version,
+		SampleRatio:    cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		slog.Error("Failed to initialize telemetry", "error", err)
 		os.Exit(1)
 	}
 	defer func() {
-		if err := shutdownTracer(context.Background()); err != nil {
-			slog.Error("Failed to shutdown tracer", "error", err)
+		if err := tel.Shutdown(context.Background()); err != nil {
+			slog.Error("Failed to shutdown telemetry", "error", err)
 		}
 	}()
+	if bridge := tel.LogHandler(); bridge != nil {
+		slog.SetDefault(logger.New(&logLevel, bridge))
+	}
 
 	// Connect to Database
 	if cfg.DatabaseURL == "" {
@@ -64,13 +94,14 @@ func main() {
 		os.Exit(1)
 	}
 	slog.Info("Connected to database")
+	metrics.RegisterDBStats(db, "payments")
 
 	// Initialize Kafka Producer
 	producer := kafka.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopic)
 	defer producer.Close()
 
 	// Initialize SPIFFE Workload API Source
-	source, err := spiffe.InitSPIFFESource(context.Background(), cfg.SpiffeSocket)
+	source, err := spiffe.NewSource(context.Background(), cfg.SpiffeSocket)
 	if err != nil {
 		slog.Error("Failed to initialize SPIFFE source", "error", err)
 		os.Exit(1)
@@ -78,19 +109,90 @@ func main() {
 	defer source.Close()
 	slog.Info("SPIFFE Source initialized successfully")
 
+	// Load Fee Schedule (empty schedule charges no fees)
+	fees, err := fee.LoadSchedule(cfg.FeeSchedule, cfg.FeeScheduleFile)
+	if err != nil {
+		slog.Error("Failed to load fee schedule", "error", err)
+		os.Exit(1)
+	}
+	if err := fees.CheckHouseAccounts(validator.Currencies(), cfg.HouseAccounts); err != nil {
+		slog.Error("Invalid fee schedule", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize Components
 	redisCache := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword)
+	broker := pubsub.NewRedisBroker(cfg.RedisAddr, cfg.RedisPassword)
 	repo := repository.NewPostgresRepository(db)
 	val := validator.New()
-	h := handler.NewPaymentHandler(repo, val, producer, redisCache)
+	webhookStore := webhook.NewPostgresStore(db)
+	dispatcher := webhook.NewDispatcher(webhookStore, webhook.NewClient(10*time.Second, cfg.WebhookAllowPrivate))
+	relay := outbox.NewRelay(repo, producer)
+	h := handler.NewPaymentHandler(repo, val, redisCache, fees, dispatcher, broker, relay)
+	wh := handler.NewWebhookHandler(webhookStore, dispatcher, cfg.WebhookAllowHTTP)
+
+	// Background workers: outbox relay, webhook delivery and payment result
+	// consumption
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go relay.Run(workerCtx)
+	go dispatcher.Run(workerCtx)
+
+	resultConsumer := kafka.NewResultConsumer(cfg.KafkaBrokers, cfg.ResultsTopic)
+	defer resultConsumer.Close()
+	resultConsumer.Start(workerCtx, h.HandlePaymentResult)
+
+	// Dependency health, served over gRPC and on the HTTP side port along
+	// with the Prometheus metrics
+	checker := health.NewChecker(healthCheckInterval)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("redis", redisCache.Ping)
+	checker.Add("kafka", func(ctx context.Context) error { return health.PingKafka(ctx, cfg.KafkaBrokers) })
+	checker.Add("spiffe", func(context.Context) error { return spiffe.CheckSVID(source) })
+	// Kafka is needed by no service: payments and webhooks are read without
+	// it, so an outage only degrades the service.
+	checker.Service(pb.PaymentService_ServiceDesc.ServiceName, "postgres", "redis", "spiffe")
+	checker.Service(pbv2.PaymentService_ServiceDesc.ServiceName, "postgres", "redis", "spiffe")
+	checker.Service(pb.WebhookService_ServiceDesc.ServiceName, "postgres", "spiffe")
+	checker.Handle("GET "+metrics.Path, metrics.Handler())
+	go checker.Run(workerCtx)
+	go func() {
+		// Keeps serving until the process exits, so that probes see the
+		// shutdown instead of a refused connection.
+		if err := checker.Serve(context.Background(), cfg.HealthPort); err != nil {
+			slog.Error("Health server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Caller principals forwarded by the gateway are required on every call
+	// except reflection and health checks.
+	verifier, err := principal.NewVerifier(source, cfg.PrincipalIssuers, "/grpc.reflection.", "/grpc.health.v1.")
+	if err != nil {
+		slog.Error("Failed to initialize principal verifier", "error", err)
+		os.Exit(1)
+	}
 
 	// Create gRPC server with mTLS credentials
-	creds := spiffe.PaymentServiceServerCredentials(source)
-	s := grpc.NewServer(creds, grpc.StatsHandler(otelgrpc.NewServerHandler()))
-	
-	// Register PaymentService
+	creds, err := spiffe.ServerCredentials(source, cfg.ClientSpiffeIDs)
+	if err != nil {
+		slog.Error("Failed to initialize server credentials", "error", err)
+		os.Exit(1)
+	}
+	s := grpc.NewServer(creds,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// Metrics first, so that calls rejected by the verifier are counted.
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), verifier.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor(), verifier.StreamServerInterceptor()),
+	)
+
+	// Register PaymentService (v1 and v2)
 	pb.RegisterPaymentServiceServer(s, h)
-	
+	pbv2.RegisterPaymentServiceServer(s, handler.NewPaymentHandlerV2(h))
+	pb.RegisterWebhookServiceServer(s, wh)
+	checker.Register(s)
+
 	// Enable reflection for debugging (e.g. grpcurl)
 	reflection.Register(s)
 
@@ -100,6 +202,8 @@ func main() {
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
 		slog.Info("Shutting down gRPC server...")
+		checker.Shutdown()
+		stopWorkers()
 		s.GracefulStop()
 		slog.Info("Server stopped")
 	}()
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // PaymentStatus represents the status of a payment transaction
 type PaymentStatus string
@@ -11,26 +14,90 @@ const (
 	StatusFailed    PaymentStatus = "FAILED"
 )
 
+// IsValidTransition ensures valid state transitions
+// PENDING -> COMPLETED
+// PENDING -> FAILED
+func IsValidTransition(current, next PaymentStatus) error {
+	if current == next {
+		return nil
+	}
+	if current == StatusCompleted || current == StatusFailed {
+		return fmt.Errorf("cannot transition from terminal state %s to %s", current, next)
+	}
+	if current == StatusPending && (next == StatusCompleted || next == StatusFailed) {
+		return nil
+	}
+	return fmt.Errorf("invalid transition from %s to %s", current, next)
+}
+
 // Payment represents a transaction record in the database
 type Payment struct {
 	ID             string
 	FromAccount    string
 	ToAccount      string
 	Amount         float64
+	Fee            float64
 	Currency       string
 	Status         string
 	IdempotencyKey string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	Version        int
+	Legs           []SplitLeg // Non-empty for split payments; ToAccount is empty then
+	InitiatedBy    string     // Subject of the end user who initiated the payment
+}
+
+// SplitLeg is one recipient of a split payment
+type SplitLeg struct {
+	ToAccount string  `json:"to_account"`
+	Amount    float64 `json:"amount"`
+}
+
+// Batch represents a bulk payment submission record in the database
+type Batch struct {
+	ID             string
+	IdempotencyKey string
+	TotalItems     int
+	InitiatedBy    string
+	CreatedAt      time.Time
+}
+
+// BatchItem represents the outcome of a single line within a batch.
+// Error is empty for lines that were accepted and persisted as payments.
+type BatchItem struct {
+	BatchID   string
+	Line      int
+	PaymentID string
+	Status    string
+	Error     string
 }
 
 // PaymentInitiatedEvent represents the event structure published to Kafka
 type PaymentInitiatedEvent struct {
-	PaymentID   string  `json:"payment_id"`
-	FromAccount string  `json:"from_account"`
-	ToAccount   string  `json:"to_account"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	Timestamp   string  `json:"timestamp"`
+	PaymentID   string     `json:"payment_id"`
+	FromAccount string     `json:"from_account"`
+	ToAccount   string     `json:"to_account"`
+	Amount      float64    `json:"amount"`
+	Fee         float64    `json:"fee"` // Charged to the payer on top of Amount
+	Currency    string     `json:"currency"`
+	Timestamp   string     `json:"timestamp"`
+	Legs        []SplitLeg `json:"legs,omitempty"` // Set for split payments, ToAccount is empty then
+}
+
+// OutboxMessage is a Kafka message stored in payments.outbox by the
+// transaction that persists the payments it announces, until it is published.
+type OutboxMessage struct {
+	ID      int64
+	Key     string
+	Payload []byte
+	Headers map[string]string // Trace context of the originating request
+}
+
+// PaymentResultEvent is published by account-service once a payment has been
+// applied to the balances (COMPLETED) or rejected (FAILED)
+type PaymentResultEvent struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+	Timestamp string `json:"timestamp"`
 }
@@ -0,0 +1,153 @@
+// Package envconfig reads configuration from environment variables.
+//
+// A Loader overrides a field only when its variable is set, so callers fill
+// a struct with defaults first and then apply the environment:
+//
+//	cfg := Config{Port: ":8081"}
+//	var env envconfig.Loader
+//	env.String("PORT", &cfg.Port)
+//	if err := env.Err(); err != nil { ... }
+package envconfig
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// LoadDotEnv loads a .env file from the working directory, if there is one.
+// Variables already set in the environment take precedence.
+func LoadDotEnv() {
+	if err := godotenv.Load(); err != nil {
+		slog.Warn("No .env file found, using system environment variables")
+	}
+}
+
+// Loader applies environment variables to configuration fields. Invalid
+// values leave the field unchanged and are reported by Err.
+type Loader struct {
+	errs []error
+}
+
+// Err returns the errors of all invalid values, or nil.
+func (l *Loader) Err() error {
+	return errors.Join(l.errs...)
+}
+
+// String sets dst to the value of key.
+func (l *Loader) String(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// List sets dst to the comma-separated values of key, dropping empty
+// entries.
+func (l *Loader) List(key string, dst *[]string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = SplitList(v)
+	}
+}
+
+// Map sets dst to the comma-separated KEY=VALUE pairs of key.
+func (l *Loader) Map(key string, dst *map[string]string) {
+	parse(l, key, dst, func(v string) (map[string]string, error) {
+		pairs := SplitList(v)
+		m := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			k, v, ok := strings.Cut(pair, "=")
+			k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+			if !ok || k == "" || v == "" {
+				return nil, fmt.Errorf("%q is not KEY=VALUE", pair)
+			}
+			if _, dup := m[k]; dup {
+				return nil, fmt.Errorf("duplicate key %s", k)
+			}
+			m[k] = v
+		}
+		return m, nil
+	})
+}
+
+// Bool sets dst to the value of key as parsed by strconv.ParseBool.
+func (l *Loader) Bool(key string, dst *bool) {
+	parse(l, key, dst, strconv.ParseBool)
+}
+
+// Int sets dst to the decimal value of key.
+func (l *Loader) Int(key string, dst *int) {
+	parse(l, key, dst, strconv.Atoi)
+}
+
+// Int64 sets dst to the decimal value of key.
+func (l *Loader) Int64(key string, dst *int64) {
+	parse(l, key, dst, func(v string) (int64, error) { return strconv.ParseInt(v, 10, 64) })
+}
+
+// Float sets dst to the value of key as a float.
+func (l *Loader) Float(key string, dst *float64) {
+	parse(l, key, dst, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) })
+}
+
+// Duration sets dst to the value of key as parsed by time.ParseDuration.
+func (l *Loader) Duration(key string, dst *time.Duration) {
+	parse(l, key, dst, time.ParseDuration)
+}
+
+// Time sets dst to the RFC 3339 value of key; an empty value sets the zero
+// time.
+func (l *Loader) Time(key string, dst *time.Time) {
+	parse(l, key, dst, func(v string) (time.Time, error) {
+		if v == "" {
+			return time.Time{}, nil
+		}
+		return time.Parse(time.RFC3339, v)
+	})
+}
+
+// Level sets dst to the value of key as a slog level name ("debug",
+// "info", "warn", "error", optionally with an offset such as "info+2").
+func (l *Loader) Level(key string, dst *slog.Level) {
+	parse(l, key, dst, func(v string) (slog.Level, error) {
+		var level slog.Level
+		err := level.UnmarshalText([]byte(v))
+		return level, err
+	})
+}
+
+// Func sets a field with a custom parser, for types the Loader does not
+// know.
+func Func[T any](l *Loader, key string, dst *T, parseFn func(string) (T, error)) {
+	parse(l, key, dst, parseFn)
+}
+
+func parse[T any](l *Loader, key string, dst *T, parseFn func(string) (T, error)) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	parsed, err := parseFn(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+		return
+	}
+	*dst = parsed
+}
+
+// SplitList splits a comma-separated list, trimming spaces and dropping
+// empty entries.
+func SplitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
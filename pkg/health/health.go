@@ -0,0 +1,291 @@
+// Package health reports the health of a service and its dependencies.
+//
+// Dependency checks run periodically in the background. Their results drive
+// the standard grpc.health.v1 service and the /livez and /readyz endpoints
+// of the HTTP side port, which Kubernetes probes because it cannot present
+// an SVID to the mTLS gRPC port. Other plain HTTP endpoints, such as
+// /metrics, can be added to the side port with Handle.
+//
+// Each gRPC service is SERVING while the dependencies it was declared with
+// are healthy, so that an outage of one dependency only takes down the
+// services that need it. The server as a whole, the empty service name, and
+// /readyz stay up while any service is SERVING. A dependency no service
+// needs only marks the server degraded.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkTimeout bounds a single dependency check.
+const checkTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of the last run of a check.
+type Result struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs dependency checks and publishes the status of every gRPC
+// service.
+type Checker struct {
+	// services maps each gRPC service to the checks it needs.
+	services map[string][]string
+	interval time.Duration
+	grpc     *grpchealth.Server
+	extra    map[string]http.Handler
+
+	mu       sync.RWMutex
+	checks   []check
+	results  map[string]Result
+	serving  map[string]bool
+	stopping bool
+}
+
+// NewChecker creates a Checker. The server as a whole, the empty service
+// name, is NOT_SERVING until the first round of checks has passed.
+func NewChecker(interval time.Duration) *Checker {
+	c := &Checker{
+		services: make(map[string][]string),
+		interval: interval,
+		grpc:     grpchealth.NewServer(),
+		results:  make(map[string]Result),
+		serving:  make(map[string]bool),
+		extra:    make(map[string]http.Handler),
+	}
+	c.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return c
+}
+
+// Service reports the gRPC service as SERVING while the named checks pass.
+// It is NOT_SERVING until the first round of checks has passed. All
+// services must be declared before Run.
+func (c *Checker) Service(name string, checks ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services[name] = checks
+	c.grpc.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Add registers a dependency check. All checks must be added before Run.
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Handle serves h on the HTTP side port under pattern. All handlers must
+// be added before Serve.
+func (c *Checker) Handle(pattern string, h http.Handler) {
+	c.extra[pattern] = h
+}
+
+// Register registers the grpc.health.v1 service on s.
+func (c *Checker) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, c.grpc)
+}
+
+// Run checks the dependencies every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) checkAll(ctx context.Context) {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	results := make(map[string]Result, len(checks))
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	for _, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			start := time.Now()
+			err := chk.fn(checkCtx)
+			r := Result{Healthy: err == nil, LatencyMS: time.Since(start).Milliseconds(), CheckedAt: start}
+			if err != nil {
+				r.Error = err.Error()
+			}
+			resultsMu.Lock()
+			results[chk.name] = r
+			resultsMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, r := range results {
+		if prev, ok := c.results[name]; r.Healthy != prev.Healthy || !ok {
+			if r.Healthy {
+				slog.InfoContext(ctx, "Dependency healthy", "dependency", name)
+			} else {
+				slog.WarnContext(ctx, "Dependency unhealthy", "dependency", name, "error", r.Error, "required", c.required(name))
+			}
+		}
+	}
+	c.results = results
+	if c.stopping {
+		return
+	}
+
+	for service, checks := range c.services {
+		c.serving[service] = c.healthy(checks...)
+		c.setServing(service, c.serving[service])
+	}
+	c.setServing("", c.ready())
+}
+
+// healthy reports whether all of the named checks, or all checks when none
+// is named, passed in the last round. Call with c.mu held.
+func (c *Checker) healthy(names ...string) bool {
+	if len(names) == 0 {
+		for _, chk := range c.checks {
+			names = append(names, chk.name)
+		}
+	}
+	for _, name := range names {
+		if r, ok := c.results[name]; !ok || !r.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// ready reports whether any service is SERVING, or every check passed when
+// no service is declared. Call with c.mu held.
+func (c *Checker) ready() bool {
+	if len(c.services) == 0 {
+		return c.healthy()
+	}
+	for _, serving := range c.serving {
+		if serving {
+			return true
+		}
+	}
+	return false
+}
+
+// required reports whether a service needs the named check. Call with c.mu
+// held.
+func (c *Checker) required(name string) bool {
+	for _, checks := range c.services {
+		if slices.Contains(checks, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shutdown marks the service NOT_SERVING for good, so that clients and
+// load balancers drain it before the server stops. Call it before
+// GracefulStop.
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	c.stopping = true
+	c.mu.Unlock()
+	c.grpc.Shutdown()
+}
+
+func (c *Checker) setServing(service string, serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	c.grpc.SetServingStatus(service, status)
+}
+
+// Handler serves /livez, /readyz and the handlers added with Handle.
+// /livez only reports that the process is running; /readyz reports every
+// dependency and service and answers 503 while no service is SERVING,
+// before the first round of checks and during shutdown. A failed check that
+// leaves the server up is reported as degraded.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for pattern, h := range c.extra {
+		mux.Handle(pattern, h)
+	}
+	mux.HandleFunc("GET /livez", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		c.mu.RLock()
+		resp := struct {
+			Status       string            `json:"status"`
+			Dependencies map[string]Result `json:"dependencies"`
+			Services     map[string]bool   `json:"services,omitempty"`
+		}{Status: "ok", Dependencies: c.results, Services: maps.Clone(c.serving)}
+		ready := len(c.results) == len(c.checks) && c.ready()
+		degraded := !c.healthy()
+		stopping := c.stopping
+		c.mu.RUnlock()
+
+		status := http.StatusOK
+		switch {
+		case stopping:
+			resp.Status, status = "shutting_down", http.StatusServiceUnavailable
+		case !ready:
+			resp.Status, status = "unavailable", http.StatusServiceUnavailable
+		case degraded:
+			resp.Status = "degraded"
+		}
+		writeJSON(w, status, resp)
+	})
+	return mux
+}
+
+// Serve runs the HTTP side port until ctx is done.
+func (c *Checker) Serve(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: c.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	slog.Info("Health endpoints listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PingKafka checks that at least one broker accepts connections and returns
+// cluster metadata.
+func PingKafka(ctx context.Context, brokers []string) error {
+	var errs []error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		_, err = conn.Brokers()
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no kafka broker reachable: %w", errors.Join(errs...))
+}
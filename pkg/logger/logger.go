@@ -0,0 +1,123 @@
+// Package logger provides a context-aware slog.Handler that enriches every
+// log record with OpenTelemetry trace correlation fields (trace_id, span_id,
+// trace_flags). All log output is JSON-formatted for easy ingestion by
+// centralised logging systems such as ELK or Grafana Loki.
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelHandler wraps an inner slog.Handler and, on every Handle call,
+// extracts the active span from the context and injects the W3C
+// trace-correlation fields into the log record before forwarding it.
+type otelHandler struct {
+	inner slog.Handler
+}
+
+// Enabled delegates level filtering to the inner handler.
+func (h *otelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle enriches the record with trace fields then forwards it.
+func (h *otelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		sc := span.SpanContext()
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+			slog.String("trace_flags", sc.TraceFlags().String()),
+		)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+// WithAttrs returns a new handler whose inner handler has the given attrs.
+func (h *otelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &otelHandler{inner: h.inner.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new handler whose inner handler opens the given group.
+func (h *otelHandler) WithGroup(name string) slog.Handler {
+	return &otelHandler{inner: h.inner.WithGroup(name)}
+}
+
+// NewHandler returns a JSON handler writing to w that injects the trace
+// correlation fields. Records below level are dropped; pass a
+// *slog.LevelVar to change the level at runtime. Records are also passed to
+// each of bridges, e.g. the OTLP log bridge, which record the trace context
+// themselves.
+func NewHandler(w io.Writer, level slog.Leveler, bridges ...slog.Handler) slog.Handler {
+	json := &otelHandler{inner: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})}
+	if len(bridges) == 0 {
+		return json
+	}
+	return &fanoutHandler{level: level, handlers: append([]slog.Handler{json}, bridges...)}
+}
+
+// New creates a new JSON slog.Logger writing to stdout that automatically
+// injects OpenTelemetry trace_id / span_id fields from the request context.
+// Records are also passed to each of bridges.
+//
+// Usage:
+//
+//	slog.SetDefault(logger.New(cfg.LogLevel))
+//
+//	// Inside a handler, use the context-aware variants:
+//	slog.InfoContext(ctx, "payment processed", "payment_id", id)
+func New(level slog.Leveler, bridges ...slog.Handler) *slog.Logger {
+	return slog.New(NewHandler(os.Stdout, level, bridges...))
+}
+
+// fanoutHandler passes records at or above level to every handler.
+type fanoutHandler struct {
+	level    slog.Leveler
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < h.level.Level() {
+		return false
+	}
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes a copy of the record to every handler that is enabled for
+// it, so that the attributes one adds do not reach the others.
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *fanoutHandler) with(f func(slog.Handler) slog.Handler) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = f(handler)
+	}
+	return &fanoutHandler{level: h.level, handlers: handlers}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	kafkaProduceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_produce_duration_seconds",
+		Help:    "Duration of writes to Kafka, by topic and result (ok or error).",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"topic", "result"})
+
+	kafkaConsumeLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consume_latency_seconds",
+		Help:    "Time from a message being produced to the end of its processing, by topic and result (ok or error).",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"topic", "result"})
+
+	kafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages behind the end of the partition, as of the last message fetched.",
+	}, []string{"topic", "group"})
+)
+
+// ObserveKafkaProduce records a write of messages to topic that started at
+// start.
+func ObserveKafkaProduce(topic string, start time.Time, err error) {
+	kafkaProduceDuration.WithLabelValues(topic, result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveKafkaConsume records the processing of m and the lag of reader.
+func ObserveKafkaConsume(reader *kafka.Reader, m kafka.Message, err error) {
+	kafkaConsumeLatency.WithLabelValues(m.Topic, result(err)).Observe(time.Since(m.Time).Seconds())
+	cfg := reader.Config()
+	kafkaConsumerLag.WithLabelValues(cfg.Topic, cfg.GroupID).Set(float64(reader.Stats().Lag))
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,83 @@
+// Package metrics exposes the Prometheus metrics of a service on the HTTP
+// side port and records the metrics the services have in common: gRPC
+// server, database pool and Kafka client metrics. Business metrics are
+// defined by the packages that record them.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Path is where the metrics are served on the HTTP side port.
+const Path = "/metrics"
+
+var (
+	grpcHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, by status code.",
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	grpcHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Duration of RPCs handled by the server.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .2, .3, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"grpc_service", "grpc_method"})
+)
+
+// Handler serves the metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats exports the connection pool statistics of db
+// (sql.DB.Stats) under the given database name.
+func RegisterDBStats(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// UnaryServerInterceptor records the status and duration of unary RPCs.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the status and duration of streaming
+// RPCs, such as WatchPayment, once the stream ends.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		record(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func record(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	grpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	grpcHandlingSeconds.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// splitMethod splits /package.Service/Method into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
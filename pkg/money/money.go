@@ -0,0 +1,20 @@
+// Package money converts between the decimal amounts stored by the services
+// and the minor units of the v2 API.
+package money
+
+import "math"
+
+// minorUnitsPerMajor is the number of minor units in one unit of each
+// supported currency (TRY, USD and EUR all have two decimals).
+const minorUnitsPerMajor = 100
+
+// ToMinorUnits converts a decimal amount, as stored in NUMERIC(18,2)
+// columns, to minor units. Fractions of a minor unit are rounded.
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * minorUnitsPerMajor))
+}
+
+// FromMinorUnits converts minor units to a decimal amount.
+func FromMinorUnits(units int64) float64 {
+	return float64(units) / minorUnitsPerMajor
+}
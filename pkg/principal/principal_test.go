@@ -0,0 +1,204 @@
+package principal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	testMethod  = "/payment.v2.PaymentService/CreatePayment"
+	gatewayID   = "spiffe://securepay.test/api-gateway"
+	otherID     = "spiffe://securepay.test/payment-service"
+	trustDomain = "securepay.test"
+)
+
+// testCA issues SVIDs for trustDomain.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: trustDomain}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, id string) *x509svid.SVID {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		URIs:         []*url.URL{uri},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &x509svid.SVID{ID: spiffeid.RequireFromString(id), Certificates: []*x509.Certificate{cert}, PrivateKey: key}
+}
+
+// staticSource is an x509svid.Source always returning the same SVID.
+type staticSource struct{ svid *x509svid.SVID }
+
+func (s staticSource) GetX509SVID() (*x509svid.SVID, error) { return s.svid, nil }
+
+// incoming turns the metadata signed into an outgoing context into the
+// context of the call received from the mTLS peer.
+func incoming(outgoing context.Context, peerSVID *x509svid.SVID) context.Context {
+	md, _ := metadata.FromOutgoingContext(outgoing)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if peerSVID == nil {
+		return ctx
+	}
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{PeerCertificates: peerSVID.Certificates},
+	}})
+}
+
+// signEnvelope signs env with svid the way Signer does, for envelopes the
+// Signer would not produce.
+func signEnvelope(t *testing.T, svid *x509svid.SVID, env envelope) context.Context {
+	t.Helper()
+	payload, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sign(svid.PrivateKey, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(),
+		MetadataPrincipal, base64.RawURLEncoding.EncodeToString(payload),
+		MetadataSignature, base64.RawURLEncoding.EncodeToString(sig),
+		MetadataSVID, base64.RawURLEncoding.EncodeToString(svid.Certificates[0].Raw),
+	)
+}
+
+// replace returns ctx with the outgoing metadata value of key replaced.
+func replace(ctx context.Context, key string, value []byte) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(key, base64.RawURLEncoding.EncodeToString(value))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func decoded(ctx context.Context, key string) []byte {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	data, _ := base64.RawURLEncoding.DecodeString(md.Get(key)[0])
+	return data
+}
+
+func TestVerify(t *testing.T) {
+	ca := newTestCA(t)
+	gateway := ca.issue(t, gatewayID)
+	other := ca.issue(t, otherID)
+	untrusted := newTestCA(t).issue(t, gatewayID)
+
+	bundle := x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString(trustDomain), []*x509.Certificate{ca.cert})
+	v, err := NewVerifier(bundle, []string{gatewayID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := Principal{Subject: "alice", Tenant: "acme", Scopes: []string{"payments:write"}}
+	signed, err := NewSigner(staticSource{gateway}).AppendToOutgoingContext(context.Background(), testMethod, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedSig := decoded(signed, MetadataSignature)
+	tamperedSig[len(tamperedSig)/2] ^= 0xff
+	tamperedPayload := []byte(strings.Replace(string(decoded(signed, MetadataPrincipal)), `"sub":"alice"`, `"sub":"mallory"`, 1))
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		outgoing context.Context
+		peer     *x509svid.SVID
+		wantErr  string
+	}{
+		{"signed by the gateway", signed, gateway, ""},
+		{"tampered payload", replace(signed, MetadataPrincipal, tamperedPayload), gateway, "invalid principal signature"},
+		{"tampered signature", replace(signed, MetadataSignature, tamperedSig), gateway, "invalid principal signature"},
+		{"expired", signEnvelope(t, gateway, envelope{Principal: alice, Method: testMethod, IssuedAt: now.Add(-MaxAge - time.Minute).Unix()}), gateway, "principal expired"},
+		{"issued in the future", signEnvelope(t, gateway, envelope{Principal: alice, Method: testMethod, IssuedAt: now.Add(MaxAge + time.Minute).Unix()}), gateway, "principal expired"},
+		{"issued for another method", signEnvelope(t, gateway, envelope{Principal: alice, Method: "/payment.v2.PaymentService/GetPayment", IssuedAt: now.Unix()}), gateway, "principal issued for"},
+		{"no subject", signEnvelope(t, gateway, envelope{Method: testMethod, IssuedAt: now.Unix()}), gateway, "principal has no subject"},
+		{"SVID of an untrusted CA", signEnvelope(t, untrusted, envelope{Principal: alice, Method: testMethod, IssuedAt: now.Unix()}), untrusted, "principal SVID not trusted"},
+		{"signed by another workload", signEnvelope(t, other, envelope{Principal: alice, Method: testMethod, IssuedAt: now.Unix()}), other, "is not a trusted principal issuer"},
+		{"relayed by another peer", signed, other, "but sent by"},
+		{"no mTLS peer", signed, nil, "no peer information"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(incoming(tt.outgoing, tt.peer), testMethod)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if got.Subject != alice.Subject || got.Tenant != alice.Tenant || !got.HasScope("payments:write") {
+					t.Errorf("Verify() = %+v, want %+v", got, alice)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package principal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Signer signs principals with the current SVID of the workload.
+type Signer struct {
+	source x509svid.Source
+}
+
+// NewSigner creates a Signer; the SVID is looked up on every call so that
+// rotated SVIDs are used as soon as they are issued.
+func NewSigner(source x509svid.Source) *Signer {
+	return &Signer{source: source}
+}
+
+// AppendToOutgoingContext signs p for the given method and adds it to the
+// outgoing metadata of ctx.
+func (s *Signer) AppendToOutgoingContext(ctx context.Context, method string, p Principal) (context.Context, error) {
+	svid, err := s.source.GetX509SVID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SVID: %w", err)
+	}
+
+	payload, err := json.Marshal(envelope{Principal: p, Method: method, IssuedAt: time.Now().Unix()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal principal: %w", err)
+	}
+
+	sig, err := sign(svid.PrivateKey, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign principal: %w", err)
+	}
+
+	var chain []byte
+	for _, cert := range svid.Certificates {
+		chain = append(chain, cert.Raw...)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataPrincipal, base64.RawURLEncoding.EncodeToString(payload),
+		MetadataSignature, base64.RawURLEncoding.EncodeToString(sig),
+		MetadataSVID, base64.RawURLEncoding.EncodeToString(chain),
+	), nil
+}
+
+func sign(key crypto.Signer, payload []byte) ([]byte, error) {
+	// Ed25519 signs the message itself; RSA and ECDSA sign its SHA-256 digest.
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(payload)
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// UnaryClientInterceptor attaches the principal returned by from to every
+// unary call. Calls without a principal are sent without one and rejected
+// by the service.
+func (s *Signer) UnaryClientInterceptor(from func(context.Context) (Principal, bool)) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := s.attach(ctx, method, from)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor.
+func (s *Signer) StreamClientInterceptor(from func(context.Context) (Principal, bool)) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := s.attach(ctx, method, from)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func (s *Signer) attach(ctx context.Context, method string, from func(context.Context) (Principal, bool)) (context.Context, error) {
+	p, ok := from(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	return s.AppendToOutgoingContext(ctx, method, p)
+}
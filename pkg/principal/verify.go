@@ -0,0 +1,204 @@
+package principal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Verifier checks signed principals.
+type Verifier struct {
+	bundles x509bundle.Source
+	issuers []spiffeid.ID
+	// exempt lists full-method prefixes that do not require a principal,
+	// such as gRPC reflection.
+	exempt []string
+}
+
+// NewVerifier creates a Verifier accepting principals signed by any of the
+// issuer SPIFFE IDs.
+func NewVerifier(bundles x509bundle.Source, issuers []string, exempt ...string) (*Verifier, error) {
+	v := &Verifier{bundles: bundles, exempt: exempt}
+	for _, issuer := range issuers {
+		id, err := spiffeid.FromString(strings.TrimSpace(issuer))
+		if err != nil {
+			return nil, fmt.Errorf("invalid principal issuer %q: %w", issuer, err)
+		}
+		v.issuers = append(v.issuers, id)
+	}
+	if len(v.issuers) == 0 {
+		return nil, errors.New("at least one principal issuer is required")
+	}
+	return v, nil
+}
+
+// Verify extracts and verifies the principal of an incoming call.
+func (v *Verifier) Verify(ctx context.Context, method string) (Principal, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Principal{}, errors.New("missing metadata")
+	}
+	payload, err := decodeMetadata(md, MetadataPrincipal)
+	if err != nil {
+		return Principal{}, err
+	}
+	sig, err := decodeMetadata(md, MetadataSignature)
+	if err != nil {
+		return Principal{}, err
+	}
+	chain, err := decodeMetadata(md, MetadataSVID)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	certs, err := x509.ParseCertificates(chain)
+	if err != nil || len(certs) == 0 {
+		return Principal{}, errors.New("invalid principal SVID")
+	}
+	signer, _, err := x509svid.Verify(certs, v.bundles)
+	if err != nil {
+		return Principal{}, fmt.Errorf("principal SVID not trusted: %w", err)
+	}
+	if !slices.Contains(v.issuers, signer) {
+		return Principal{}, fmt.Errorf("%s is not a trusted principal issuer", signer)
+	}
+	peerID, err := peerSPIFFEID(ctx)
+	if err != nil {
+		return Principal{}, err
+	}
+	if peerID != signer {
+		return Principal{}, fmt.Errorf("principal signed by %s but sent by %s", signer, peerID)
+	}
+	if err := verifySignature(certs[0].PublicKey, payload, sig); err != nil {
+		return Principal{}, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return Principal{}, fmt.Errorf("invalid principal payload: %w", err)
+	}
+	if env.Method != method {
+		return Principal{}, fmt.Errorf("principal issued for %s", env.Method)
+	}
+	age := time.Since(time.Unix(env.IssuedAt, 0))
+	if age > MaxAge || age < -MaxAge {
+		return Principal{}, errors.New("principal expired")
+	}
+	if env.Subject == "" {
+		return Principal{}, errors.New("principal has no subject")
+	}
+	return env.Principal, nil
+}
+
+func (v *Verifier) authorize(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range v.exempt {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+	p, err := v.Verify(ctx, method)
+	if err != nil {
+		slog.WarnContext(ctx, "Rejected call without valid principal", "error", err, "method", method)
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid caller principal")
+	}
+	return NewContext(ctx, p), nil
+}
+
+// UnaryServerInterceptor rejects unary calls without a valid principal and
+// stores the principal in the handler context.
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func decodeMetadata(md metadata.MD, key string) ([]byte, error) {
+	values := md.Get(key)
+	if len(values) != 1 {
+		return nil, fmt.Errorf("expected exactly one %s", key)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(values[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func peerSPIFFEID(ctx context.Context) (spiffeid.ID, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return spiffeid.ID{}, errors.New("no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return spiffeid.ID{}, errors.New("peer is not authenticated with mTLS")
+	}
+	return x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+}
+
+func verifySignature(pub crypto.PublicKey, payload, sig []byte) error {
+	digest := sha256.Sum256(payload)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(key, digest[:], sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, payload, sig) {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported SVID key type %T", pub)
+	}
+	return errors.New("invalid principal signature")
+}
@@ -0,0 +1,84 @@
+// Package spiffe connects to the SPIRE agent and builds the mTLS gRPC
+// credentials the SecurePay services authenticate each other with.
+package spiffe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewSource initializes and returns a new X.509 source connected to the
+// SPIRE Agent. The source fetches and renews SVIDs in the background. It is
+// the caller's responsibility to close the source when done.
+func NewSource(ctx context.Context, socketPath string) (*workloadapi.X509Source, error) {
+	clientOptions := workloadapi.WithClientOptions(workloadapi.WithAddr(socketPath))
+	source, err := workloadapi.NewX509Source(ctx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create X509Source: %w", err)
+	}
+
+	return source, nil
+}
+
+// ClientCredentials returns gRPC dial options with mTLS credentials for
+// connecting to a backend service. It enforces that the server presents a
+// valid SVID with the given SPIFFE ID.
+func ClientCredentials(source *workloadapi.X509Source, serverID string) (grpc.DialOption, error) {
+	id, err := spiffeid.FromString(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server SPIFFE ID: %w", err)
+	}
+
+	// - source: provides our client certificate (SVID)
+	// - source: provides the trust bundle to verify the server's certificate
+	// - AuthorizeID: ensures the server has the expected SPIFFE ID
+	tlsConfig := tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeID(id))
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
+
+// ServerCredentials returns gRPC server options with mTLS credentials that
+// only accept clients presenting one of clientIDs. With no client IDs, any
+// client with a valid SVID from a trusted domain is accepted.
+func ServerCredentials(source *workloadapi.X509Source, clientIDs []string) (grpc.ServerOption, error) {
+	authorizer := tlsconfig.AuthorizeAny()
+	if len(clientIDs) > 0 {
+		ids := make([]spiffeid.ID, 0, len(clientIDs))
+		for _, s := range clientIDs {
+			id, err := spiffeid.FromString(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client SPIFFE ID %q: %w", s, err)
+			}
+			ids = append(ids, id)
+		}
+		authorizer = tlsconfig.AuthorizeOneOf(ids...)
+	}
+
+	tlsConfig := tlsconfig.MTLSServerConfig(source, source, authorizer)
+	return grpc.Creds(credentials.NewTLS(tlsConfig)), nil
+}
+
+// CheckSVID reports an error when the source holds no X.509-SVID or the
+// SVID is not currently valid, e.g. because rotation stopped working.
+func CheckSVID(source *workloadapi.X509Source) error {
+	svid, err := source.GetX509SVID()
+	if err != nil {
+		return fmt.Errorf("no X509-SVID: %w", err)
+	}
+	leaf := svid.Certificates[0]
+	now := time.Now()
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("X509-SVID %s is not valid before %s", svid.ID, leaf.NotBefore.Format(time.RFC3339))
+	}
+	if !now.Before(leaf.NotAfter) {
+		return fmt.Errorf("X509-SVID %s expired at %s", svid.ID, leaf.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Package telemetry sets up OpenTelemetry for the SecurePay binaries:
+// traces, metrics and logs exported over OTLP/gRPC.
+//
+// The standard OTEL_* environment variables configure it:
+//
+//   - OTEL_TRACES_EXPORTER, OTEL_METRICS_EXPORTER and OTEL_LOGS_EXPORTER
+//     select "otlp" or "none". Traces are exported by default. Metrics and
+//     logs are opt-in: Prometheus scrapes the metrics and logs are
+//     collected from stdout, and not every collector accepts them.
+//   - OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG replace the default
+//     sampler, which follows the parent's decision and samples root spans
+//     at Config.SampleRatio.
+//   - OTEL_EXPORTER_OTLP_ENDPOINT, _HEADERS, _CERTIFICATE,
+//     _CLIENT_CERTIFICATE, _CLIENT_KEY, _COMPRESSION and _TIMEOUT, and
+//     their per-signal variants, configure the exporters. An https://
+//     endpoint is dialed with TLS. A bare host:port, as deployments have
+//     always set it, and the default localhost:4317 are plaintext.
+//   - OTEL_METRIC_EXPORT_INTERVAL sets how often metrics are pushed.
+//   - OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME extend and override
+//     the resource.
+//
+// The resource also carries k8s.pod.name and k8s.namespace.name from
+// POD_NAME and POD_NAMESPACE, which Kubernetes sets through the downward
+// API, and deployment.environment.name from DEPLOYMENT_ENVIRONMENT.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// defaultEndpoint is the OTLP/gRPC endpoint used when none is configured.
+const defaultEndpoint = "localhost:4317"
+
+// Config configures Init.
+type Config struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// ServiceVersion is reported as service.version unless empty.
+	ServiceVersion string
+	// SampleRatio is the fraction of root spans to sample, from 0 (none) to
+	// 1 (all). Spans with a remote parent follow the parent's decision.
+	SampleRatio float64
+}
+
+// Telemetry holds the providers installed by Init.
+type Telemetry struct {
+	logHandler slog.Handler
+	shutdown   []func(context.Context) error
+}
+
+// Init installs the global tracer and meter providers and the W3C
+// TraceContext propagator, and creates the OTLP log bridge when logs are
+// exported. Call Shutdown to flush and stop the exporters.
+func Init(ctx context.Context, cfg Config) (*Telemetry, error) {
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	t := &Telemetry{}
+	for _, setup := range []func(context.Context, *resource.Resource, Config) error{t.initTraces, t.initMetrics, t.initLogs} {
+		if err := setup(ctx, res, cfg); err != nil {
+			_ = t.Shutdown(ctx)
+			return nil, err
+		}
+	}
+
+	// Set global propagator to W3C TraceContext (standard)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return t, nil
+}
+
+// LogHandler returns the handler that exports log records over OTLP, or
+// nil when OTEL_LOGS_EXPORTER does not enable it. It does no level
+// filtering of its own.
+func (t *Telemetry) LogHandler() slog.Handler {
+	return t.logHandler
+}
+
+// Shutdown flushes pending telemetry and stops the exporters. Logs are
+// stopped last, so that records logged while shutting down are exported.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, shutdown := range t.shutdown {
+		errs = append(errs, shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (t *Telemetry) initTraces(ctx context.Context, res *resource.Resource, cfg Config) error {
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	// The SDK reads OTEL_TRACES_SAMPLER itself unless a sampler is passed.
+	if _, ok := os.LookupEnv("OTEL_TRACES_SAMPLER"); !ok {
+		opts = append(opts, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))))
+	}
+
+	enabled, err := exporterEnabled("TRACES", "otlp")
+	if err != nil {
+		return err
+	}
+	if enabled {
+		var exporterOpts []otlptracegrpc.Option
+		if endpoint, ok := plaintextEndpoint("TRACES"); ok {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	slog.Info("Initializing tracer", "export", enabled, "sample_ratio", cfg.SampleRatio)
+
+	// Without an exporter spans are still created, so that trace ids are
+	// propagated and logged.
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	t.shutdown = append(t.shutdown, tp.Shutdown)
+	return nil
+}
+
+func (t *Telemetry) initMetrics(ctx context.Context, res *resource.Resource, _ Config) error {
+	enabled, err := exporterEnabled("METRICS", "none")
+	if err != nil || !enabled {
+		return err
+	}
+
+	var exporterOpts []otlpmetricgrpc.Option
+	if endpoint, ok := plaintextEndpoint("METRICS"); ok {
+		exporterOpts = append(exporterOpts, otlpmetricgrpc.WithEndpoint(endpoint), otlpmetricgrpc.WithInsecure())
+	}
+	exporter, err := otlpmetricgrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+	slog.Info("Initializing metric export")
+
+	// The metrics registered with Prometheus are pushed as well, so that
+	// the collector receives the series scrapers see next to the OTel
+	// instruments (e.g. the otelgrpc RPC metrics).
+	reader := sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithProducer(prometheusbridge.NewMetricProducer()))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithReader(reader))
+	otel.SetMeterProvider(mp)
+	t.shutdown = append(t.shutdown, mp.Shutdown)
+	return nil
+}
+
+func (t *Telemetry) initLogs(ctx context.Context, res *resource.Resource, cfg Config) error {
+	enabled, err := exporterEnabled("LOGS", "none")
+	if err != nil || !enabled {
+		return err
+	}
+
+	var exporterOpts []otlploggrpc.Option
+	if endpoint, ok := plaintextEndpoint("LOGS"); ok {
+		exporterOpts = append(exporterOpts, otlploggrpc.WithEndpoint(endpoint), otlploggrpc.WithInsecure())
+	}
+	exporter, err := otlploggrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create log exporter: %w", err)
+	}
+	slog.Info("Initializing log export")
+
+	lp := sdklog.NewLoggerProvider(sdklog.WithResource(res), sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	t.logHandler = otelslog.NewHandler(cfg.ServiceName, otelslog.WithLoggerProvider(lp))
+	t.shutdown = append(t.shutdown, lp.Shutdown)
+	return nil
+}
+
+// exporterEnabled reports whether OTEL_<signal>_EXPORTER, or def when it is
+// unset, enables the OTLP exporter.
+func exporterEnabled(signal, def string) (bool, error) {
+	key := "OTEL_" + signal + "_EXPORTER"
+	v := os.Getenv(key)
+	if v == "" {
+		v = def
+	}
+	switch v {
+	case "otlp":
+		return true, nil
+	case "none":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported %s %q: must be otlp or none", key, v)
+	}
+}
+
+// plaintextEndpoint returns the endpoint to dial without TLS, if any. The
+// exporters parse OTEL_EXPORTER_OTLP_ENDPOINT as a URL and would misread a
+// bare host:port, and default to TLS when it is unset; both cases keep
+// their plaintext behaviour here. Per-signal endpoints are left to the
+// exporter.
+func plaintextEndpoint(signal string) (string, bool) {
+	if _, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_" + signal + "_ENDPOINT"); ok {
+		return "", false
+	}
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return defaultEndpoint, true
+	}
+	if strings.Contains(endpoint, "://") {
+		return "", false
+	}
+	return endpoint, true
+}
+
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceName(cfg.ServiceName)}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.ServiceVersion))
+	}
+	for _, env := range []struct {
+		key  string
+		attr func(string) attribute.KeyValue
+	}{
+		{"POD_NAME", semconv.K8SPodName},
+		{"POD_NAMESPACE", semconv.K8SNamespaceName},
+		{"DEPLOYMENT_ENVIRONMENT", semconv.DeploymentEnvironmentName},
+	} {
+		if v := os.Getenv(env.key); v != "" {
+			attrs = append(attrs, env.attr(v))
+		}
+	}
+
+	return resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attrs...),
+		// Later detectors take precedence, so OTEL_RESOURCE_ATTRIBUTES and
+		// OTEL_SERVICE_NAME override the attributes above.
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+}
@@ -7,6 +7,7 @@
 package accountv1
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -130,7 +131,7 @@ var File_account_proto protoreflect.FileDescriptor
 const file_account_proto_rawDesc = "" +
 	"\n" +
 	"\raccount.proto\x12\n" +
-	"account.v1\"4\n" +
+	"account.v1\x1a\x1cgoogle/api/annotations.proto\"4\n" +
 	"\x13CheckBalanceRequest\x12\x1d\n" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tR\taccountId\"k\n" +
@@ -138,9 +139,9 @@ const file_account_proto_rawDesc = "" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tR\taccountId\x12\x18\n" +
 	"\abalance\x18\x02 \x01(\x01R\abalance\x12\x1a\n" +
-	"\bcurrency\x18\x03 \x01(\tR\bcurrency2c\n" +
-	"\x0eAccountService\x12Q\n" +
-	"\fCheckBalance\x12\x1f.account.v1.CheckBalanceRequest\x1a .account.v1.CheckBalanceResponseB-Z+securepay/proto/gen/go/account/v1;accountv1b\x06proto3"
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency2\x93\x01\n" +
+	"\x0eAccountService\x12\x80\x01\n" +
+	"\fCheckBalance\x12\x1f.account.v1.CheckBalanceRequest\x1a .account.v1.CheckBalanceResponse\"-\x82\xd3\xe4\x93\x02'\x12%/api/v1/accounts/{account_id}/balanceB-Z+securepay/proto/gen/go/account/v1;accountv1b\x06proto3"
 
 var (
 	file_account_proto_rawDescOnce sync.Once
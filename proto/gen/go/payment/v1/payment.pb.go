@@ -7,6 +7,7 @@
 package paymentv1
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -162,6 +163,7 @@ type InitiatePaymentResponse struct {
 	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
 	Status        PaymentStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=payment.v1.PaymentStatus" json:"status,omitempty"`
 	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Fee           float64                `protobuf:"fixed64,4,opt,name=fee,proto3" json:"fee,omitempty"` // Charged to the payer on top of the amount
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -217,6 +219,13 @@ func (x *InitiatePaymentResponse) GetMessage() string {
 	return ""
 }
 
+func (x *InitiatePaymentResponse) GetFee() float64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
 type GetPaymentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
@@ -270,6 +279,9 @@ type GetPaymentResponse struct {
 	Currency      string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
 	FromAccount   string                 `protobuf:"bytes,6,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
 	ToAccount     string                 `protobuf:"bytes,7,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	Legs          []*SplitLeg            `protobuf:"bytes,8,rep,name=legs,proto3" json:"legs,omitempty"`
+	Fee           float64                `protobuf:"fixed64,9,opt,name=fee,proto3" json:"fee,omitempty"`
+	InitiatedBy   string                 `protobuf:"bytes,10,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"` // Subject of the end user who initiated the payment
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -353,12 +365,613 @@ func (x *GetPaymentResponse) GetToAccount() string {
 	return ""
 }
 
+func (x *GetPaymentResponse) GetLegs() []*SplitLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+func (x *GetPaymentResponse) GetFee() float64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *GetPaymentResponse) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+type InitiateBatchPaymentRequest struct {
+	state          protoimpl.MessageState    `protogen:"open.v1"`
+	BatchId        string                    `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	IdempotencyKey string                    `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Payments       []*InitiatePaymentRequest `protobuf:"bytes,3,rep,name=payments,proto3" json:"payments,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InitiateBatchPaymentRequest) Reset() {
+	*x = InitiateBatchPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitiateBatchPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitiateBatchPaymentRequest) ProtoMessage() {}
+
+func (x *InitiateBatchPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateBatchPaymentRequest.ProtoReflect.Descriptor instead.
+func (*InitiateBatchPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InitiateBatchPaymentRequest) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *InitiateBatchPaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *InitiateBatchPaymentRequest) GetPayments() []*InitiatePaymentRequest {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+type BatchItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int32                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	PaymentId     string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Status        PaymentStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=payment.v1.PaymentStatus" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchItem) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *BatchItem) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *BatchItem) GetStatus() PaymentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *BatchItem) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type InitiateBatchPaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Accepted      int32                  `protobuf:"varint,3,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      int32                  `protobuf:"varint,4,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Items         []*BatchItem           `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitiateBatchPaymentResponse) Reset() {
+	*x = InitiateBatchPaymentResponse{}
+	mi := &file_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitiateBatchPaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitiateBatchPaymentResponse) ProtoMessage() {}
+
+func (x *InitiateBatchPaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateBatchPaymentResponse.ProtoReflect.Descriptor instead.
+func (*InitiateBatchPaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *InitiateBatchPaymentResponse) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *InitiateBatchPaymentResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *InitiateBatchPaymentResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *InitiateBatchPaymentResponse) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *InitiateBatchPaymentResponse) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type GetBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchRequest) Reset() {
+	*x = GetBatchRequest{}
+	mi := &file_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchRequest) ProtoMessage() {}
+
+func (x *GetBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchRequest.ProtoReflect.Descriptor instead.
+func (*GetBatchRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetBatchRequest) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+type GetBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Succeeded     int32                  `protobuf:"varint,3,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        int32                  `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	Pending       int32                  `protobuf:"varint,5,opt,name=pending,proto3" json:"pending,omitempty"`
+	Items         []*BatchItem           `protobuf:"bytes,6,rep,name=items,proto3" json:"items,omitempty"`
+	InitiatedBy   string                 `protobuf:"bytes,7,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchResponse) Reset() {
+	*x = GetBatchResponse{}
+	mi := &file_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchResponse) ProtoMessage() {}
+
+func (x *GetBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchResponse.ProtoReflect.Descriptor instead.
+func (*GetBatchResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBatchResponse) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *GetBatchResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *GetBatchResponse) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *GetBatchResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *GetBatchResponse) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *GetBatchResponse) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *GetBatchResponse) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+type SplitLeg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ToAccount     string                 `protobuf:"bytes,1,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SplitLeg) Reset() {
+	*x = SplitLeg{}
+	mi := &file_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SplitLeg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SplitLeg) ProtoMessage() {}
+
+func (x *SplitLeg) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SplitLeg.ProtoReflect.Descriptor instead.
+func (*SplitLeg) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SplitLeg) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *SplitLeg) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type InitiateSplitPaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId      string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	FromAccount    string                 `protobuf:"bytes,2,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	Currency       string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Legs           []*SplitLeg            `protobuf:"bytes,5,rep,name=legs,proto3" json:"legs,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InitiateSplitPaymentRequest) Reset() {
+	*x = InitiateSplitPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitiateSplitPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitiateSplitPaymentRequest) ProtoMessage() {}
+
+func (x *InitiateSplitPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateSplitPaymentRequest.ProtoReflect.Descriptor instead.
+func (*InitiateSplitPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *InitiateSplitPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *InitiateSplitPaymentRequest) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *InitiateSplitPaymentRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *InitiateSplitPaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *InitiateSplitPaymentRequest) GetLegs() []*SplitLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+type WatchPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPaymentRequest) Reset() {
+	*x = WatchPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPaymentRequest) ProtoMessage() {}
+
+func (x *WatchPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPaymentRequest.ProtoReflect.Descriptor instead.
+func (*WatchPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+type PaymentStatusEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Status        PaymentStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=payment.v1.PaymentStatus" json:"status,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`       // Failure reason, set for FAILED
+	Timestamp     string                 `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // RFC3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentStatusEvent) Reset() {
+	*x = PaymentStatusEvent{}
+	mi := &file_payment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentStatusEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentStatusEvent) ProtoMessage() {}
+
+func (x *PaymentStatusEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentStatusEvent.ProtoReflect.Descriptor instead.
+func (*PaymentStatusEvent) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PaymentStatusEvent) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *PaymentStatusEvent) GetStatus() PaymentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *PaymentStatusEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *PaymentStatusEvent) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
 var File_payment_proto protoreflect.FileDescriptor
 
 const file_payment_proto_rawDesc = "" +
 	"\n" +
 	"\rpayment.proto\x12\n" +
-	"payment.v1\"\xd6\x01\n" +
+	"payment.v1\x1a\x1cgoogle/api/annotations.proto\"\xd6\x01\n" +
 	"\x16InitiatePaymentRequest\x12\x1d\n" +
 	"\n" +
 	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12!\n" +
@@ -367,15 +980,16 @@ const file_payment_proto_rawDesc = "" +
 	"to_account\x18\x03 \x01(\tR\ttoAccount\x12\x16\n" +
 	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12\x1a\n" +
 	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12'\n" +
-	"\x0fidempotency_key\x18\x06 \x01(\tR\x0eidempotencyKey\"\x85\x01\n" +
+	"\x0fidempotency_key\x18\x06 \x01(\tR\x0eidempotencyKey\"\x97\x01\n" +
 	"\x17InitiatePaymentResponse\x12\x1d\n" +
 	"\n" +
 	"payment_id\x18\x01 \x01(\tR\tpaymentId\x121\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x19.payment.v1.PaymentStatusR\x06status\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"2\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x10\n" +
+	"\x03fee\x18\x04 \x01(\x01R\x03fee\"2\n" +
 	"\x11GetPaymentRequest\x12\x1d\n" +
 	"\n" +
-	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\xf6\x01\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\xd5\x02\n" +
 	"\x12GetPaymentResponse\x12\x1d\n" +
 	"\n" +
 	"payment_id\x18\x01 \x01(\tR\tpaymentId\x121\n" +
@@ -385,17 +999,71 @@ const file_payment_proto_rawDesc = "" +
 	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12!\n" +
 	"\ffrom_account\x18\x06 \x01(\tR\vfromAccount\x12\x1d\n" +
 	"\n" +
-	"to_account\x18\a \x01(\tR\ttoAccount*W\n" +
+	"to_account\x18\a \x01(\tR\ttoAccount\x12(\n" +
+	"\x04legs\x18\b \x03(\v2\x14.payment.v1.SplitLegR\x04legs\x12\x10\n" +
+	"\x03fee\x18\t \x01(\x01R\x03fee\x12!\n" +
+	"\finitiated_by\x18\n" +
+	" \x01(\tR\vinitiatedBy\"\xa1\x01\n" +
+	"\x1bInitiateBatchPaymentRequest\x12\x19\n" +
+	"\bbatch_id\x18\x01 \x01(\tR\abatchId\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12>\n" +
+	"\bpayments\x18\x03 \x03(\v2\".payment.v1.InitiatePaymentRequestR\bpayments\"\x87\x01\n" +
+	"\tBatchItem\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\x121\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x19.payment.v1.PaymentStatusR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\xb4\x01\n" +
+	"\x1cInitiateBatchPaymentResponse\x12\x19\n" +
+	"\bbatch_id\x18\x01 \x01(\tR\abatchId\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1a\n" +
+	"\baccepted\x18\x03 \x01(\x05R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x04 \x01(\x05R\brejected\x12+\n" +
+	"\x05items\x18\x05 \x03(\v2\x15.payment.v1.BatchItemR\x05items\",\n" +
+	"\x0fGetBatchRequest\x12\x19\n" +
+	"\bbatch_id\x18\x01 \x01(\tR\abatchId\"\xe3\x01\n" +
+	"\x10GetBatchResponse\x12\x19\n" +
+	"\bbatch_id\x18\x01 \x01(\tR\abatchId\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1c\n" +
+	"\tsucceeded\x18\x03 \x01(\x05R\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\x05R\x06failed\x12\x18\n" +
+	"\apending\x18\x05 \x01(\x05R\apending\x12+\n" +
+	"\x05items\x18\x06 \x03(\v2\x15.payment.v1.BatchItemR\x05items\x12!\n" +
+	"\finitiated_by\x18\a \x01(\tR\vinitiatedBy\"A\n" +
+	"\bSplitLeg\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x01 \x01(\tR\ttoAccount\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\"\xce\x01\n" +
+	"\x1bInitiateSplitPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12!\n" +
+	"\ffrom_account\x18\x02 \x01(\tR\vfromAccount\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\x12(\n" +
+	"\x04legs\x18\x05 \x03(\v2\x14.payment.v1.SplitLegR\x04legs\"4\n" +
+	"\x13WatchPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\x9c\x01\n" +
+	"\x12PaymentStatusEvent\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x121\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x19.payment.v1.PaymentStatusR\x06status\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\tR\ttimestamp*W\n" +
 	"\rPaymentStatus\x12\x1e\n" +
 	"\x1aPAYMENT_STATUS_UNSPECIFIED\x10\x00\x12\v\n" +
 	"\aPENDING\x10\x01\x12\r\n" +
 	"\tCOMPLETED\x10\x02\x12\n" +
 	"\n" +
-	"\x06FAILED\x10\x032\xb9\x01\n" +
-	"\x0ePaymentService\x12Z\n" +
-	"\x0fInitiatePayment\x12\".payment.v1.InitiatePaymentRequest\x1a#.payment.v1.InitiatePaymentResponse\x12K\n" +
+	"\x06FAILED\x10\x032\xdd\x05\n" +
+	"\x0ePaymentService\x12w\n" +
+	"\x0fInitiatePayment\x12\".payment.v1.InitiatePaymentRequest\x1a#.payment.v1.InitiatePaymentResponse\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/api/v1/payments\x12r\n" +
 	"\n" +
-	"GetPayment\x12\x1d.payment.v1.GetPaymentRequest\x1a\x1e.payment.v1.GetPaymentResponseB-Z+securepay/proto/gen/go/payment/v1;paymentv1b\x06proto3"
+	"GetPayment\x12\x1d.payment.v1.GetPaymentRequest\x1a\x1e.payment.v1.GetPaymentResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v1/payments/{payment_id}\x12\x8d\x01\n" +
+	"\x14InitiateBatchPayment\x12'.payment.v1.InitiateBatchPaymentRequest\x1a(.payment.v1.InitiateBatchPaymentResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/v1/payment-batches\x12q\n" +
+	"\bGetBatch\x12\x1b.payment.v1.GetBatchRequest\x1a\x1c.payment.v1.GetBatchResponse\"*\x82\xd3\xe4\x93\x02$\x12\"/api/v1/payment-batches/{batch_id}\x12\x87\x01\n" +
+	"\x14InitiateSplitPayment\x12'.payment.v1.InitiateSplitPaymentRequest\x1a#.payment.v1.InitiatePaymentResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v1/payments/split\x12Q\n" +
+	"\fWatchPayment\x12\x1f.payment.v1.WatchPaymentRequest\x1a\x1e.payment.v1.PaymentStatusEvent0\x01B-Z+securepay/proto/gen/go/payment/v1;paymentv1b\x06proto3"
 
 var (
 	file_payment_proto_rawDescOnce sync.Once
@@ -410,26 +1078,50 @@ func file_payment_proto_rawDescGZIP() []byte {
 }
 
 var file_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_payment_proto_goTypes = []any{
-	(PaymentStatus)(0),              // 0: payment.v1.PaymentStatus
-	(*InitiatePaymentRequest)(nil),  // 1: payment.v1.InitiatePaymentRequest
-	(*InitiatePaymentResponse)(nil), // 2: payment.v1.InitiatePaymentResponse
-	(*GetPaymentRequest)(nil),       // 3: payment.v1.GetPaymentRequest
-	(*GetPaymentResponse)(nil),      // 4: payment.v1.GetPaymentResponse
+	(PaymentStatus)(0),                   // 0: payment.v1.PaymentStatus
+	(*InitiatePaymentRequest)(nil),       // 1: payment.v1.InitiatePaymentRequest
+	(*InitiatePaymentResponse)(nil),      // 2: payment.v1.InitiatePaymentResponse
+	(*GetPaymentRequest)(nil),            // 3: payment.v1.GetPaymentRequest
+	(*GetPaymentResponse)(nil),           // 4: payment.v1.GetPaymentResponse
+	(*InitiateBatchPaymentRequest)(nil),  // 5: payment.v1.InitiateBatchPaymentRequest
+	(*BatchItem)(nil),                    // 6: payment.v1.BatchItem
+	(*InitiateBatchPaymentResponse)(nil), // 7: payment.v1.InitiateBatchPaymentResponse
+	(*GetBatchRequest)(nil),              // 8: payment.v1.GetBatchRequest
+	(*GetBatchResponse)(nil),             // 9: payment.v1.GetBatchResponse
+	(*SplitLeg)(nil),                     // 10: payment.v1.SplitLeg
+	(*InitiateSplitPaymentRequest)(nil),  // 11: payment.v1.InitiateSplitPaymentRequest
+	(*WatchPaymentRequest)(nil),          // 12: payment.v1.WatchPaymentRequest
+	(*PaymentStatusEvent)(nil),           // 13: payment.v1.PaymentStatusEvent
 }
 var file_payment_proto_depIdxs = []int32{
-	0, // 0: payment.v1.InitiatePaymentResponse.status:type_name -> payment.v1.PaymentStatus
-	0, // 1: payment.v1.GetPaymentResponse.status:type_name -> payment.v1.PaymentStatus
-	1, // 2: payment.v1.PaymentService.InitiatePayment:input_type -> payment.v1.InitiatePaymentRequest
-	3, // 3: payment.v1.PaymentService.GetPayment:input_type -> payment.v1.GetPaymentRequest
-	2, // 4: payment.v1.PaymentService.InitiatePayment:output_type -> payment.v1.InitiatePaymentResponse
-	4, // 5: payment.v1.PaymentService.GetPayment:output_type -> payment.v1.GetPaymentResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: payment.v1.InitiatePaymentResponse.status:type_name -> payment.v1.PaymentStatus
+	0,  // 1: payment.v1.GetPaymentResponse.status:type_name -> payment.v1.PaymentStatus
+	10, // 2: payment.v1.GetPaymentResponse.legs:type_name -> payment.v1.SplitLeg
+	1,  // 3: payment.v1.InitiateBatchPaymentRequest.payments:type_name -> payment.v1.InitiatePaymentRequest
+	0,  // 4: payment.v1.BatchItem.status:type_name -> payment.v1.PaymentStatus
+	6,  // 5: payment.v1.InitiateBatchPaymentResponse.items:type_name -> payment.v1.BatchItem
+	6,  // 6: payment.v1.GetBatchResponse.items:type_name -> payment.v1.BatchItem
+	10, // 7: payment.v1.InitiateSplitPaymentRequest.legs:type_name -> payment.v1.SplitLeg
+	0,  // 8: payment.v1.PaymentStatusEvent.status:type_name -> payment.v1.PaymentStatus
+	1,  // 9: payment.v1.PaymentService.InitiatePayment:input_type -> payment.v1.InitiatePaymentRequest
+	3,  // 10: payment.v1.PaymentService.GetPayment:input_type -> payment.v1.GetPaymentRequest
+	5,  // 11: payment.v1.PaymentService.InitiateBatchPayment:input_type -> payment.v1.InitiateBatchPaymentRequest
+	8,  // 12: payment.v1.PaymentService.GetBatch:input_type -> payment.v1.GetBatchRequest
+	11, // 13: payment.v1.PaymentService.InitiateSplitPayment:input_type -> payment.v1.InitiateSplitPaymentRequest
+	12, // 14: payment.v1.PaymentService.WatchPayment:input_type -> payment.v1.WatchPaymentRequest
+	2,  // 15: payment.v1.PaymentService.InitiatePayment:output_type -> payment.v1.InitiatePaymentResponse
+	4,  // 16: payment.v1.PaymentService.GetPayment:output_type -> payment.v1.GetPaymentResponse
+	7,  // 17: payment.v1.PaymentService.InitiateBatchPayment:output_type -> payment.v1.InitiateBatchPaymentResponse
+	9,  // 18: payment.v1.PaymentService.GetBatch:output_type -> payment.v1.GetBatchResponse
+	2,  // 19: payment.v1.PaymentService.InitiateSplitPayment:output_type -> payment.v1.InitiatePaymentResponse
+	13, // 20: payment.v1.PaymentService.WatchPayment:output_type -> payment.v1.PaymentStatusEvent
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_payment_proto_init() }
@@ -443,7 +1135,7 @@ func file_payment_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   4,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,16 +19,30 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PaymentService_InitiatePayment_FullMethodName = "/payment.v1.PaymentService/InitiatePayment"
-	PaymentService_GetPayment_FullMethodName      = "/payment.v1.PaymentService/GetPayment"
+	PaymentService_InitiatePayment_FullMethodName      = "/payment.v1.PaymentService/InitiatePayment"
+	PaymentService_GetPayment_FullMethodName           = "/payment.v1.PaymentService/GetPayment"
+	PaymentService_InitiateBatchPayment_FullMethodName = "/payment.v1.PaymentService/InitiateBatchPayment"
+	PaymentService_GetBatch_FullMethodName             = "/payment.v1.PaymentService/GetBatch"
+	PaymentService_InitiateSplitPayment_FullMethodName = "/payment.v1.PaymentService/InitiateSplitPayment"
+	PaymentService_WatchPayment_FullMethodName         = "/payment.v1.PaymentService/WatchPayment"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The google.api.http options define the REST API served by the API gateway.
 type PaymentServiceClient interface {
 	InitiatePayment(ctx context.Context, in *InitiatePaymentRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error)
 	GetPayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*GetPaymentResponse, error)
+	// The gateway also accepts batches as text/csv on the same route.
+	InitiateBatchPayment(ctx context.Context, in *InitiateBatchPaymentRequest, opts ...grpc.CallOption) (*InitiateBatchPaymentResponse, error)
+	GetBatch(ctx context.Context, in *GetBatchRequest, opts ...grpc.CallOption) (*GetBatchResponse, error)
+	InitiateSplitPayment(ctx context.Context, in *InitiateSplitPaymentRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error)
+	// WatchPayment sends the current status, then every transition until the
+	// payment reaches a terminal status or the client cancels. The gateway
+	// serves it as Server-Sent Events on GET /api/v1/payments/{payment_id}/events.
+	WatchPayment(ctx context.Context, in *WatchPaymentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PaymentStatusEvent], error)
 }
 
 type paymentServiceClient struct {
@@ -59,12 +73,71 @@ func (c *paymentServiceClient) GetPayment(ctx context.Context, in *GetPaymentReq
 	return out, nil
 }
 
+func (c *paymentServiceClient) InitiateBatchPayment(ctx context.Context, in *InitiateBatchPaymentRequest, opts ...grpc.CallOption) (*InitiateBatchPaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InitiateBatchPaymentResponse)
+	err := c.cc.Invoke(ctx, PaymentService_InitiateBatchPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetBatch(ctx context.Context, in *GetBatchRequest, opts ...grpc.CallOption) (*GetBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBatchResponse)
+	err := c.cc.Invoke(ctx, PaymentService_GetBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) InitiateSplitPayment(ctx context.Context, in *InitiateSplitPaymentRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InitiatePaymentResponse)
+	err := c.cc.Invoke(ctx, PaymentService_InitiateSplitPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) WatchPayment(ctx context.Context, in *WatchPaymentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PaymentStatusEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[0], PaymentService_WatchPayment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPaymentRequest, PaymentStatusEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentService_WatchPaymentClient = grpc.ServerStreamingClient[PaymentStatusEvent]
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
+//
+// The google.api.http options define the REST API served by the API gateway.
 type PaymentServiceServer interface {
 	InitiatePayment(context.Context, *InitiatePaymentRequest) (*InitiatePaymentResponse, error)
 	GetPayment(context.Context, *GetPaymentRequest) (*GetPaymentResponse, error)
+	// The gateway also accepts batches as text/csv on the same route.
+	InitiateBatchPayment(context.Context, *InitiateBatchPaymentRequest) (*InitiateBatchPaymentResponse, error)
+	GetBatch(context.Context, *GetBatchRequest) (*GetBatchResponse, error)
+	InitiateSplitPayment(context.Context, *InitiateSplitPaymentRequest) (*InitiatePaymentResponse, error)
+	// WatchPayment sends the current status, then every transition until the
+	// payment reaches a terminal status or the client cancels. The gateway
+	// serves it as Server-Sent Events on GET /api/v1/payments/{payment_id}/events.
+	WatchPayment(*WatchPaymentRequest, grpc.ServerStreamingServer[PaymentStatusEvent]) error
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -81,6 +154,18 @@ func (UnimplementedPaymentServiceServer) InitiatePayment(context.Context, *Initi
 func (UnimplementedPaymentServiceServer) GetPayment(context.Context, *GetPaymentRequest) (*GetPaymentResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetPayment not implemented")
 }
+func (UnimplementedPaymentServiceServer) InitiateBatchPayment(context.Context, *InitiateBatchPaymentRequest) (*InitiateBatchPaymentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method InitiateBatchPayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetBatch(context.Context, *GetBatchRequest) (*GetBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetBatch not implemented")
+}
+func (UnimplementedPaymentServiceServer) InitiateSplitPayment(context.Context, *InitiateSplitPaymentRequest) (*InitiatePaymentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method InitiateSplitPayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) WatchPayment(*WatchPaymentRequest, grpc.ServerStreamingServer[PaymentStatusEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchPayment not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -138,6 +223,71 @@ func _PaymentService_GetPayment_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_InitiateBatchPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitiateBatchPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).InitiateBatchPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_InitiateBatchPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).InitiateBatchPayment(ctx, req.(*InitiateBatchPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetBatch(ctx, req.(*GetBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_InitiateSplitPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitiateSplitPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).InitiateSplitPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_InitiateSplitPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).InitiateSplitPayment(ctx, req.(*InitiateSplitPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_WatchPayment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPaymentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).WatchPayment(m, &grpc.GenericServerStream[WatchPaymentRequest, PaymentStatusEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentService_WatchPaymentServer = grpc.ServerStreamingServer[PaymentStatusEvent]
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +303,25 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPayment",
 			Handler:    _PaymentService_GetPayment_Handler,
 		},
+		{
+			MethodName: "InitiateBatchPayment",
+			Handler:    _PaymentService_InitiateBatchPayment_Handler,
+		},
+		{
+			MethodName: "GetBatch",
+			Handler:    _PaymentService_GetBatch_Handler,
+		},
+		{
+			MethodName: "InitiateSplitPayment",
+			Handler:    _PaymentService_InitiateSplitPayment_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPayment",
+			Handler:       _PaymentService_WatchPayment_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
 }
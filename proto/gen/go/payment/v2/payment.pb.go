@@ -0,0 +1,728 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: payment/v2/payment.proto
+
+// Version 2 of the payments API. Amounts are integers in the minor unit of
+// their currency and payment ids are generated by the server. payment.v1
+// remains served alongside it and is translated to this API by the payment
+// service. Batches, webhooks and payment events are only available in v1.
+
+package paymentv2
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PaymentStatus int32
+
+const (
+	PaymentStatus_PAYMENT_STATUS_UNSPECIFIED PaymentStatus = 0
+	PaymentStatus_PAYMENT_STATUS_PENDING     PaymentStatus = 1
+	PaymentStatus_PAYMENT_STATUS_COMPLETED   PaymentStatus = 2
+	PaymentStatus_PAYMENT_STATUS_FAILED      PaymentStatus = 3
+)
+
+// Enum value maps for PaymentStatus.
+var (
+	PaymentStatus_name = map[int32]string{
+		0: "PAYMENT_STATUS_UNSPECIFIED",
+		1: "PAYMENT_STATUS_PENDING",
+		2: "PAYMENT_STATUS_COMPLETED",
+		3: "PAYMENT_STATUS_FAILED",
+	}
+	PaymentStatus_value = map[string]int32{
+		"PAYMENT_STATUS_UNSPECIFIED": 0,
+		"PAYMENT_STATUS_PENDING":     1,
+		"PAYMENT_STATUS_COMPLETED":   2,
+		"PAYMENT_STATUS_FAILED":      3,
+	}
+)
+
+func (x PaymentStatus) Enum() *PaymentStatus {
+	p := new(PaymentStatus)
+	*p = x
+	return p
+}
+
+func (x PaymentStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PaymentStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_v2_payment_proto_enumTypes[0].Descriptor()
+}
+
+func (PaymentStatus) Type() protoreflect.EnumType {
+	return &file_payment_v2_payment_proto_enumTypes[0]
+}
+
+func (x PaymentStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PaymentStatus.Descriptor instead.
+func (PaymentStatus) EnumDescriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{0}
+}
+
+// Money is an amount in the minor unit of its currency, e.g. 1050 with
+// currency "USD" is 10.50 USD.
+type Money struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinorUnits    int64                  `protobuf:"varint,1,opt,name=minor_units,json=minorUnits,proto3" json:"minor_units,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"` // ISO 4217 code: TRY, USD or EUR
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_payment_v2_payment_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Money) GetMinorUnits() int64 {
+	if x != nil {
+		return x.MinorUnits
+	}
+	return 0
+}
+
+func (x *Money) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type Payment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Status        PaymentStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=payment.v2.PaymentStatus" json:"status,omitempty"`
+	FromAccount   string                 `protobuf:"bytes,3,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	ToAccount     string                 `protobuf:"bytes,4,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"` // Empty for split payments
+	Amount        *Money                 `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`                        // Total of the legs for split payments
+	Fee           *Money                 `protobuf:"bytes,6,opt,name=fee,proto3" json:"fee,omitempty"`                              // Charged to the payer on top of the amount
+	Legs          []*SplitLeg            `protobuf:"bytes,7,rep,name=legs,proto3" json:"legs,omitempty"`
+	InitiatedBy   string                 `protobuf:"bytes,8,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"` // Subject of the end user who initiated the payment
+	CreateTime    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime    *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_payment_v2_payment_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Payment) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *Payment) GetStatus() PaymentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *Payment) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *Payment) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *Payment) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Payment) GetFee() *Money {
+	if x != nil {
+		return x.Fee
+	}
+	return nil
+}
+
+func (x *Payment) GetLegs() []*SplitLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+func (x *Payment) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+func (x *Payment) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Payment) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+type SplitLeg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ToAccount     string                 `protobuf:"bytes,1,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	Amount        *Money                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SplitLeg) Reset() {
+	*x = SplitLeg{}
+	mi := &file_payment_v2_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SplitLeg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SplitLeg) ProtoMessage() {}
+
+func (x *SplitLeg) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SplitLeg.ProtoReflect.Descriptor instead.
+func (*SplitLeg) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SplitLeg) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *SplitLeg) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+type CreatePaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FromAccount    string                 `protobuf:"bytes,1,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	ToAccount      string                 `protobuf:"bytes,2,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	Amount         *Money                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // Required
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreatePaymentRequest) Reset() {
+	*x = CreatePaymentRequest{}
+	mi := &file_payment_v2_payment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePaymentRequest) ProtoMessage() {}
+
+func (x *CreatePaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePaymentRequest.ProtoReflect.Descriptor instead.
+func (*CreatePaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreatePaymentRequest) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *CreatePaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type GetPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentRequest) Reset() {
+	*x = GetPaymentRequest{}
+	mi := &file_payment_v2_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentRequest) ProtoMessage() {}
+
+func (x *GetPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentRequest.ProtoReflect.Descriptor instead.
+func (*GetPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+type ListPaymentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // Defaults to 50, at most 100
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsRequest) Reset() {
+	*x = ListPaymentsRequest{}
+	mi := &file_payment_v2_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsRequest) ProtoMessage() {}
+
+func (x *ListPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListPaymentsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPaymentsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListPaymentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payments      []*Payment             `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsResponse) Reset() {
+	*x = ListPaymentsResponse{}
+	mi := &file_payment_v2_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsResponse) ProtoMessage() {}
+
+func (x *ListPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPaymentsResponse) GetPayments() []*Payment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+func (x *ListPaymentsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CreateSplitPaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FromAccount    string                 `protobuf:"bytes,1,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	Legs           []*SplitLeg            `protobuf:"bytes,2,rep,name=legs,proto3" json:"legs,omitempty"`                                           // All legs must have the same currency
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // Required
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateSplitPaymentRequest) Reset() {
+	*x = CreateSplitPaymentRequest{}
+	mi := &file_payment_v2_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSplitPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSplitPaymentRequest) ProtoMessage() {}
+
+func (x *CreateSplitPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_v2_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSplitPaymentRequest.ProtoReflect.Descriptor instead.
+func (*CreateSplitPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_v2_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateSplitPaymentRequest) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *CreateSplitPaymentRequest) GetLegs() []*SplitLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+func (x *CreateSplitPaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+var File_payment_v2_payment_proto protoreflect.FileDescriptor
+
+const file_payment_v2_payment_proto_rawDesc = "" +
+	"\n" +
+	"\x18payment/v2/payment.proto\x12\n" +
+	"payment.v2\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"D\n" +
+	"\x05Money\x12\x1f\n" +
+	"\vminor_units\x18\x01 \x01(\x03R\n" +
+	"minorUnits\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"\xb4\x03\n" +
+	"\aPayment\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x121\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x19.payment.v2.PaymentStatusR\x06status\x12!\n" +
+	"\ffrom_account\x18\x03 \x01(\tR\vfromAccount\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x04 \x01(\tR\ttoAccount\x12)\n" +
+	"\x06amount\x18\x05 \x01(\v2\x11.payment.v2.MoneyR\x06amount\x12#\n" +
+	"\x03fee\x18\x06 \x01(\v2\x11.payment.v2.MoneyR\x03fee\x12(\n" +
+	"\x04legs\x18\a \x03(\v2\x14.payment.v2.SplitLegR\x04legs\x12!\n" +
+	"\finitiated_by\x18\b \x01(\tR\vinitiatedBy\x12;\n" +
+	"\vcreate_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vupdate_time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\"T\n" +
+	"\bSplitLeg\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x01 \x01(\tR\ttoAccount\x12)\n" +
+	"\x06amount\x18\x02 \x01(\v2\x11.payment.v2.MoneyR\x06amount\"\xac\x01\n" +
+	"\x14CreatePaymentRequest\x12!\n" +
+	"\ffrom_account\x18\x01 \x01(\tR\vfromAccount\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x02 \x01(\tR\ttoAccount\x12)\n" +
+	"\x06amount\x18\x03 \x01(\v2\x11.payment.v2.MoneyR\x06amount\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"2\n" +
+	"\x11GetPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\"p\n" +
+	"\x13ListPaymentsRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"o\n" +
+	"\x14ListPaymentsResponse\x12/\n" +
+	"\bpayments\x18\x01 \x03(\v2\x13.payment.v2.PaymentR\bpayments\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x91\x01\n" +
+	"\x19CreateSplitPaymentRequest\x12!\n" +
+	"\ffrom_account\x18\x01 \x01(\tR\vfromAccount\x12(\n" +
+	"\x04legs\x18\x02 \x03(\v2\x14.payment.v2.SplitLegR\x04legs\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey*\x84\x01\n" +
+	"\rPaymentStatus\x12\x1e\n" +
+	"\x1aPAYMENT_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16PAYMENT_STATUS_PENDING\x10\x01\x12\x1c\n" +
+	"\x18PAYMENT_STATUS_COMPLETED\x10\x02\x12\x19\n" +
+	"\x15PAYMENT_STATUS_FAILED\x10\x032\xd7\x03\n" +
+	"\x0ePaymentService\x12c\n" +
+	"\rCreatePayment\x12 .payment.v2.CreatePaymentRequest\x1a\x13.payment.v2.Payment\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/api/v2/payments\x12g\n" +
+	"\n" +
+	"GetPayment\x12\x1d.payment.v2.GetPaymentRequest\x1a\x13.payment.v2.Payment\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v2/payments/{payment_id}\x12\x81\x01\n" +
+	"\fListPayments\x12\x1f.payment.v2.ListPaymentsRequest\x1a .payment.v2.ListPaymentsResponse\".\x82\xd3\xe4\x93\x02(\x12&/api/v2/accounts/{account_id}/payments\x12s\n" +
+	"\x12CreateSplitPayment\x12%.payment.v2.CreateSplitPaymentRequest\x1a\x13.payment.v2.Payment\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v2/split-paymentsB-Z+securepay/proto/gen/go/payment/v2;paymentv2b\x06proto3"
+
+var (
+	file_payment_v2_payment_proto_rawDescOnce sync.Once
+	file_payment_v2_payment_proto_rawDescData []byte
+)
+
+func file_payment_v2_payment_proto_rawDescGZIP() []byte {
+	file_payment_v2_payment_proto_rawDescOnce.Do(func() {
+		file_payment_v2_payment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_payment_v2_payment_proto_rawDesc), len(file_payment_v2_payment_proto_rawDesc)))
+	})
+	return file_payment_v2_payment_proto_rawDescData
+}
+
+var file_payment_v2_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_payment_v2_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_payment_v2_payment_proto_goTypes = []any{
+	(PaymentStatus)(0),                // 0: payment.v2.PaymentStatus
+	(*Money)(nil),                     // 1: payment.v2.Money
+	(*Payment)(nil),                   // 2: payment.v2.Payment
+	(*SplitLeg)(nil),                  // 3: payment.v2.SplitLeg
+	(*CreatePaymentRequest)(nil),      // 4: payment.v2.CreatePaymentRequest
+	(*GetPaymentRequest)(nil),         // 5: payment.v2.GetPaymentRequest
+	(*ListPaymentsRequest)(nil),       // 6: payment.v2.ListPaymentsRequest
+	(*ListPaymentsResponse)(nil),      // 7: payment.v2.ListPaymentsResponse
+	(*CreateSplitPaymentRequest)(nil), // 8: payment.v2.CreateSplitPaymentRequest
+	(*timestamppb.Timestamp)(nil),     // 9: google.protobuf.Timestamp
+}
+var file_payment_v2_payment_proto_depIdxs = []int32{
+	0,  // 0: payment.v2.Payment.status:type_name -> payment.v2.PaymentStatus
+	1,  // 1: payment.v2.Payment.amount:type_name -> payment.v2.Money
+	1,  // 2: payment.v2.Payment.fee:type_name -> payment.v2.Money
+	3,  // 3: payment.v2.Payment.legs:type_name -> payment.v2.SplitLeg
+	9,  // 4: payment.v2.Payment.create_time:type_name -> google.protobuf.Timestamp
+	9,  // 5: payment.v2.Payment.update_time:type_name -> google.protobuf.Timestamp
+	1,  // 6: payment.v2.SplitLeg.amount:type_name -> payment.v2.Money
+	1,  // 7: payment.v2.CreatePaymentRequest.amount:type_name -> payment.v2.Money
+	2,  // 8: payment.v2.ListPaymentsResponse.payments:type_name -> payment.v2.Payment
+	3,  // 9: payment.v2.CreateSplitPaymentRequest.legs:type_name -> payment.v2.SplitLeg
+	4,  // 10: payment.v2.PaymentService.CreatePayment:input_type -> payment.v2.CreatePaymentRequest
+	5,  // 11: payment.v2.PaymentService.GetPayment:input_type -> payment.v2.GetPaymentRequest
+	6,  // 12: payment.v2.PaymentService.ListPayments:input_type -> payment.v2.ListPaymentsRequest
+	8,  // 13: payment.v2.PaymentService.CreateSplitPayment:input_type -> payment.v2.CreateSplitPaymentRequest
+	2,  // 14: payment.v2.PaymentService.CreatePayment:output_type -> payment.v2.Payment
+	2,  // 15: payment.v2.PaymentService.GetPayment:output_type -> payment.v2.Payment
+	7,  // 16: payment.v2.PaymentService.ListPayments:output_type -> payment.v2.ListPaymentsResponse
+	2,  // 17: payment.v2.PaymentService.CreateSplitPayment:output_type -> payment.v2.Payment
+	14, // [14:18] is the sub-list for method output_type
+	10, // [10:14] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_payment_v2_payment_proto_init() }
+func file_payment_v2_payment_proto_init() {
+	if File_payment_v2_payment_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_v2_payment_proto_rawDesc), len(file_payment_v2_payment_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_payment_v2_payment_proto_goTypes,
+		DependencyIndexes: file_payment_v2_payment_proto_depIdxs,
+		EnumInfos:         file_payment_v2_payment_proto_enumTypes,
+		MessageInfos:      file_payment_v2_payment_proto_msgTypes,
+	}.Build()
+	File_payment_v2_payment_proto = out.File
+	file_payment_v2_payment_proto_goTypes = nil
+	file_payment_v2_payment_proto_depIdxs = nil
+}
@@ -0,0 +1,15 @@
+// Package openapi embeds the OpenAPI v3 descriptions of the REST API,
+// generated from the google.api.http annotations by protoc-gen-openapi.
+package openapi
+
+import _ "embed"
+
+// V1 is the OpenAPI document of the v1 API in YAML.
+//
+//go:embed v1/openapi.yaml
+var V1 []byte
+
+// V2 is the OpenAPI document of the v2 API in YAML.
+//
+//go:embed v2/openapi.yaml
+var V2 []byte